@@ -0,0 +1,116 @@
+// @Title  blobStore.go
+// @Description  To define the storage of the image files and select its implementation from the settings
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package blobStorage
+
+import (
+	"Flipped_Server/initialSetting"
+	"Flipped_Server/utils"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 文件不存在时返回的错误
+var ErrNotExist = errors.New("blob doesn't exist")
+
+// 文件名不合法时返回的错误
+var ErrInvalidKey = errors.New("blob key is invalid")
+
+// Object描述保存的一个文件，Key是以/分隔的相对路径
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// ObjectReader按需读取文件的内容，支持Seek以便只读取请求的范围，使用完毕后由调用者关闭
+type ObjectReader interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// BlobStore负责保存图片文件，调用者以Key区分文件，同一Key重复写入时覆盖原内容，写入是原子的
+// Open不会一次读入整个文件，用于向客户端返回图片；List返回Key以prefix开头的全部文件(包括子目录中的文件)，顺序不做保证
+type BlobStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, Object, error)
+	Open(key string) (ObjectReader, Object, error)
+	Exists(key string) (bool, error)
+	Delete(key string) error
+	List(prefix string) ([]Object, error)
+}
+
+// @title    ValidKey
+// @description   			判断Key是否是不含..、不以/开头的相对路径，防止访问存储目录之外的文件
+// @auth      郑康       	2026.10.19
+// @param     string		文件的Key
+// @return    bool
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// @title    LoadStore
+// @description   			按配置文件中storage项的type创建存储，没有该项时使用程序所在目录下的imageContainer
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    BlobStore, error	存储, 错误信息
+func LoadStore() (BlobStore, error) {
+	settings := initialSetting.StorageConfig
+	storageType, _ := settings["type"].(string)
+	switch storageType {
+	case "", "local":
+		local, _ := settings["local"].(map[string]interface{})
+		dir, _ := local["dir"].(string)
+		if dir == "" {
+			dir = "imageContainer"
+		}
+		return NewLocalStore(ResolveDir(dir)), nil
+	case "s3":
+		s3, _ := settings["s3"].(map[string]interface{})
+		config := S3Config{}
+		config.Endpoint, _ = s3["endpoint"].(string)
+		config.Region, _ = s3["region"].(string)
+		config.Bucket, _ = s3["bucket"].(string)
+		config.Prefix, _ = s3["prefix"].(string)
+		//密钥与数据库的配置一样以AESKey加密保存
+		if accessKey, _ := s3["accessKey"].(string); accessKey != "" {
+			config.AccessKey = utils.AesDecrypt(accessKey, initialSetting.AESKey)
+		}
+		if secretKey, _ := s3["secretKey"].(string); secretKey != "" {
+			config.SecretKey = utils.AesDecrypt(secretKey, initialSetting.AESKey)
+		}
+		return NewS3Store(config)
+	}
+	return nil, errors.New("unknown storage type '" + storageType + "', it should be 'local' or 's3'")
+}
+
+// @title    ResolveDir
+// @description   			将本地存储的目录转换为绝对路径，相对路径相对于程序所在的目录而不是工作目录，使旧版本保存在imageContainer下的图片不受启动位置影响
+// @auth      郑康       	2026.10.19
+// @param     string		配置的目录
+// @return    string		绝对路径
+func ResolveDir(dir string) string {
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	if executable, err := os.Executable(); err == nil {
+		return filepath.Join(filepath.Dir(executable), dir)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
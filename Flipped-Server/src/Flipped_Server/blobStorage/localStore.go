@@ -0,0 +1,162 @@
+// @Title  localStore.go
+// @Description  To store the image files in a configurable local directory
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package blobStorage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore将文件保存在Dir目录下，Key中的/对应子目录，目录在第一次写入时创建
+type LocalStore struct {
+	Dir string
+}
+
+// @title    NewLocalStore
+// @description   			创建以dir为根目录的本地存储，相对路径相对于进程的工作目录
+// @auth      郑康       	2026.10.19
+// @param     string		根目录
+// @return    *LocalStore	本地存储
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{Dir: dir}
+}
+
+func (store *LocalStore) pathOf(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(store.Dir, filepath.FromSlash(key)), nil
+}
+
+// @title    Put
+// @description   			先写入同一目录下的临时文件再重命名，不会留下写了一半的文件
+// @auth      郑康       	2026.10.19
+// @param     string, []byte	文件的Key, 文件内容
+// @return    error			错误信息
+func (store *LocalStore) Put(key string, data []byte) error {
+	path, err := store.pathOf(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
+
+func (store *LocalStore) Get(key string) ([]byte, Object, error) {
+	path, err := store.pathOf(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, Object{}, ErrNotExist
+	} else if err != nil {
+		return nil, Object{}, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return data, Object{Key: key, Size: int64(len(data)), ModTime: info.ModTime()}, nil
+}
+
+func (store *LocalStore) Open(key string) (ObjectReader, Object, error) {
+	path, err := store.pathOf(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, Object{}, ErrNotExist
+	} else if err != nil {
+		return nil, Object{}, err
+	}
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = ErrNotExist
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, Object{}, err
+	}
+	return file, Object{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (store *LocalStore) Exists(key string) (bool, error) {
+	path, err := store.pathOf(key)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+// @title    Delete
+// @description   			删除文件，文件不存在时不返回错误
+// @auth      郑康       	2026.10.19
+// @param     string		文件的Key
+// @return    error			错误信息
+func (store *LocalStore) Delete(key string) error {
+	path, err := store.pathOf(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// @title    List
+// @description   			遍历根目录，跳过写入中的临时文件，根目录不存在时返回空列表
+// @auth      郑康       	2026.10.19
+// @param     string		Key的前缀
+// @return    []Object, error	文件列表, 错误信息
+func (store *LocalStore) List(prefix string) ([]Object, error) {
+	res := []Object{}
+	err := filepath.Walk(store.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == store.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		relative, err := filepath.Rel(store.Dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(relative); strings.HasPrefix(key, prefix) {
+			res = append(res, Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	return res, err
+}
@@ -0,0 +1,104 @@
+// @Title  memoryStore.go
+// @Description  To keep the image files in memory, used by tests and local debugging
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package blobStorage
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore以Key为键在内存中保存文件
+type MemoryStore struct {
+	lock    sync.RWMutex
+	objects map[string][]byte
+	times   map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string][]byte), times: make(map[string]time.Time)}
+}
+
+func (store *MemoryStore) Put(key string, data []byte) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.objects[key] = append([]byte{}, data...)
+	store.times[key] = time.Now()
+	return nil
+}
+
+func (store *MemoryStore) Get(key string) ([]byte, Object, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	data, ok := store.objects[key]
+	if !ok {
+		return nil, Object{}, ErrNotExist
+	}
+	return append([]byte{}, data...), Object{Key: key, Size: int64(len(data)), ModTime: store.times[key]}, nil
+}
+
+// memoryReader为内存中的文件内容提供空操作的Close
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func (reader memoryReader) Close() error {
+	return nil
+}
+
+func (store *MemoryStore) Open(key string) (ObjectReader, Object, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	data, ok := store.objects[key]
+	if !ok {
+		return nil, Object{}, ErrNotExist
+	}
+	//写入时总是替换整个切片，读取期间原切片不会被修改
+	return memoryReader{bytes.NewReader(data)}, Object{Key: key, Size: int64(len(data)), ModTime: store.times[key]}, nil
+}
+
+func (store *MemoryStore) Exists(key string) (bool, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	_, ok := store.objects[key]
+	return ok, nil
+}
+
+func (store *MemoryStore) Delete(key string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.objects, key)
+	delete(store.times, key)
+	return nil
+}
+
+func (store *MemoryStore) List(prefix string) ([]Object, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []Object{}
+	for key, data := range store.objects {
+		if strings.HasPrefix(key, prefix) {
+			res = append(res, Object{Key: key, Size: int64(len(data)), ModTime: store.times[key]})
+		}
+	}
+	return res, nil
+}
+
+// Keys按字典序返回全部文件的Key，供测试检查
+func (store *MemoryStore) Keys() []string {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make([]string, 0, len(store.objects))
+	for key := range store.objects {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,407 @@
+// @Title  s3Store.go
+// @Description  To store the image files in an S3 compatible object storage such as MinIO, signing requests with AWS Signature Version 4
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package blobStorage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config描述S3兼容存储的连接参数，使用path-style地址(Endpoint/Bucket/Prefix+Key)
+type S3Config struct {
+	Endpoint  string //如http://127.0.0.1:9000
+	Region    string //为空时使用us-east-1
+	Bucket    string
+	Prefix    string //所有Key的公共前缀，可以为空
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store通过http访问S3兼容存储中的一个bucket
+type S3Store struct {
+	config   S3Config
+	endpoint *url.URL
+	Client   *http.Client
+	now      func() time.Time
+}
+
+// @title    NewS3Store
+// @description   			创建S3兼容存储，只校验配置，不访问网络
+// @auth      郑康       	2026.10.19
+// @param     S3Config		连接参数
+// @return    *S3Store, error	S3存储, 错误信息
+func NewS3Store(config S3Config) (*S3Store, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, errors.New("s3 endpoint '" + config.Endpoint + "' should be an absolute http or https url")
+	}
+	if config.Bucket == "" {
+		return nil, errors.New("s3 bucket should not be empty")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return &S3Store{
+		config:   config,
+		endpoint: endpoint,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+func (store *S3Store) Put(key string, data []byte) error {
+	resp, err := store.do("PUT", key, nil, data)
+	if err != nil {
+		return err
+	}
+	return closeResponse(resp, "PUT", key)
+}
+
+func (store *S3Store) Get(key string) ([]byte, Object, error) {
+	resp, err := store.do("GET", key, nil, nil)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, Object{}, closeResponse(resp, "GET", key)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return data, Object{Key: key, Size: int64(len(data)), ModTime: modTime}, nil
+}
+
+// @title    Open
+// @description   			以HEAD获取对象的大小和修改时间，内容在第一次Read时以Range GET从当前位置读取，Seek后重新请求
+// @auth      郑康       	2026.10.19
+// @param     string		文件的Key
+// @return    ObjectReader, Object, error	对象的读取器, 对象信息, 错误信息
+func (store *S3Store) Open(key string) (ObjectReader, Object, error) {
+	resp, err := store.do("HEAD", key, nil, nil)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, Object{}, closeResponse(resp, "HEAD", key)
+	}
+	_ = resp.Body.Close()
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	object := Object{Key: key, Size: resp.ContentLength, ModTime: modTime}
+	return &s3Reader{store: store, key: key, size: object.Size}, object, nil
+}
+
+// s3Reader从offset开始按需读取对象，body为当前Range GET的响应体
+type s3Reader struct {
+	store  *S3Store
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (reader *s3Reader) Read(p []byte) (int, error) {
+	if reader.offset >= reader.size {
+		return 0, io.EOF
+	}
+	if reader.body == nil {
+		header := http.Header{"Range": {"bytes=" + strconv.FormatInt(reader.offset, 10) + "-"}}
+		resp, err := reader.store.do("GET", reader.key, header, nil)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+			return 0, closeResponse(resp, "GET", reader.key)
+		}
+		//不支持Range的存储返回完整的对象，跳过offset之前的部分
+		if resp.StatusCode == http.StatusOK && reader.offset > 0 {
+			if _, err := io.CopyN(ioutil.Discard, resp.Body, reader.offset); err != nil {
+				_ = resp.Body.Close()
+				return 0, err
+			}
+		}
+		reader.body = resp.Body
+	}
+	n, err := reader.body.Read(p)
+	reader.offset += int64(n)
+	return n, err
+}
+
+func (reader *s3Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += reader.offset
+	case io.SeekEnd:
+		offset += reader.size
+	}
+	if offset < 0 {
+		return 0, errors.New("s3 seek " + reader.key + " to a negative position")
+	}
+	if offset != reader.offset && reader.body != nil {
+		_ = reader.body.Close()
+		reader.body = nil
+	}
+	reader.offset = offset
+	return offset, nil
+}
+
+func (reader *s3Reader) Close() error {
+	if reader.body == nil {
+		return nil
+	}
+	err := reader.body.Close()
+	reader.body = nil
+	return err
+}
+
+func (store *S3Store) Exists(key string) (bool, error) {
+	resp, err := store.do("HEAD", key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	err = closeResponse(resp, "HEAD", key)
+	if err == ErrNotExist {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// @title    Delete
+// @description   			删除对象，S3删除不存在的对象时同样返回成功
+// @auth      郑康       	2026.10.19
+// @param     string		文件的Key
+// @return    error			错误信息
+func (store *S3Store) Delete(key string) error {
+	resp, err := store.do("DELETE", key, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err = closeResponse(resp, "DELETE", key); err == ErrNotExist {
+		return nil
+	}
+	return err
+}
+
+// @title    List
+// @description   			以ListObjectsV2分页列出Prefix+prefix下的对象，返回的Key不含配置的Prefix
+// @auth      郑康       	2026.10.19
+// @param     string		Key的前缀
+// @return    []Object, error	文件列表, 错误信息
+func (store *S3Store) List(prefix string) ([]Object, error) {
+	res := []Object{}
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {store.config.Prefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := store.send("GET", "/"+store.config.Bucket, query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, closeResponse(resp, "LIST", prefix)
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range page.Contents {
+			res = append(res, Object{Key: strings.TrimPrefix(content.Key, store.config.Prefix), Size: content.Size, ModTime: content.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return res, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// listBucketResult是ListObjectsV2响应中用到的部分
+type listBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []listedObject
+}
+
+type listedObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// @title    do
+// @description   			签名并发送对一个对象的请求
+// @auth      郑康       	2026.10.19
+// @param     string, string, http.Header, []byte	http方法, 文件的Key, 额外的请求头(如Range), 请求体
+// @return    *http.Response, error	响应, 错误信息
+func (store *S3Store) do(method string, key string, header http.Header, body []byte) (*http.Response, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	return store.send(method, "/"+store.config.Bucket+"/"+store.config.Prefix+key, nil, header, body)
+}
+
+// @title    send
+// @description   			签名并发送请求，path是相对于Endpoint的路径
+// @auth      郑康       	2026.10.19
+// @param     string, string, url.Values, http.Header, []byte	http方法, 路径, 查询参数, 额外的请求头, 请求体
+// @return    *http.Response, error	响应, 错误信息
+func (store *S3Store) send(method string, path string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	target := *store.endpoint
+	target.Path = store.endpoint.Path + path
+	target.RawQuery = query.Encode()
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Type", http.DetectContentType(body))
+	}
+	signV4(req, body, store.config.AccessKey, store.config.SecretKey, store.config.Region, store.now())
+	return store.Client.Do(req)
+}
+
+// @title    closeResponse
+// @description   			关闭响应体并将状态码转换为错误，404对应ErrNotExist
+// @auth      郑康       	2026.10.19
+// @param     *http.Response, string, string	响应, http方法, 文件的Key
+// @return    error			错误信息
+func closeResponse(resp *http.Response, method string, key string) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotExist
+	}
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return errors.New("s3 " + method + " " + key + " failed with status " + strconv.Itoa(resp.StatusCode) + ": " + string(message))
+}
+
+// @title    signV4
+// @description   			按AWS Signature Version 4为请求添加x-amz-date、x-amz-content-sha256和Authorization头，签名包含host和请求中已有的全部头
+// @auth      郑康       	2026.10.19
+// @param     *http.Request, []byte, string, string, string, time.Time	请求, 请求体, AccessKey, SecretKey, 区域, 签名时间
+// @return    void
+func signV4(req *http.Request, body []byte, accessKey string, secretKey string, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); lower != "authorization" {
+			headers[lower] = strings.Join(values, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsEscapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// @title    canonicalQuery
+// @description   			按参数名排序并编码查询参数
+// @auth      郑康       	2026.10.19
+// @param     url.Values	查询参数
+// @return    string		规范化的查询字符串
+func canonicalQuery(values url.Values) string {
+	pairs := []string{}
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// @title    awsEscapePath
+// @description   			编码路径中的每一段，保留分隔符/
+// @auth      郑康       	2026.10.19
+// @param     string		路径
+// @return    string		编码后的路径
+func awsEscapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = awsEscape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// @title    awsEscape
+// @description   			按RFC 3986编码，只保留字母、数字和-._~
+// @auth      郑康       	2026.10.19
+// @param     string		原字符串
+// @return    string		编码后的字符串
+func awsEscape(s string) string {
+	var res strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' {
+			res.WriteByte(c)
+		} else {
+			res.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return res.String()
+}
@@ -0,0 +1,241 @@
+// @Title  sweeper.go
+// @Description  To reconcile the stored image files against the references in the stores, quarantining and finally removing the unreferenced ones
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package blobStorage
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/initialSetting"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 存储中保存Blob的目录, 旧版本上传的图片直接保存在存储的根目录下
+const BlobDir = "blobs"
+
+// 存储中隔离区的目录, 被隔离的文件以"quarantine/"+原Key保存
+const QuarantineDir = "quarantine"
+
+// SweeperConfig描述清理任务的参数
+type SweeperConfig struct {
+	Interval            time.Duration //两次清理的间隔
+	Grace               time.Duration //新写入的文件和图片在这段时间内不会被清理，避免与正在进行的上传冲突
+	QuarantineRetention time.Duration //文件在隔离区中保留的时间，期间重新被引用的文件会被恢复
+	Thumbnails          []string      //缩略图的名称，用于识别旧版本保存在根目录下的缩略图
+}
+
+// SweepReport是一次清理的结果
+type SweepReport struct {
+	ScannedFiles     int   `json:"scannedFiles"`
+	ReleasedImages   int   `json:"releasedImages"`
+	QuarantinedFiles int   `json:"quarantinedFiles"`
+	QuarantinedBytes int64 `json:"quarantinedBytes"`
+	RestoredFiles    int   `json:"restoredFiles"`
+	DeletedFiles     int   `json:"deletedFiles"`
+	ReclaimedBytes   int64 `json:"reclaimedBytes"`
+}
+
+// Sweeper定期对比存储中的文件与数据库中的引用，先将不再被引用的文件移入隔离区，保留一段时间后再删除
+type Sweeper struct {
+	Stores *repository.Stores
+	Blobs  BlobStore
+	Config SweeperConfig
+}
+
+// @title    DefaultSweeperConfig
+// @description   			获取默认的清理配置：每小时清理一次，宽限24小时，隔离7天
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    SweeperConfig	清理配置
+func DefaultSweeperConfig() SweeperConfig {
+	return SweeperConfig{Interval: time.Hour, Grace: 24 * time.Hour, QuarantineRetention: 7 * 24 * time.Hour}
+}
+
+// @title    LoadSweeperConfig
+// @description   			读取配置文件中storage.sweep项，缺少的参数使用默认值
+// @auth      郑康       	2026.10.19
+// @param     []string		缩略图的名称
+// @return    SweeperConfig	清理配置
+func LoadSweeperConfig(thumbnails []string) SweeperConfig {
+	config := DefaultSweeperConfig()
+	config.Thumbnails = thumbnails
+	settings, _ := initialSetting.StorageConfig["sweep"].(map[string]interface{})
+	if value, ok := settings["intervalMinutes"].(float64); ok && value > 0 {
+		config.Interval = time.Duration(value * float64(time.Minute))
+	}
+	if value, ok := settings["graceHours"].(float64); ok && value >= 0 {
+		config.Grace = time.Duration(value * float64(time.Hour))
+	}
+	if value, ok := settings["quarantineDays"].(float64); ok && value >= 0 {
+		config.QuarantineRetention = time.Duration(value * float64(24*time.Hour))
+	}
+	return config
+}
+
+// @title    BlobKey
+// @description   			获取Blob文件在存储中的Key
+// @auth      郑康       	2026.10.19
+// @param     dataBase.Blob	Blob
+// @return    string		文件的Key
+func BlobKey(blob dataBase.Blob) string {
+	return BlobDir + "/" + blob.Hash + "." + blob.Format
+}
+
+// @title    Sweep
+// @description   			执行一次清理：释放超过宽限期且不再被引用的图片，将超过宽限期且不再被引用的文件移入隔离区，恢复隔离区中重新被引用的文件，删除超过保留期的文件
+// @auth      郑康       	2026.10.19
+// @param     time.Time		当前时间
+// @return    SweepReport, error	清理结果(出错时为出错前已完成的部分), 错误信息
+func (sweeper *Sweeper) Sweep(now time.Time) (SweepReport, error) {
+	report := SweepReport{}
+	references, err := sweeper.references()
+	if err != nil {
+		return report, err
+	}
+	deadline := now.Add(-sweeper.Config.Grace)
+	images, err := sweeper.Stores.Images.ListImages()
+	if err != nil {
+		return report, err
+	}
+	for _, image := range images {
+		if references[image.ID] || time.Unix(image.CreatedAt, 0).After(deadline) {
+			continue
+		}
+		//释放后不再被引用的Blob留给下面的文件检查隔离
+		if err := sweeper.Stores.Images.ReleaseImage(image.ID); err != nil && err != dataBase.ErrImageNotFound {
+			return report, err
+		}
+		report.ReleasedImages++
+	}
+	blobs, err := sweeper.Stores.Images.ListBlobs()
+	if err != nil {
+		return report, err
+	}
+	for _, blob := range blobs {
+		references[BlobKey(blob)] = true
+	}
+
+	objects, err := sweeper.Blobs.List("")
+	if err != nil {
+		return report, err
+	}
+	for _, object := range objects {
+		report.ScannedFiles++
+		if strings.HasPrefix(object.Key, QuarantineDir+"/") {
+			err = sweeper.checkQuarantined(object, references, now, &report)
+		} else if !references[object.Key] && object.ModTime.Before(deadline) &&
+			(strings.HasPrefix(object.Key, BlobDir+"/") || !strings.Contains(object.Key, "/")) {
+			err = sweeper.move(object.Key, QuarantineDir+"/"+object.Key)
+			if err == nil {
+				report.QuarantinedFiles++
+				report.QuarantinedBytes += object.Size
+			}
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// @title    checkQuarantined
+// @description   			恢复隔离区中重新被引用的文件，删除超过保留期的文件
+// @auth      郑康       	2026.10.19
+// @param     Object, map[string]bool, time.Time, *SweepReport	隔离区中的文件, 被引用的Key, 当前时间, 清理结果
+// @return    error			错误信息
+func (sweeper *Sweeper) checkQuarantined(object Object, references map[string]bool, now time.Time, report *SweepReport) error {
+	key := strings.TrimPrefix(object.Key, QuarantineDir+"/")
+	if references[key] {
+		if err := sweeper.move(object.Key, key); err != nil {
+			return err
+		}
+		report.RestoredFiles++
+		return nil
+	}
+	//移入隔离区时文件被重新写入，修改时间即隔离的时间
+	if object.ModTime.After(now.Add(-sweeper.Config.QuarantineRetention)) {
+		return nil
+	}
+	if err := sweeper.Blobs.Delete(object.Key); err != nil {
+		return err
+	}
+	report.DeletedFiles++
+	report.ReclaimedBytes += object.Size
+	return nil
+}
+
+// @title    move
+// @description   			将文件复制到新的Key后删除原文件
+// @auth      郑康       	2026.10.19
+// @param     string, string	原Key, 新Key
+// @return    error			错误信息
+func (sweeper *Sweeper) move(from string, to string) error {
+	data, _, err := sweeper.Blobs.Get(from)
+	if err != nil {
+		return err
+	}
+	if err := sweeper.Blobs.Put(to, data); err != nil {
+		return err
+	}
+	return sweeper.Blobs.Delete(from)
+}
+
+// @title    references
+// @description   			收集相册、头像以及发送消息和提交举报时记录的图片id, 以及旧版本文件(含缩略图)在存储中的Key
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    map[string]bool, error	被引用的图片id和Key, 错误信息
+func (sweeper *Sweeper) references() (map[string]bool, error) {
+	paths, err := sweeper.Stores.Photos.References()
+	if err != nil {
+		return nil, err
+	}
+	names, err := sweeper.Stores.Images.ReferencedNames()
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, names...)
+	references := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		//旧版本保存的是文件路径，文件位于存储的根目录下
+		name := filepath.Base(path)
+		references[name] = true
+		ext := filepath.Ext(name)
+		for _, thumbnail := range sweeper.Config.Thumbnails {
+			references[strings.TrimSuffix(name, ext)+"_"+thumbnail+ext] = true
+		}
+	}
+	return references, nil
+}
+
+// @title    Run
+// @description   			每隔Interval执行一次清理并记录结果，直到stop被关闭
+// @auth      郑康       	2026.10.19
+// @param     <-chan struct{}	停止信号
+// @return    void
+func (sweeper *Sweeper) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(sweeper.Config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		report, err := sweeper.Sweep(time.Now())
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "Sweeper.Run", "sweep the image files", err.Error())
+			continue
+		}
+		logger.SetToLogger(logrus.InfoLevel, "Sweeper.Run", "sweep the image files", fmt.Sprintf(
+			"scanned: %d, released images: %d, quarantined: %d (%d bytes), restored: %d, deleted: %d, reclaimed: %d bytes",
+			report.ScannedFiles, report.ReleasedImages, report.QuarantinedFiles, report.QuarantinedBytes,
+			report.RestoredFiles, report.DeletedFiles, report.ReclaimedBytes))
+	}
+}
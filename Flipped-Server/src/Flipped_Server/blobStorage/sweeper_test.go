@@ -0,0 +1,71 @@
+package blobStorage
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/initialSetting"
+	"Flipped_Server/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSweeper(t *testing.T) {
+	stores := &repository.Stores{
+		Photos: repository.NewMemoryPhotoStore(),
+		Images: repository.NewMemoryImageStore(),
+	}
+	blobs := NewMemoryStore()
+	now := time.Now()
+	for _, id := range []string{"kept", "shared", "orphan"} {
+		blob := dataBase.Blob{Hash: id, Format: "png", Size: len(id)}
+		_ = stores.Images.AddImage(&dataBase.Image{ID: id, CreatedAt: now.Unix(), Variants: map[string]dataBase.ImageVariant{
+			dataBase.OriginalVariant: {Name: dataBase.OriginalVariant, Blob: blob},
+		}})
+		_ = blobs.Put(BlobKey(blob), []byte(id))
+	}
+	//没有对应记录的Blob以及旧版本保存在根目录下的原图和缩略图
+	for _, key := range []string{"blobs/stray.png", "old.png", "old_list.png", "gone.png", "gone_list.png"} {
+		_ = blobs.Put(key, []byte(key))
+	}
+	_ = stores.Photos.AddPhoto(&dataBase.Photo{ID: "1", Username: "MrFirst", Path: "kept"}, 6)
+	_ = stores.Photos.AddPhoto(&dataBase.Photo{ID: "2", Username: "MrFirst", Path: "./imageContainer/old.png"}, 6)
+	//发送消息时记录的引用
+	_ = stores.Images.AddReferences([]dataBase.ImageReference{{Name: "shared", Source: dataBase.ReferenceMessage, Ref: dataBase.ConversationRef("MrFirst", "MrSecond")}})
+	sweeper := &Sweeper{Stores: stores, Blobs: blobs, Config: SweeperConfig{Grace: 24 * time.Hour, QuarantineRetention: 7 * 24 * time.Hour, Thumbnails: []string{"list"}}}
+
+	//宽限期内不做任何处理
+	report, err := sweeper.Sweep(now)
+	assert.NoError(t, err)
+	assert.Equal(t, SweepReport{ScannedFiles: 8}, report)
+
+	report, err = sweeper.Sweep(now.Add(25 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, SweepReport{ScannedFiles: 8, ReleasedImages: 1, QuarantinedFiles: 4, QuarantinedBytes: 6 + 15 + 8 + 13}, report)
+	assert.Equal(t, []string{"blobs/kept.png", "blobs/shared.png", "old.png", "old_list.png",
+		"quarantine/blobs/orphan.png", "quarantine/blobs/stray.png", "quarantine/gone.png", "quarantine/gone_list.png"}, blobs.Keys())
+
+	//隔离期间重新被引用的文件会被恢复
+	_ = stores.Images.AddReferences([]dataBase.ImageReference{{Name: "gone.png", Source: dataBase.ReferenceReport, Ref: "report"}})
+	report, err = sweeper.Sweep(now.Add(26 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.RestoredFiles)
+	exists, _ := blobs.Exists("gone_list.png")
+	assert.True(t, exists)
+
+	report, err = sweeper.Sweep(now.Add(8 * 24 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.DeletedFiles)
+	assert.Equal(t, int64(6+15), report.ReclaimedBytes)
+	assert.Equal(t, []string{"blobs/kept.png", "blobs/shared.png", "gone.png", "gone_list.png", "old.png", "old_list.png"}, blobs.Keys())
+}
+
+func TestLoadSweeperConfig(t *testing.T) {
+	defer func() { initialSetting.StorageConfig = nil }()
+	assert.Equal(t, DefaultSweeperConfig(), LoadSweeperConfig(nil))
+	initialSetting.StorageConfig = map[string]interface{}{"sweep": map[string]interface{}{"intervalMinutes": 5.0, "graceHours": 0.0}}
+	config := LoadSweeperConfig([]string{"list"})
+	assert.Equal(t, 5*time.Minute, config.Interval)
+	assert.Equal(t, time.Duration(0), config.Grace)
+	assert.Equal(t, 7*24*time.Hour, config.QuarantineRetention)
+	assert.Equal(t, []string{"list"}, config.Thumbnails)
+}
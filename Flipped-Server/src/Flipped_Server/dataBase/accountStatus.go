@@ -0,0 +1,185 @@
+// @Title  accountStatus.go
+// @Description  To provide the status of the accounts (active, suspended or banned) stored in the userinfo table of mysql and the audit history of changing it to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	red "github.com/garyburd/redigo/redis"
+	"github.com/sirupsen/logrus"
+)
+
+// 账号的状态
+const (
+	AccountActive    = "active"    //正常使用
+	AccountSuspended = "suspended" //在Until之前停用
+	AccountBanned    = "banned"    //永久停用
+)
+
+// 缓存在Redis db0中的账号状态的key前缀，与token存放在同一数据库中，过期时间与token相同
+const accountStatusPrefix = "accountStatus:"
+
+// AccountStatus是账号的状态，Until只对suspended有效，Reason是展示给用户的停用原因
+type AccountStatus struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	Until    int64  `json:"until"`
+	Reason   string `json:"reason"`
+}
+
+// AccountStatusChange是账号状态的一条审计记录，From是修改前的状态，Status、Until和Reason是修改后的状态
+type AccountStatusChange struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Actor     string `json:"actor"`
+	From      string `json:"from"`
+	Status    string `json:"status"`
+	Until     int64  `json:"until"`
+	Reason    string `json:"reason"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// @title    IsRestricted
+// @description   			判断账号在给定时间是否处于停用状态，停用期限已过的suspended视为正常
+// @auth      郑康       	2026.10.19
+// @param     int64			unix时间戳(秒)
+// @return    bool			是否停用
+func (status *AccountStatus) IsRestricted(now int64) bool {
+	return status.Status == AccountBanned || (status.Status == AccountSuspended && status.Until > now)
+}
+
+// activeAccountCondition 只保留未停用账号的where条件，与IsRestricted的判断一致
+const activeAccountCondition = "NOT (status = '" + AccountBanned + "' OR (status = '" + AccountSuspended + "' AND status_until > UNIX_TIMESTAMP()))"
+
+// @title    FindAccountStatus
+// @description   			查询账号的状态
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    *AccountStatus, error	账号的状态, 错误信息，用户不存在时同样返回错误
+func FindAccountStatus(username string) (*AccountStatus, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	status := &AccountStatus{Username: username}
+	err := mysqlDB.QueryRow("SELECT status, status_until, status_reason FROM im.userinfo WHERE username = ?", username).
+		Scan(&status.Status, &status.Until, &status.Reason)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindAccountStatus", "select status from userinfo", err.Error())
+		return nil, err
+	}
+	return status, nil
+}
+
+// @title    UpdateAccountStatus
+// @description   			在一个事务中更新账号的状态并追加一条审计记录，审计记录的From由修改前的状态填写
+// @auth      郑康       	2026.10.19
+// @param     *AccountStatus, *AccountStatusChange	新的状态, 审计记录(ID、Actor和CreatedAt由调用方填写)
+// @return    error			错误信息
+func UpdateAccountStatus(status *AccountStatus, change *AccountStatusChange) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	//锁定该行，保证审计记录中修改前的状态与实际被覆盖的状态一致
+	err = tx.QueryRow("SELECT status FROM im.userinfo WHERE username = ? FOR UPDATE", status.Username).Scan(&change.From)
+	if err == sql.ErrNoRows {
+		_ = tx.Rollback()
+		return errors.New("user doesn't exist: " + status.Username)
+	} else if err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "UpdateAccountStatus", "select status from userinfo", err.Error())
+		return err
+	}
+	if _, err = tx.Exec("UPDATE im.userinfo SET status = ?, status_until = ?, status_reason = ? WHERE username = ?",
+		status.Status, status.Until, status.Reason, status.Username); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "UpdateAccountStatus", "update status of userinfo", err.Error())
+		return err
+	}
+	change.Username, change.Status, change.Until, change.Reason = status.Username, status.Status, status.Until, status.Reason
+	if _, err = tx.Exec("INSERT INTO im.account_status_change (id, username, actor, from_status, status, until, reason, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		change.ID, change.Username, change.Actor, change.From, change.Status, change.Until, change.Reason, change.CreatedAt); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "UpdateAccountStatus", "insert into account_status_change", err.Error())
+		return err
+	}
+	return tx.Commit()
+}
+
+// @title    FindAccountStatusChanges
+// @description   			按时间顺序查询账号状态的审计记录
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    []AccountStatusChange, error	审计记录, 错误信息
+func FindAccountStatusChanges(username string) ([]AccountStatusChange, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT id, username, actor, from_status, status, until, reason, created_at FROM im.account_status_change "+
+		"WHERE username = ? ORDER BY created_at, seq", username)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindAccountStatusChanges", "select from account_status_change", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	res := []AccountStatusChange{}
+	for rows.Next() {
+		var change AccountStatusChange
+		if err := rows.Scan(&change.ID, &change.Username, &change.Actor, &change.From, &change.Status, &change.Until, &change.Reason, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, change)
+	}
+	return res, rows.Err()
+}
+
+// @title    CacheAccountStatus
+// @description   			将账号的状态缓存到Redis db0中，过期时间与token相同
+// @auth      郑康       	2026.10.19
+// @param     *AccountStatus	账号的状态
+// @return    error			错误信息
+func CacheAccountStatus(status *AccountStatus) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	err = withRedisDB(0, func(conn red.Conn) error {
+		_, err := conn.Do("SET", accountStatusPrefix+status.Username, value, "EX", timeout)
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "CacheAccountStatus", "cache the status of "+status.Username, err.Error())
+	}
+	return err
+}
+
+// @title    ReadCachedAccountStatus
+// @description   			读取缓存在Redis db0中的账号状态
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    *AccountStatus, error	账号的状态(未缓存时为nil), 错误信息
+func ReadCachedAccountStatus(username string) (*AccountStatus, error) {
+	var status *AccountStatus
+	err := withRedisDB(0, func(conn red.Conn) error {
+		value, err := red.Bytes(conn.Do("GET", accountStatusPrefix+username))
+		if err == red.ErrNil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		status = &AccountStatus{}
+		return json.Unmarshal(value, status)
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "ReadCachedAccountStatus", "read the cached status of "+username, err.Error())
+		return nil, err
+	}
+	return status, nil
+}
@@ -0,0 +1,98 @@
+// @Title  blockList.go
+// @Description  To provide the block lists of users stored in MongoDB to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// 存放用户黑名单的集合名称
+const blockListCollectionName = "blockList"
+
+type blockList struct {
+	SourceUser  string   `bson:"sourceUser"`
+	BlockedList []string `bson:"blockedList"`
+}
+
+// @title    BlockUser
+// @description   			将targetUser加入sourceUser的黑名单
+// @auth      郑康       	2026.10.19
+// @param     string, string	发起拉黑的用户, 被拉黑的用户
+// @return    error			错误信息
+func BlockUser(sourceUser string, targetUser string) error {
+	_, err := currentDB.C(blockListCollectionName).Upsert(bson.M{"sourceUser": sourceUser}, bson.M{"$addToSet": bson.M{"blockedList": targetUser}})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "BlockUser", "update block list of User: "+sourceUser, err.Error())
+		return err
+	}
+	return nil
+}
+
+// @title    UnblockUser
+// @description   			将targetUser移出sourceUser的黑名单
+// @auth      郑康       	2026.10.19
+// @param     string, string	发起拉黑的用户, 被拉黑的用户
+// @return    error			错误信息
+func UnblockUser(sourceUser string, targetUser string) error {
+	err := currentDB.C(blockListCollectionName).Update(bson.M{"sourceUser": sourceUser}, bson.M{"$pull": bson.M{"blockedList": targetUser}})
+	if err != nil && err != mgo.ErrNotFound {
+		logger.SetToLogger(logrus.ErrorLevel, "UnblockUser", "update block list of User: "+sourceUser, err.Error())
+		return err
+	}
+	return nil
+}
+
+// @title    GetBlockList
+// @description   			获取用户拉黑的全部用户
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    []string, error	黑名单, 错误信息
+func GetBlockList(username string) ([]string, error) {
+	res := blockList{}
+	err := currentDB.C(blockListCollectionName).Find(bson.M{"sourceUser": username}).One(&res)
+	if err == mgo.ErrNotFound {
+		return []string{}, nil
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "GetBlockList", "find data in MongoDB", err.Error())
+		return []string{}, err
+	}
+	return res.BlockedList, nil
+}
+
+// @title    GetBlockedBy
+// @description   			获取将该用户拉黑的全部用户
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    []string, error	拉黑该用户的用户列表, 错误信息
+func GetBlockedBy(username string) ([]string, error) {
+	var lists []blockList
+	err := currentDB.C(blockListCollectionName).Find(bson.M{"blockedList": username}).Select(bson.M{"sourceUser": 1}).All(&lists)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "GetBlockedBy", "find data in MongoDB", err.Error())
+		return []string{}, err
+	}
+	res := make([]string, len(lists))
+	for i := range lists {
+		res[i] = lists[i].SourceUser
+	}
+	return res, nil
+}
+
+// @title    IsUserBlocked
+// @description   			判断sourceUser是否拉黑了targetUser
+// @auth      郑康       	2026.10.19
+// @param     string, string	发起拉黑的用户, 被拉黑的用户
+// @return    bool, error			是否拉黑, 错误信息
+func IsUserBlocked(sourceUser string, targetUser string) (bool, error) {
+	count, err := currentDB.C(blockListCollectionName).Find(bson.M{"sourceUser": sourceUser, "blockedList": targetUser}).Count()
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "IsUserBlocked", "count data in MongoDB", err.Error())
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,77 @@
+// @Title  flaggedContent.go
+// @Description  To record the user content flagged or rejected by the moderation rules in mysql for review
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// 被审核的内容的来源
+const (
+	SourceMessage = "message"
+	SourceProfile = "profile"
+)
+
+// FlaggedContent是一条被审核规则标记或拒绝的内容，Content为用户提交的原文
+// Target在聊天消息中为接收者，在资料中为字段名；Rejected表示内容是否被拒绝
+type FlaggedContent struct {
+	ID        string   `json:"id"`
+	Username  string   `json:"username"`
+	Source    string   `json:"source"`
+	Target    string   `json:"target"`
+	Content   string   `json:"content"`
+	Rules     []string `json:"rules"`
+	Rejected  bool     `json:"rejected"`
+	CreatedAt int64    `json:"createdAt"`
+}
+
+// @title    InsertFlaggedContent
+// @description   			保存一条被标记的内容
+// @auth      郑康       	2026.10.19
+// @param     *FlaggedContent	被标记的内容
+// @return    error			错误信息
+func InsertFlaggedContent(item *FlaggedContent) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	_, err := mysqlDB.Exec("INSERT INTO im.flagged_content (id, username, source, target, content, rules, rejected, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		item.ID, item.Username, item.Source, item.Target, item.Content, strings.Join(item.Rules, ","), item.Rejected, item.CreatedAt)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "InsertFlaggedContent", "insert into flagged_content", err.Error())
+	}
+	return err
+}
+
+// @title    FindFlaggedContents
+// @description   			按时间倒序查询最近被标记的内容
+// @auth      郑康       	2026.10.19
+// @param     int			最多返回的条数
+// @return    []FlaggedContent, error	被标记的内容, 错误信息
+func FindFlaggedContents(limit int) ([]FlaggedContent, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT id, username, source, target, content, rules, rejected, created_at FROM im.flagged_content "+
+		"ORDER BY created_at DESC LIMIT ?", limit)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindFlaggedContents", "select from flagged_content", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	res := []FlaggedContent{}
+	for rows.Next() {
+		var item FlaggedContent
+		var rules string
+		if err := rows.Scan(&item.ID, &item.Username, &item.Source, &item.Target, &item.Content, &rules, &item.Rejected, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.Rules = strings.Split(rules, ",")
+		res = append(res, item)
+	}
+	return res, rows.Err()
+}
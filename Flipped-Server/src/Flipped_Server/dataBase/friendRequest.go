@@ -0,0 +1,108 @@
+// @Title  friendRequest.go
+// @Description  To provide the pending friend requests stored in MongoDB to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// 存放待处理好友请求的集合名称
+const friendRequestCollectionName = "friendRequest"
+
+// FriendRequest表示SourceUser向TargetUser发出的、尚未处理的好友请求
+type FriendRequest struct {
+	SourceUser string `bson:"sourceUser" json:"sourceUser"`
+	TargetUser string `bson:"targetUser" json:"targetUser"`
+	CreatedAt  int64  `bson:"createdAt" json:"createdAt"`
+}
+
+// 好友请求已经存在或不存在时返回的错误
+var (
+	ErrFriendRequestExists   = errors.New("friend request already exists")
+	ErrFriendRequestNotFound = errors.New("friend request doesn't exist")
+)
+
+// @title    EnsureFriendRequestIndex
+// @description   			在好友请求集合上建立(sourceUser, targetUser)唯一索引，保证并发发送时不会产生重复的请求
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    error			错误信息
+func EnsureFriendRequestIndex() error {
+	err := currentDB.C(friendRequestCollectionName).EnsureIndex(mgo.Index{
+		Key:    []string{"sourceUser", "targetUser"},
+		Unique: true,
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "EnsureFriendRequestIndex", "ensure unique index of friendRequest", err.Error())
+	}
+	return err
+}
+
+// @title    InsertFriendRequest
+// @description   			保存一条好友请求
+// @auth      郑康       	2026.10.19
+// @param     *FriendRequest	好友请求
+// @return    error			错误信息，请求已经存在时为ErrFriendRequestExists
+func InsertFriendRequest(request *FriendRequest) error {
+	err := currentDB.C(friendRequestCollectionName).Insert(request)
+	if mgo.IsDup(err) {
+		return ErrFriendRequestExists
+	}
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "InsertFriendRequest", "insert into mongodb", err.Error())
+		return err
+	}
+	return nil
+}
+
+// @title    FindFriendRequests
+// @description   			按发送方或接收方查询好友请求
+// @auth      郑康       	2026.10.19
+// @param     string, string	查询字段(sourceUser或targetUser), 用户名
+// @return    []FriendRequest, error	好友请求列表, 错误信息
+func FindFriendRequests(field string, username string) ([]FriendRequest, error) {
+	res := []FriendRequest{}
+	err := currentDB.C(friendRequestCollectionName).Find(bson.M{field: username}).Sort("createdAt").All(&res)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindFriendRequests", "find data in mongodb", err.Error())
+		return []FriendRequest{}, err
+	}
+	return res, nil
+}
+
+// @title    FriendRequestExists
+// @description   			判断sourceUser是否向targetUser发出过尚未处理的好友请求
+// @auth      郑康       	2026.10.19
+// @param     string, string	发送方, 接收方
+// @return    bool			是否存在
+func FriendRequestExists(sourceUser string, targetUser string) bool {
+	count, err := currentDB.C(friendRequestCollectionName).Find(bson.M{"sourceUser": sourceUser, "targetUser": targetUser}).Count()
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FriendRequestExists", "count data in mongodb", err.Error())
+		return false
+	}
+	return count > 0
+}
+
+// @title    RemoveFriendRequest
+// @description   			删除一条好友请求，请求不存在时返回ErrFriendRequestNotFound
+// @auth      郑康       	2026.10.19
+// @param     string, string	发送方, 接收方
+// @return    error			错误信息
+func RemoveFriendRequest(sourceUser string, targetUser string) error {
+	err := currentDB.C(friendRequestCollectionName).Remove(bson.M{"sourceUser": sourceUser, "targetUser": targetUser})
+	if err == mgo.ErrNotFound {
+		return ErrFriendRequestNotFound
+	}
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "RemoveFriendRequest", "remove data from mongodb", err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// @Title  image.go
+// @Description  To provide the content addressed image blobs and their reference counts stored in mysql to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"errors"
+	"github.com/sirupsen/logrus"
+)
+
+// 图片原图对应的尺寸名称
+const OriginalVariant = "original"
+
+// Blob是以内容的sha256命名的文件，相同内容的上传只保存一份，RefCount为引用它的图片尺寸数
+type Blob struct {
+	Hash      string `json:"hash"`
+	Format    string `json:"format"`
+	Size      int    `json:"size"`
+	RefCount  int    `json:"refCount"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// ImageVariant是图片的一个尺寸(原图或缩略图)
+type ImageVariant struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Blob   Blob   `json:"blob"`
+}
+
+// Image是一张上传的图片，ID是随机生成的公开id，Variants以尺寸名称为键
+type Image struct {
+	ID        string                  `json:"id"`
+	Owner     string                  `json:"owner"`
+	CreatedAt int64                   `json:"createdAt"`
+	Variants  map[string]ImageVariant `json:"variants"`
+}
+
+// 图片不存在时返回的错误
+var ErrImageNotFound = errors.New("image doesn't exist")
+
+// @title    InsertImage
+// @description   			在一个事务中保存图片及其各尺寸，已存在的Blob引用计数加一，不存在的新建
+// @auth      郑康       	2026.10.19
+// @param     *Image		图片
+// @return    error			错误信息
+func InsertImage(image *Image) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec("INSERT INTO im.image (id, owner, created_at) VALUES (?, ?, ?)", image.ID, image.Owner, image.CreatedAt); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "InsertImage", "insert into image", err.Error())
+		return err
+	}
+	for name, variant := range image.Variants {
+		blob := variant.Blob
+		if _, err = tx.Exec("INSERT INTO im.image_blob (hash, format, size, ref_count, created_at) VALUES (?, ?, ?, 1, ?) "+
+			"ON DUPLICATE KEY UPDATE ref_count = ref_count + 1", blob.Hash, blob.Format, blob.Size, blob.CreatedAt); err != nil {
+			_ = tx.Rollback()
+			logger.SetToLogger(logrus.ErrorLevel, "InsertImage", "insert into image_blob", err.Error())
+			return err
+		}
+		if _, err = tx.Exec("INSERT INTO im.image_variant (image_id, name, blob_hash, width, height) VALUES (?, ?, ?, ?, ?)",
+			image.ID, name, blob.Hash, variant.Width, variant.Height); err != nil {
+			_ = tx.Rollback()
+			logger.SetToLogger(logrus.ErrorLevel, "InsertImage", "insert into image_variant", err.Error())
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// @title    FindImages
+// @description   			批量查询图片及其各尺寸，不存在的图片不在结果中
+// @auth      郑康       	2026.10.19
+// @param     []string		图片id列表
+// @return    map[string]Image, error	图片id与图片, 错误信息
+func FindImages(imageIDs []string) (map[string]Image, error) {
+	res := make(map[string]Image)
+	if len(imageIDs) == 0 {
+		return res, nil
+	}
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	args := make([]interface{}, len(imageIDs))
+	for i := range imageIDs {
+		args[i] = imageIDs[i]
+	}
+	rows, err := mysqlDB.Query("SELECT i.id, i.owner, i.created_at, v.name, v.width, v.height, b.hash, b.format, b.size, b.ref_count, b.created_at "+
+		"FROM im.image i JOIN im.image_variant v ON v.image_id = i.id JOIN im.image_blob b ON b.hash = v.blob_hash "+
+		"WHERE i.id IN ("+placeholders(len(imageIDs))+")", args...)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindImages", "select from image", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var image Image
+		var variant ImageVariant
+		if err := rows.Scan(&image.ID, &image.Owner, &image.CreatedAt, &variant.Name, &variant.Width, &variant.Height,
+			&variant.Blob.Hash, &variant.Blob.Format, &variant.Blob.Size, &variant.Blob.RefCount, &variant.Blob.CreatedAt); err != nil {
+			return nil, err
+		}
+		if existing, ok := res[image.ID]; ok {
+			image = existing
+		} else {
+			image.Variants = make(map[string]ImageVariant)
+		}
+		image.Variants[variant.Name] = variant
+		res[image.ID] = image
+	}
+	return res, rows.Err()
+}
+
+// @title    DeleteImage
+// @description   			在一个事务中删除图片，其各尺寸引用的Blob计数减一，计数为0的Blob记录一并删除，文件留给清理任务在宽限期后回收
+// @auth      郑康       	2026.10.19
+// @param     string		图片id
+// @return    error			错误信息，图片不存在时为ErrImageNotFound
+func DeleteImage(imageID string) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Query("SELECT blob_hash FROM im.image_variant WHERE image_id = ? FOR UPDATE", imageID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	hashes := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			_ = rows.Close()
+			_ = tx.Rollback()
+			return err
+		}
+		hashes = append(hashes, hash)
+	}
+	_ = rows.Close()
+	result, err := tx.Exec("DELETE FROM im.image WHERE id = ?", imageID)
+	if err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "DeleteImage", "delete from image", err.Error())
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		_ = tx.Rollback()
+		return ErrImageNotFound
+	}
+	for _, hash := range hashes {
+		if _, err = tx.Exec("UPDATE im.image_blob SET ref_count = ref_count - 1 WHERE hash = ?", hash); err != nil {
+			_ = tx.Rollback()
+			logger.SetToLogger(logrus.ErrorLevel, "DeleteImage", "update image_blob", err.Error())
+			return err
+		}
+		if _, err = tx.Exec("DELETE FROM im.image_blob WHERE hash = ? AND ref_count <= 0", hash); err != nil {
+			_ = tx.Rollback()
+			logger.SetToLogger(logrus.ErrorLevel, "DeleteImage", "delete from image_blob", err.Error())
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// @title    FindAllImages
+// @description   			查询全部图片的id、上传者和上传时间，不包含各尺寸
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    []Image, error	图片列表, 错误信息
+func FindAllImages() ([]Image, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT id, owner, created_at FROM im.image")
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindAllImages", "select from image", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Image{}
+	for rows.Next() {
+		var image Image
+		if err := rows.Scan(&image.ID, &image.Owner, &image.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, image)
+	}
+	return res, rows.Err()
+}
+
+// @title    FindAllBlobs
+// @description   			查询全部仍被引用的Blob
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    []Blob, error	Blob列表, 错误信息
+func FindAllBlobs() ([]Blob, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT hash, format, size, ref_count, created_at FROM im.image_blob")
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindAllBlobs", "select from image_blob", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	res := []Blob{}
+	for rows.Next() {
+		var blob Blob
+		if err := rows.Scan(&blob.Hash, &blob.Format, &blob.Size, &blob.RefCount, &blob.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, blob)
+	}
+	return res, rows.Err()
+}
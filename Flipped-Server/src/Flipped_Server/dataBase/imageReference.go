@@ -0,0 +1,115 @@
+// @Title  imageReference.go
+// @Description  To provide the references to the images from chat messages and report snapshots stored in mysql to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 引用图片的内容来源
+const (
+	ReferenceMessage = "message" //聊天消息，Ref为ConversationRef生成的会话标识
+	ReferenceReport  = "report"  //举报中引用的消息快照，Ref为举报id
+)
+
+// ImageReference表示Ref对应的消息或举报中引用了公开id(或旧版本的文件名)为Name的图片
+type ImageReference struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Ref       string `json:"ref"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// 内容中引用的图片url
+var imageURLPattern = regexp.MustCompile(`/images/([^/\s"'?#]+)`)
+
+// 图片id的最大长度，与image_reference表的列宽一致，更长的不可能是图片
+const maxImageNameLength = 100
+
+// @title    ImageNamesIn
+// @description   			提取内容中以/images/引用的图片id(或旧版本的文件名), 去除重复和过长的名称
+// @auth      郑康       	2026.10.19
+// @param     string		内容
+// @return    []string		图片id列表
+func ImageNamesIn(content string) []string {
+	res := []string{}
+	for _, match := range imageURLPattern.FindAllStringSubmatch(content, -1) {
+		duplicated := len(match[1]) > maxImageNameLength
+		for _, name := range res {
+			duplicated = duplicated || name == match[1]
+		}
+		if !duplicated {
+			res = append(res, match[1])
+		}
+	}
+	return res
+}
+
+// @title    ConversationRef
+// @description   			生成两个用户之间会话的标识, 与用户的顺序无关
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户A, 用户B
+// @return    string		会话标识
+func ConversationRef(userA string, userB string) string {
+	users := []string{userA, userB}
+	sort.Strings(users)
+	return strings.Join(users, "|")
+}
+
+// @title    InsertImageReferences
+// @description   			保存图片引用, 已存在的引用忽略
+// @auth      郑康       	2026.10.19
+// @param     []ImageReference	图片引用
+// @return    error			错误信息
+func InsertImageReferences(references []ImageReference) error {
+	if len(references) == 0 {
+		return nil
+	}
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	values := make([]string, len(references))
+	args := make([]interface{}, 0, 4*len(references))
+	for i, reference := range references {
+		values[i] = "(?, ?, ?, ?)"
+		args = append(args, reference.Name, reference.Source, reference.Ref, reference.CreatedAt)
+	}
+	_, err := mysqlDB.Exec("INSERT IGNORE INTO im.image_reference (name, source, ref, created_at) VALUES "+strings.Join(values, ", "), args...)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "InsertImageReferences", "insert into image_reference", err.Error())
+	}
+	return err
+}
+
+// @title    FindReferencedImageNames
+// @description   			查询被消息或举报引用的全部图片id(或旧版本的文件名)
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    []string, error	图片id列表, 错误信息
+func FindReferencedImageNames() ([]string, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT DISTINCT name FROM im.image_reference")
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindReferencedImageNames", "select from image_reference", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	res := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		res = append(res, name)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,172 @@
+// @Title  interestTag.go
+// @Description  To provide the interest tag catalogue and the tags of every user stored in mysql to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"database/sql"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"unicode/utf8"
+)
+
+// 标签名的最大长度(按字符计)，与tag表的列宽保持一致
+const MaxTagLength = 32
+
+// Tag是兴趣标签目录中的一项，Name已经过NormalizeTag处理
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// @title    NormalizeTag
+// @description   			规范化标签名：去掉开头的#，转为小写，连续空白替换为一个'-'，超过最大长度时返回空字符串
+// @auth      郑康       	2026.10.19
+// @param     string		标签名
+// @return    string		规范化后的标签名
+func NormalizeTag(name string) string {
+	name = strings.Join(strings.Fields(strings.ToLower(strings.TrimLeft(strings.TrimSpace(name), "#"))), "-")
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return ""
+	}
+	return name
+}
+
+// @title    placeholders
+// @description   			生成n个以逗号分隔的占位符
+// @auth      郑康       	2026.10.19
+// @param     int			占位符个数
+// @return    string		占位符
+func placeholders(n int) string {
+	return "?" + strings.Repeat(", ?", n-1)
+}
+
+// @title    scanTags
+// @description   			解析(id, name)两列的查询结果
+// @auth      郑康       	2026.10.19
+// @param     *sql.Rows		查询结果
+// @return    []Tag, error	标签列表, 错误信息
+func scanTags(rows *sql.Rows) ([]Tag, error) {
+	defer rows.Close()
+	res := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.ID, &tag.Name); err != nil {
+			return nil, err
+		}
+		res = append(res, tag)
+	}
+	return res, rows.Err()
+}
+
+// @title    SearchTags
+// @description   			按前缀查询标签目录，越短的标签越靠前，前缀为空时返回全部标签，limit不大于0时不限制数量
+// @auth      郑康       	2026.10.19
+// @param     string, int	已规范化的前缀, 最大数量
+// @return    []Tag, error	标签列表, 错误信息
+func SearchTags(prefix string, limit int) ([]Tag, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	SQL := "SELECT id, name FROM im.tag WHERE name LIKE ? ORDER BY CHAR_LENGTH(name), name"
+	args := []interface{}{escapeLike(prefix) + "%"}
+	if limit > 0 {
+		SQL += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := mysqlDB.Query(SQL, args...)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "SearchTags", "select from tag", err.Error())
+		return nil, err
+	}
+	return scanTags(rows)
+}
+
+// @title    FindTagsByNames
+// @description   			查询目录中存在的标签，不存在的标签名不在结果中
+// @auth      郑康       	2026.10.19
+// @param     []string		已规范化的标签名列表
+// @return    []Tag, error	标签列表, 错误信息
+func FindTagsByNames(names []string) ([]Tag, error) {
+	if len(names) == 0 {
+		return []Tag{}, nil
+	}
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	args := make([]interface{}, len(names))
+	for i := range names {
+		args[i] = names[i]
+	}
+	rows, err := mysqlDB.Query("SELECT id, name FROM im.tag WHERE name IN ("+placeholders(len(names))+")", args...)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindTagsByNames", "select from tag", err.Error())
+		return nil, err
+	}
+	return scanTags(rows)
+}
+
+// @title    ReplaceUserTags
+// @description   			在一个事务中用给定的标签替换用户原有的全部标签
+// @auth      郑康       	2026.10.19
+// @param     string, []int	用户名, 标签id列表
+// @return    error			错误信息
+func ReplaceUserTags(username string, tagIDs []int) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM im.user_tag WHERE username = ?", username); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "ReplaceUserTags", "delete from user_tag", err.Error())
+		return err
+	}
+	for _, tagID := range tagIDs {
+		if _, err = tx.Exec("INSERT INTO im.user_tag (username, tag_id) VALUES (?, ?)", username, tagID); err != nil {
+			_ = tx.Rollback()
+			logger.SetToLogger(logrus.ErrorLevel, "ReplaceUserTags", "insert into user_tag", err.Error())
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// @title    FindUserTags
+// @description   			批量查询用户的标签名，没有标签的用户不在结果中
+// @auth      郑康       	2026.10.19
+// @param     []string		用户名列表
+// @return    map[string][]string, error	用户名与按字典序排列的标签名, 错误信息
+func FindUserTags(usernames []string) (map[string][]string, error) {
+	res := make(map[string][]string)
+	if len(usernames) == 0 {
+		return res, nil
+	}
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	args := make([]interface{}, len(usernames))
+	for i := range usernames {
+		args[i] = usernames[i]
+	}
+	rows, err := mysqlDB.Query("SELECT ut.username, t.name FROM im.user_tag ut JOIN im.tag t ON ut.tag_id = t.id "+
+		"WHERE ut.username IN ("+placeholders(len(usernames))+") ORDER BY t.name", args...)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindUserTags", "select from user_tag", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var username, name string
+		if err := rows.Scan(&username, &name); err != nil {
+			return nil, err
+		}
+		res[username] = append(res[username], name)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,76 @@
+// @Title  location.go
+// @Description  To provide the coarse locations reported by the clients and stored in MongoDB to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// 存放用户位置的集合名称
+const locationCollectionName = "location"
+
+// Location是用户最近一次上报的位置，经纬度已经过取整，不是精确位置
+type Location struct {
+	Username  string  `bson:"username" json:"username"`
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+	UpdatedAt int64   `bson:"updatedAt" json:"updatedAt"`
+}
+
+// @title    UpsertLocation
+// @description   			保存用户的位置，覆盖之前上报的位置
+// @auth      郑康       	2026.10.19
+// @param     *Location		位置
+// @return    error			错误信息
+func UpsertLocation(location *Location) error {
+	_, err := currentDB.C(locationCollectionName).Upsert(bson.M{"username": location.Username}, location)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "UpsertLocation", "upsert into mongodb", err.Error())
+		return err
+	}
+	return nil
+}
+
+// @title    FindLocations
+// @description   			批量查询用户的位置，没有上报过位置的用户不在结果中
+// @auth      郑康       	2026.10.19
+// @param     []string		用户名列表
+// @return    []Location, error	位置列表, 错误信息
+func FindLocations(usernames []string) ([]Location, error) {
+	res := []Location{}
+	if len(usernames) == 0 {
+		return res, nil
+	}
+	err := currentDB.C(locationCollectionName).Find(bson.M{"username": bson.M{"$in": usernames}}).All(&res)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindLocations", "find data in mongodb", err.Error())
+		return []Location{}, err
+	}
+	return res, nil
+}
+
+// @title    FindUsersWithin
+// @description   			查询位置在经纬度范围内的全部用户
+// @auth      郑康       	2026.10.19
+// @param     float64, float64, float64, float64	最小纬度, 最大纬度, 最小经度, 最大经度
+// @return    []string, error	用户名列表, 错误信息
+func FindUsersWithin(minLatitude float64, maxLatitude float64, minLongitude float64, maxLongitude float64) ([]string, error) {
+	locations := []Location{}
+	err := currentDB.C(locationCollectionName).Find(bson.M{
+		"latitude":  bson.M{"$gte": minLatitude, "$lte": maxLatitude},
+		"longitude": bson.M{"$gte": minLongitude, "$lte": maxLongitude},
+	}).Select(bson.M{"username": 1}).All(&locations)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindUsersWithin", "find data in mongodb", err.Error())
+		return []string{}, err
+	}
+	res := make([]string, len(locations))
+	for i := range locations {
+		res[i] = locations[i].Username
+	}
+	return res, nil
+}
@@ -0,0 +1,143 @@
+// @Title  message.go
+// @Description  To provide the chat history stored in MongoDB to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Message表示SourceUser发送给TargetUser的一条聊天消息
+type Message struct {
+	SourceUser string `bson:"sourceUser" json:"sourceUser"`
+	TargetUser string `bson:"targetUser" json:"targetUser"`
+	Content    string `bson:"content" json:"content"`
+	CreatedAt  int64  `bson:"createdAt" json:"createdAt"`
+}
+
+// MessagePair表示SourceUser曾向TargetUser发送过消息
+type MessagePair struct {
+	SourceUser string `bson:"sourceUser" json:"sourceUser"`
+	TargetUser string `bson:"targetUser" json:"targetUser"`
+}
+
+// @title    conversationSelector
+// @description   			生成匹配两个用户之间全部消息的查询条件
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户A, 用户B
+// @return    bson.M		查询条件
+func conversationSelector(userA string, userB string) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"sourceUser": userA, "targetUser": userB},
+		{"sourceUser": userB, "targetUser": userA},
+	}}
+}
+
+// @title    InsertMessage
+// @description   			保存一条聊天消息
+// @auth      郑康       	2026.10.19
+// @param     *Message		聊天消息
+// @return    error			错误信息
+func InsertMessage(message *Message) error {
+	err := currentDB.C(msgCollectionName).Insert(message)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "InsertMessage", "insert into mongodb", err.Error())
+		return err
+	}
+	return nil
+}
+
+// @title    FindMessages
+// @description   			查询两个用户之间最近的若干条消息
+// @auth      郑康       	2026.10.19
+// @param     string, string, int	用户A, 用户B, 最多返回的条数
+// @return    []Message, error	消息列表(按发送时间从旧到新), 错误信息
+func FindMessages(userA string, userB string, limit int) ([]Message, error) {
+	res := []Message{}
+	err := currentDB.C(msgCollectionName).Find(conversationSelector(userA, userB)).Sort("-createdAt", "-_id").Limit(limit).All(&res)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindMessages", "find data in mongodb", err.Error())
+		return []Message{}, err
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
+
+// @title    FindLastMessages
+// @description   			通过一次聚合查询username与每个partner之间的最后一条消息
+// @auth      郑康       	2026.10.19
+// @param     string, []string	用户名, 对方的用户名列表
+// @return    map[string]Message, error	对方的用户名与最后一条消息(没有消息的用户不在其中), 错误信息
+func FindLastMessages(username string, partners []string) (map[string]Message, error) {
+	res := make(map[string]Message, len(partners))
+	if len(partners) == 0 {
+		return res, nil
+	}
+	groups := []struct {
+		Partner string  `bson:"_id"`
+		Message Message `bson:"message"`
+	}{}
+	err := currentDB.C(msgCollectionName).Pipe([]bson.M{
+		{"$match": bson.M{"$or": []bson.M{
+			{"sourceUser": username, "targetUser": bson.M{"$in": partners}},
+			{"sourceUser": bson.M{"$in": partners}, "targetUser": username},
+		}}},
+		{"$sort": bson.D{{Name: "createdAt", Value: -1}, {Name: "_id", Value: -1}}},
+		{"$group": bson.M{
+			"_id":     bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{"$sourceUser", username}}, "$targetUser", "$sourceUser"}},
+			"message": bson.M{"$first": "$$ROOT"},
+		}},
+	}).All(&groups)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindLastMessages", "aggregate data in mongodb", err.Error())
+		return res, err
+	}
+	for i := range groups {
+		res[groups[i].Partner] = groups[i].Message
+	}
+	return res, nil
+}
+
+// @title    FindMessagePairs
+// @description   			统计since(Unix时间戳, 秒)之后发送过消息的全部(发送方, 接收方)组合，用于离线计算推荐模型
+// @auth      郑康       	2026.10.19
+// @param     int64			起始时间
+// @return    []MessagePair, error	发送方与接收方的组合, 错误信息
+func FindMessagePairs(since int64) ([]MessagePair, error) {
+	groups := []struct {
+		ID MessagePair `bson:"_id"`
+	}{}
+	err := currentDB.C(msgCollectionName).Pipe([]bson.M{
+		{"$match": bson.M{"createdAt": bson.M{"$gte": since}}},
+		{"$group": bson.M{"_id": bson.M{"sourceUser": "$sourceUser", "targetUser": "$targetUser"}}},
+	}).All(&groups)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindMessagePairs", "aggregate data in mongodb", err.Error())
+		return []MessagePair{}, err
+	}
+	res := make([]MessagePair, len(groups))
+	for i := range groups {
+		res[i] = groups[i].ID
+	}
+	return res, nil
+}
+
+// @title    FindMessagesSentAt
+// @description   			查询sourceUser在createdAt(Unix时间戳, 秒)这一秒内发送给targetUser的消息
+// @auth      郑康       	2026.10.19
+// @param     string, string, int64	发送方, 接收方, 发送时间
+// @return    []Message, error	消息列表, 错误信息
+func FindMessagesSentAt(sourceUser string, targetUser string, createdAt int64) ([]Message, error) {
+	res := []Message{}
+	err := currentDB.C(msgCollectionName).Find(bson.M{"sourceUser": sourceUser, "targetUser": targetUser, "createdAt": createdAt}).Sort("_id").All(&res)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindMessagesSentAt", "find data in mongodb", err.Error())
+		return []Message{}, err
+	}
+	return res, nil
+}
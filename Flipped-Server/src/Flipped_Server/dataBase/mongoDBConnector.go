@@ -22,8 +22,8 @@ var (
 	mongoPort      string
 	mongoUser      string
 	mongoPassword  string
-	collectionName string
-	//msgCollectionName string
+	collectionName    string
+	msgCollectionName string
 )
 
 var (
@@ -51,7 +51,7 @@ func initialSettingsMongo() {
 	mongoUser = utils.AesDecrypt(mongoSettings["userName"].(string), initialSetting.AESKey)
 	mongoPassword = utils.AesDecrypt(mongoSettings["pwd"].(string), initialSetting.AESKey)
 	collectionName = utils.AesDecrypt(mongoSettings["friendCollectionName"].(string), initialSetting.AESKey)
-	//msgCollectionName = mongoSettings["msgCollectionName"].(string)
+	msgCollectionName = utils.AesDecrypt(mongoSettings["msgCollectionName"].(string), initialSetting.AESKey)
 }
 
 func InitializeMongoDB() {
@@ -77,6 +77,7 @@ func InitializeMongoDB() {
 	currentDB = session.DB("im")
 	currentCollection = currentDB.C(collectionName)
 	session.SetPoolLimit(5)
+	_ = EnsureFriendRequestIndex()
 }
 
 func GetFriendListByUserName(username string) ([]string, error) {
@@ -159,6 +160,36 @@ func DeleteFriend(sourceUser string, targetUser string) error {
 	return nil
 }
 
+// @title    AddMutualFriends
+// @description   			在两个用户的friendMap文档中互相添加对方为好友, 两次更新不在同一事务中,
+// 第二次更新失败时只撤销本次调用新添加的好友关系, 调用前已存在的单向关系保持不变
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户A, 用户B
+// @return    error			错误信息
+func AddMutualFriends(userA string, userB string) error {
+	existed, err := currentCollection.Find(bson.M{"sourceUser": userA, "friendList": userB}).Count()
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "AddMutualFriends", "find friends of User: "+userA, err.Error())
+		return err
+	}
+	_, err = currentCollection.Upsert(bson.M{"sourceUser": userA}, bson.M{"$addToSet": bson.M{"friendList": userB}})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "AddMutualFriends", "update friends of User: "+userA, err.Error())
+		return err
+	}
+	_, err = currentCollection.Upsert(bson.M{"sourceUser": userB}, bson.M{"$addToSet": bson.M{"friendList": userA}})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "AddMutualFriends", "update friends of User: "+userB, err.Error())
+		if existed == 0 {
+			if pullErr := currentCollection.Update(bson.M{"sourceUser": userA}, bson.M{"$pull": bson.M{"friendList": userB}}); pullErr != nil {
+				logger.SetToLogger(logrus.ErrorLevel, "AddMutualFriends", "revert friends of User: "+userA, pullErr.Error())
+			}
+		}
+		return err
+	}
+	return nil
+}
+
 //func WriteMessage(sourceUser string, targetUser string, content string) error {
 //	curRecorder := utils.Recorder{SourceUser: sourceUser, Content: content, TargetUser: targetUser}
 //	currentCollection = currentDB.C(msgCollectionName)
@@ -14,6 +14,7 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
 	_ "github.com/widuu/gojson"
+	"strings"
 )
 
 // 包内全局变量，存放于数据库的指针
@@ -92,6 +93,15 @@ func Init() {
 	mysqlDB = database
 }
 
+// @title    GetMySqlClient
+// @description   			获取mysql数据库连接，尚未初始化时返回nil
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    *sql.DB		数据库连接
+func GetMySqlClient() *sql.DB {
+	return mysqlDB
+}
+
 // @title    ExecSQL
 // @description   			执行给定(insert、 update、delete)的SQL语句
 // @auth      郑康       	2020.5.17
@@ -119,30 +129,29 @@ func ExecSQL(sql string) (string, error) {
 // @title    ExecSelectSQL
 // @description   			执行给定(select)的SQL语句
 // @auth      郑康       	2020.5.25
-// @param     string		sql语句字符串
-// @return    []*UserInfoTable 根据sql语句选择出的用户信息表
-func ExecSelectSQL(sql string) []*UserInfoTable {
-	rows, err := mysqlDB.Query(sql)
-
-	if err != nil {
-		fmt.Println(err.Error())
+// @param     string, ...interface{}		sql语句字符串, 语句中占位符对应的参数
+// @return    []*UserInfoTable, error 根据sql语句选择出的用户信息表, 查询或解析失败时的错误信息
+func ExecSelectSQL(sql string, args ...interface{}) ([]*UserInfoTable, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
 	}
-	if rows == nil {
-		return nil
+	rows, err := mysqlDB.Query(sql, args...)
+	if err != nil {
+		return nil, err
 	}
-	userInfoList := rowsMapper(rows)
-	return userInfoList
+	defer rows.Close()
+	return rowsMapper(rows)
 }
 
 // @title    rowsMapper
 // @description   			解析select结果
 // @auth      郑康       	2020.5.25
 // @param     string		select结果
-// @return    []*UserInfoTable 根据解析select结果构成的用户信息表
-func rowsMapper(rows *sql.Rows) []*UserInfoTable {
+// @return    []*UserInfoTable, error 根据解析select结果构成的用户信息表, 错误信息
+func rowsMapper(rows *sql.Rows) ([]*UserInfoTable, error) {
 	columns, err := rows.Columns()
 	if err != nil {
-		fmt.Println(err.Error())
+		return nil, err
 	}
 	values := make([]sql.RawBytes, len(columns))
 	scanArgs := make([]interface{}, len(values))
@@ -156,7 +165,7 @@ func rowsMapper(rows *sql.Rows) []*UserInfoTable {
 	for rows.Next() {
 		err = rows.Scan(scanArgs...)
 		if err != nil {
-			fmt.Println(err.Error())
+			return nil, err
 		}
 		rowMap := make(map[string]string)
 		var value string
@@ -171,36 +180,7 @@ func rowsMapper(rows *sql.Rows) []*UserInfoTable {
 		userInfo, _ = MakeUserInfoStruct(&rowMap)
 		res = append(res, userInfo)
 	}
-	return res
-}
-
-func SelectSimilarUser(username string) (*UserInfoTable, error) {
-	SQL := "Select * From im.userinfo Order By Rand() Limit 20"
-	currentUser, err := FindUserInfo(username, "")
-	if err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "SelectSimilarUser", "to find user by username: "+username, err.Error())
-		return nil, err
-	}
-	userList := ExecSelectSQL(SQL)
-	userSimilarMap := make(map[int]float32)
-	maxSimilarityIndex, maxSimilarValue := 0, float32(0.0)
-	curFriendList, err := GetFriendListByUserName(username)
-	if err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "SelectSimilarUser", "error to get friend list of "+username, err.Error())
-		return nil, err
-	}
-	for index := range userList {
-		if userList[index].Username == username || utils.Contains(curFriendList, userList[index].Username) {
-			continue
-		}
-		res := CalculateSimilarity(currentUser, userList[index])
-		if res > maxSimilarValue {
-			maxSimilarValue = res
-			maxSimilarityIndex = index
-		}
-		userSimilarMap[index] = res
-	}
-	return userList[maxSimilarityIndex], nil
+	return res, rows.Err()
 }
 
 // @title    	FindUserInfo
@@ -216,13 +196,33 @@ func FindUserInfo(username string, pwd string) (*UserInfoTable, error) {
 		SQL += ";"
 	}
 	fmt.Println(SQL)
-	data := ExecSelectSQL(SQL)
-	if data == nil || len(data) != 1 {
+	data, err := ExecSelectSQL(SQL)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 1 {
 		return nil, errors.New("the data you select is nil or has repetitive")
 	}
 	return data[0], nil
 }
 
+// @title    	FindUsersByNames
+// @description   								通过一条查询语句批量获取多个用户的完整信息
+// @auth      	郑康           					2026.10.19
+// @param     	[]string						用户名列表
+// @return    	[]*dataBase.UserInfoTable, error		用户信息列表(顺序与数据库返回顺序一致), 错误信息
+func FindUsersByNames(usernames []string) ([]*UserInfoTable, error) {
+	if len(usernames) == 0 {
+		return []*UserInfoTable{}, nil
+	}
+	args := make([]interface{}, len(usernames))
+	for i := range usernames {
+		args[i] = usernames[i]
+	}
+	SQL := "SELECT * FROM im.userinfo WHERE username IN (?" + strings.Repeat(", ?", len(usernames)-1) + ")"
+	return ExecSelectSQL(SQL, args...)
+}
+
 // @title    CLoseMySqlClient
 // @description   			关闭mysql连接
 // @auth      郑康       	2020.5.26
@@ -0,0 +1,244 @@
+// @Title  photo.go
+// @Description  To provide the metadata of the photos in the profile gallery stored in mysql to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"database/sql"
+	"errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Photo是用户相册中的一张照片，Position从0开始表示展示顺序，每个用户最多有一张主照片
+// Path是照片对应图片的公开id，引入图片存储之前上传的照片为文件路径
+type Photo struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Path      string `json:"-"`
+	Position  int    `json:"position"`
+	Primary   bool   `json:"primary"`
+	Size      int    `json:"size"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// 照片不存在或不属于该用户时返回的错误
+var ErrPhotoNotFound = errors.New("photo doesn't exist")
+
+// 相册中的照片数已达上限时返回的错误
+var ErrGalleryFull = errors.New("the gallery is full")
+
+const photoColumns = "id, username, path, position, is_primary, size, created_at"
+
+// @title    scanPhotos
+// @description   			解析photoColumns对应的查询结果
+// @auth      郑康       	2026.10.19
+// @param     *sql.Rows		查询结果
+// @return    []Photo, error	照片列表, 错误信息
+func scanPhotos(rows *sql.Rows) ([]Photo, error) {
+	defer rows.Close()
+	res := []Photo{}
+	for rows.Next() {
+		var photo Photo
+		if err := rows.Scan(&photo.ID, &photo.Username, &photo.Path, &photo.Position, &photo.Primary, &photo.Size, &photo.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, photo)
+	}
+	return res, rows.Err()
+}
+
+// @title    InsertPhoto
+// @description   			在锁定用户行的事务中统计相册照片数, 未达到limit时将照片添加到相册末尾(Position由此处设置)
+// @auth      郑康       	2026.10.19
+// @param     *Photo, int		照片, 相册中最多的照片数
+// @return    error			错误信息(相册已满时为ErrGalleryFull)
+func InsertPhoto(photo *Photo, limit int) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	//锁定userinfo中的用户行, 使同一用户并发的添加依次统计和插入
+	var pid int
+	if err = tx.QueryRow("SELECT pid FROM im.userinfo WHERE username = ? FOR UPDATE", photo.Username).Scan(&pid); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "InsertPhoto", "lock the user in userinfo", err.Error())
+		return err
+	}
+	var count int
+	if err = tx.QueryRow("SELECT COUNT(*) FROM im.user_photo WHERE username = ?", photo.Username).Scan(&count); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "InsertPhoto", "count photos in user_photo", err.Error())
+		return err
+	}
+	if count >= limit {
+		_ = tx.Rollback()
+		return ErrGalleryFull
+	}
+	photo.Position = count
+	_, err = tx.Exec("INSERT INTO im.user_photo ("+photoColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		photo.ID, photo.Username, photo.Path, photo.Position, photo.Primary, photo.Size, photo.CreatedAt)
+	if err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "InsertPhoto", "insert into user_photo", err.Error())
+		return err
+	}
+	return tx.Commit()
+}
+
+// @title    FindPhotos
+// @description   			批量查询用户的相册，每个相册按Position排列，没有照片的用户不在结果中
+// @auth      郑康       	2026.10.19
+// @param     []string		用户名列表
+// @return    map[string][]Photo, error	用户名与相册, 错误信息
+func FindPhotos(usernames []string) (map[string][]Photo, error) {
+	res := make(map[string][]Photo)
+	if len(usernames) == 0 {
+		return res, nil
+	}
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	args := make([]interface{}, len(usernames))
+	for i := range usernames {
+		args[i] = usernames[i]
+	}
+	rows, err := mysqlDB.Query("SELECT "+photoColumns+" FROM im.user_photo WHERE username IN ("+placeholders(len(usernames))+") ORDER BY position", args...)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindPhotos", "select from user_photo", err.Error())
+		return nil, err
+	}
+	photos, err := scanPhotos(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, photo := range photos {
+		res[photo.Username] = append(res[photo.Username], photo)
+	}
+	return res, nil
+}
+
+// @title    DeletePhoto
+// @description   			删除用户的一张照片，并将其后的照片依次前移
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户名, 照片id
+// @return    error			错误信息，照片不存在时为ErrPhotoNotFound
+func DeletePhoto(username string, photoID string) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	var position int
+	err = tx.QueryRow("SELECT position FROM im.user_photo WHERE id = ? AND username = ? FOR UPDATE", photoID, username).Scan(&position)
+	if err == sql.ErrNoRows {
+		_ = tx.Rollback()
+		return ErrPhotoNotFound
+	} else if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM im.user_photo WHERE id = ?", photoID); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "DeletePhoto", "delete from user_photo", err.Error())
+		return err
+	}
+	if _, err = tx.Exec("UPDATE im.user_photo SET position = position - 1 WHERE username = ? AND position > ?", username, position); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "DeletePhoto", "update positions in user_photo", err.Error())
+		return err
+	}
+	return tx.Commit()
+}
+
+// @title    UpdatePhotoPositions
+// @description   			按给定的照片id顺序重新设置用户全部照片的Position
+// @auth      郑康       	2026.10.19
+// @param     string, []string	用户名, 照片id列表
+// @return    error			错误信息
+func UpdatePhotoPositions(username string, photoIDs []string) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	for position, photoID := range photoIDs {
+		result, err := tx.Exec("UPDATE im.user_photo SET position = ? WHERE id = ? AND username = ?", position, photoID, username)
+		if err != nil {
+			_ = tx.Rollback()
+			logger.SetToLogger(logrus.ErrorLevel, "UpdatePhotoPositions", "update user_photo", err.Error())
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			var exists int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM im.user_photo WHERE id = ? AND username = ?", photoID, username).Scan(&exists); err != nil || exists == 0 {
+				_ = tx.Rollback()
+				return ErrPhotoNotFound
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// @title    SetPrimaryPhoto
+// @description   			将用户的一张照片设为主照片，同时取消其他照片的主照片标记
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户名, 照片id
+// @return    error			错误信息，照片不存在时为ErrPhotoNotFound
+func SetPrimaryPhoto(username string, photoID string) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	var count int
+	if err = tx.QueryRow("SELECT COUNT(*) FROM im.user_photo WHERE id = ? AND username = ?", photoID, username).Scan(&count); err != nil || count == 0 {
+		_ = tx.Rollback()
+		if err == nil {
+			err = ErrPhotoNotFound
+		}
+		return err
+	}
+	if _, err = tx.Exec("UPDATE im.user_photo SET is_primary = (id = ?) WHERE username = ?", photoID, username); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "SetPrimaryPhoto", "update user_photo", err.Error())
+		return err
+	}
+	return tx.Commit()
+}
+
+// @title    FindAllPhotoReferences
+// @description   			查询相册照片和用户头像引用的全部图片id(或旧版本的文件路径)，用于清理不再被引用的图片
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    []string, error	图片id或文件路径列表, 错误信息
+func FindAllPhotoReferences() ([]string, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT path FROM im.user_photo UNION SELECT photo FROM im.userinfo WHERE photo <> ''")
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindAllPhotoReferences", "select from user_photo and userinfo", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	res := []string{}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		res = append(res, path)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,99 @@
+// @Title  recommendCandidate.go
+// @Description  To provide the candidate pool of the recommendation selected from mysql to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/utils"
+	"hash/crc32"
+	"strings"
+)
+
+// CandidateQuery描述推荐的候选池，MaxAge为0时不限制年龄(未填写年龄的用户总是满足)，Included为nil时不限制范围，停用的账号不属于候选池
+type CandidateQuery struct {
+	MinAge   int
+	MaxAge   int
+	Included []string //不为nil时只在这些用户中选择，如位于距离范围内的用户
+	Excluded []string
+	Seed     string //抽样的种子，候选用户按用户名与种子的CRC32排列后取前Limit个，种子相同时结果稳定
+	Limit    int
+}
+
+// @title    Matches
+// @description   			判断用户是否属于候选池
+// @auth      郑康       	2026.10.19
+// @param     *UserInfoTable	用户信息
+// @return    bool			是否属于
+func (query *CandidateQuery) Matches(user *UserInfoTable) bool {
+	if query.Included != nil && !utils.Contains(query.Included, user.Username) {
+		return false
+	}
+	if utils.Contains(query.Excluded, user.Username) {
+		return false
+	}
+	if query.MaxAge > 0 && user.Age != 0 && (user.Age < query.MinAge || user.Age > query.MaxAge) {
+		return false
+	}
+	return true
+}
+
+// @title    SampleKey
+// @description   			获取用户在候选池抽样中的排序键，与BuildCandidateSQL中mysql的CRC32一致
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    uint32		排序键
+func (query *CandidateQuery) SampleKey(username string) uint32 {
+	return crc32.ChecksumIEEE([]byte(username + query.Seed))
+}
+
+// @title    BuildCandidateSQL
+// @description   			根据候选池条件拼接带占位符的select语句, 按SampleKey在整张表中抽样, 不偏向注册时间或用户名
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    string, []interface{}	sql语句, 占位符参数
+func (query *CandidateQuery) BuildCandidateSQL() (string, []interface{}) {
+	conditions := []string{activeAccountCondition}
+	var args []interface{}
+	if query.Included != nil {
+		if len(query.Included) == 0 {
+			conditions = append(conditions, "FALSE")
+		} else {
+			conditions = append(conditions, "username IN ("+placeholders(len(query.Included))+")")
+			for i := range query.Included {
+				args = append(args, query.Included[i])
+			}
+		}
+	}
+	if len(query.Excluded) > 0 {
+		conditions = append(conditions, "username NOT IN ("+placeholders(len(query.Excluded))+")")
+		for i := range query.Excluded {
+			args = append(args, query.Excluded[i])
+		}
+	}
+	if query.MaxAge > 0 {
+		conditions = append(conditions, "(age = 0 OR age BETWEEN ? AND ?)")
+		args = append(args, query.MinAge, query.MaxAge)
+	}
+	SQL := "SELECT * FROM im.userinfo WHERE " + strings.Join(conditions, " AND ")
+	SQL += " ORDER BY CRC32(CONCAT(username, ?)), pid LIMIT ?"
+	args = append(args, query.Seed, query.Limit)
+	return SQL, args
+}
+
+// @title    FindCandidates
+// @description   			在mysql中查询推荐的候选池
+// @auth      郑康       	2026.10.19
+// @param     *CandidateQuery	候选池条件
+// @return    []*UserInfoTable, error	用户列表, 错误信息
+func FindCandidates(query *CandidateQuery) ([]*UserInfoTable, error) {
+	SQL, args := query.BuildCandidateSQL()
+	res, err := ExecSelectSQL(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return []*UserInfoTable{}, nil
+	}
+	return res, nil
+}
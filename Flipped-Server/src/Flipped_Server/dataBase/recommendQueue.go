@@ -0,0 +1,137 @@
+// @Title  recommendQueue.go
+// @Description  To provide the precomputed recommendation queues stored in Redis db3 to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	red "github.com/garyburd/redigo/redis"
+	"github.com/sirupsen/logrus"
+	"strconv"
+)
+
+// 推荐队列存放于Redis db3，键为前缀加用户名，值为按推荐顺序排列的用户名列表
+const (
+	recommendQueueDB     = 3
+	recommendQueuePrefix = "recommend:"
+	recommendQueueExpire = 24 * 3600
+)
+
+// @title    withRedisDB
+// @description   			从连接池获取连接并切换到指定的数据库执行fn，结束后切换回db0再归还连接，避免影响其他使用db0的操作
+// @auth      郑康       	2026.10.19
+// @param     int, func(red.Conn) error	数据库编号, 需要执行的操作
+// @return    error			错误信息
+func withRedisDB(dbNum int, fn func(conn red.Conn) error) error {
+	conn := redis.pool.Get()
+	if err := conn.Err(); err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Do("SELECT", dbNum); err != nil {
+		return err
+	}
+	defer conn.Do("SELECT", 0)
+	return fn(conn)
+}
+
+// @title    ReplaceRecommendQueue
+// @description   			在一个事务中用新的推荐列表替换用户的推荐队列
+// @auth      郑康       	2026.10.19
+// @param     string, []string	用户名, 推荐的用户名列表
+// @return    error			错误信息
+func ReplaceRecommendQueue(username string, candidates []string) error {
+	key := recommendQueuePrefix + username
+	err := withRedisDB(recommendQueueDB, func(conn red.Conn) error {
+		_ = conn.Send("MULTI")
+		_ = conn.Send("DEL", key)
+		if len(candidates) > 0 {
+			_ = conn.Send("RPUSH", red.Args{}.Add(key).AddFlat(candidates)...)
+			_ = conn.Send("EXPIRE", key, recommendQueueExpire)
+		}
+		_, err := conn.Do("EXEC")
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "ReplaceRecommendQueue", "replace queue of "+username, err.Error())
+	}
+	return err
+}
+
+// @title    PopRecommendQueue
+// @description   			取出用户推荐队列中的第一个用户名
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    string, bool, error	推荐的用户名, 队列是否非空, 错误信息
+func PopRecommendQueue(username string) (string, bool, error) {
+	var res string
+	ok := false
+	err := withRedisDB(recommendQueueDB, func(conn red.Conn) error {
+		reply, err := red.String(conn.Do("LPOP", recommendQueuePrefix+username))
+		if err == red.ErrNil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		res, ok = reply, true
+		return nil
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "PopRecommendQueue", "pop queue of "+username, err.Error())
+	}
+	return res, ok, err
+}
+
+// @title    RecommendQueueLength
+// @description   			获取用户推荐队列的长度
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    int, error	长度, 错误信息
+func RecommendQueueLength(username string) (int, error) {
+	var res int
+	err := withRedisDB(recommendQueueDB, func(conn red.Conn) error {
+		var err error
+		res, err = red.Int(conn.Do("LLEN", recommendQueuePrefix+username))
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "RecommendQueueLength", "length of queue of "+username, err.Error())
+	}
+	return res, err
+}
+
+// @title    DeleteRecommendQueue
+// @description   			删除用户的推荐队列
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    error			错误信息
+func DeleteRecommendQueue(username string) error {
+	err := withRedisDB(recommendQueueDB, func(conn red.Conn) error {
+		_, err := conn.Do("DEL", recommendQueuePrefix+username)
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "DeleteRecommendQueue", "delete queue of "+username+" in db"+strconv.Itoa(recommendQueueDB), err.Error())
+	}
+	return err
+}
+
+// @title    ListOnlineUsers
+// @description   			获取Redis db2中全部在线用户的用户名
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    []string, error	用户名列表, 错误信息
+func ListOnlineUsers() ([]string, error) {
+	var res []string
+	err := withRedisDB(2, func(conn red.Conn) error {
+		var err error
+		res, err = red.Strings(conn.Do("KEYS", "*"))
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "ListOnlineUsers", "list keys of db2", err.Error())
+		return []string{}, err
+	}
+	return res, nil
+}
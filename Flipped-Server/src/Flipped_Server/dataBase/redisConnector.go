@@ -14,7 +14,6 @@ import (
 	red "github.com/garyburd/redigo/redis"
 	"github.com/sirupsen/logrus"
 	"strconv"
-	"sync"
 	"time"
 )
 
@@ -115,7 +114,7 @@ func WriteToRedis(key string, value string) error {
 		logger.SetToLogger(logrus.ErrorLevel, "WriteToRedis", "set expire to redis failed", err2.Error())
 		return err2
 	} else {
-		logger.SetToLogger(logrus.InfoLevel, "WriteToRedis", "set expire to redis successfully", string(res2.(int64)))
+		logger.SetToLogger(logrus.InfoLevel, "WriteToRedis", "set expire to redis successfully", strconv.FormatInt(res2.(int64), 10))
 	}
 	return nil
 }
@@ -147,7 +146,7 @@ func ReadFromRedis(key string) (string, error) {
 		logger.SetToLogger(logrus.ErrorLevel, "ReadFromRedis", "update the expire time failed", err1.Error())
 		return "", err1
 	} else {
-		logger.SetToLogger(logrus.InfoLevel, "ReadFromRedis", "update the expire time successfully", string(res.(int64)))
+		logger.SetToLogger(logrus.InfoLevel, "ReadFromRedis", "update the expire time successfully", strconv.FormatInt(res.(int64), 10))
 	}
 	value := string(reply.([]uint8))
 	return value, nil
@@ -216,26 +215,86 @@ func CountOnlineUsers() (int, error) {
 	return len(reply.([]interface{})), nil
 }
 
-// 实时更新用户状态， 如果用户存在于redis db2中，则更新其过期时间60s， 不存在则建立该key
-func UpdateUserStatus(username string, lock *sync.Mutex) {
-	conn := redis.pool.Get()
-	if err := conn.Err(); err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "UpdateUserStatus", "error to get instance from redis pool", err.Error())
-		return
-	}
-	defer conn.Close()
+// redis db2中在线用户的key的值，区分只建立了socket连接的用户和仍在发送http心跳的用户
+const (
+	presenceAlive     = "alive"
+	presenceHeartbeat = "heartbeat"
+)
 
-	lock.Lock()
-	isExist := KeyExists(username, 2)
-	if isExist {
-		_, _ = conn.Do("Expire", username, timeoutHeartBeat)
-	} else {
-		_, err := conn.Do("Set", username, "alive")
+// @title    UpdateUserStatus
+// @description   			实时更新用户状态，如果用户存在于redis db2中，则更新其过期时间60s，不存在则建立该key，heartbeat为true时将该key标记为由http心跳维持
+// @auth      郑康       	2026.10.19
+// @param     string, bool	用户名, 是否来自http心跳
+// @return    bool, error	用户是否由离线变为在线(key是否新建立), 错误信息
+func UpdateUserStatus(username string, heartbeat bool) (bool, error) {
+	created := false
+	err := withRedisDB(2, func(conn red.Conn) error {
+		if heartbeat {
+			//在事务中判断key是否存在并覆盖其值，保证判断和建立是原子的
+			_ = conn.Send("MULTI")
+			_ = conn.Send("EXISTS", username)
+			_ = conn.Send("SET", username, presenceHeartbeat, "EX", timeoutHeartBeat)
+			replies, err := red.Values(conn.Do("EXEC"))
+			if err != nil {
+				return err
+			}
+			exists, err := red.Int(replies[0], nil)
+			created = exists == 0
+			return err
+		}
+		//SET NX只在key不存在时成功，保证判断和建立是原子的
+		reply, err := conn.Do("SET", username, presenceAlive, "EX", timeoutHeartBeat, "NX")
 		if err != nil {
-			logger.SetToLogger(logrus.ErrorLevel, "UpdateUserStatus", "Error to Set Key-Value", err.Error())
-			return
+			return err
 		}
-		_, _ = conn.Do("Expire", username, timeoutHeartBeat)
+		if reply != nil {
+			created = true
+			return nil
+		}
+		_, err = conn.Do("EXPIRE", username, timeoutHeartBeat)
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "UpdateUserStatus", "update the key of "+username+" in db2", err.Error())
+		return false, err
+	}
+	return created, nil
+}
+
+// @title    UserHasHeartbeat
+// @description   			判断用户在redis db2中的key是否存在且由http心跳维持
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    bool			是否仍在发送http心跳
+func UserHasHeartbeat(username string) bool {
+	value := ""
+	err := withRedisDB(2, func(conn red.Conn) error {
+		var err error
+		value, err = red.String(conn.Do("GET", username))
+		if err == red.ErrNil {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "UserHasHeartbeat", "get the key of "+username+" in db2", err.Error())
+		return false
+	}
+	return value == presenceHeartbeat
+}
+
+// @title    DeleteUserStatus
+// @description   			删除用户在redis db2中的key，使其立即变为离线
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    error			错误信息
+func DeleteUserStatus(username string) error {
+	err := withRedisDB(2, func(conn red.Conn) error {
+		_, err := conn.Do("DEL", username)
+		return err
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "DeleteUserStatus", "delete the key of "+username+" in db2", err.Error())
 	}
-	lock.Unlock()
+	return err
 }
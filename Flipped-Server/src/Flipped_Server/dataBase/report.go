@@ -0,0 +1,240 @@
+// @Title  report.go
+// @Description  To provide the user reports and the audit history of handling them stored in mysql to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"database/sql"
+	"errors"
+	"github.com/sirupsen/logrus"
+)
+
+// 举报的状态
+const (
+	ReportOpen     = "open"     //等待处理
+	ReportTriaged  = "triaged"  //已由管理员接手
+	ReportResolved = "resolved" //已处理完毕
+)
+
+// 举报的处理结果
+const (
+	ResolutionDismissed  = "dismissed"  //举报不成立
+	ResolutionWarning    = "warning"    //警告被举报的用户
+	ResolutionSuspension = "suspension" //在SuspendedUntil之前停用被举报用户的账号
+	ResolutionBan        = "ban"        //永久停用被举报用户的账号
+)
+
+// 审计记录中的操作
+const (
+	ReportActionCreated  = "created"
+	ReportActionTriaged  = "triaged"
+	ReportActionResolved = "resolved"
+)
+
+// Report是一条用户举报，MessageAt和Message是举报引用的消息的发送时间和内容，没有引用消息时为0和空字符串
+// Message是提交举报时的快照，之后聊天记录被删除也不受影响
+type Report struct {
+	ID             string `json:"id"`
+	Reporter       string `json:"reporter"`
+	Target         string `json:"target"`
+	Reason         string `json:"reason"`
+	Details        string `json:"details"`
+	MessageAt      int64  `json:"messageAt"`
+	Message        string `json:"message"`
+	Status         string `json:"status"`
+	Assignee       string `json:"assignee"`
+	Resolution     string `json:"resolution"`
+	SuspendedUntil int64  `json:"suspendedUntil"`
+	CreatedAt      int64  `json:"createdAt"`
+	UpdatedAt      int64  `json:"updatedAt"`
+}
+
+// ReportAction是举报的一条审计记录，记录操作者、操作以及操作后的状态和处理结果
+type ReportAction struct {
+	ID         string `json:"id"`
+	ReportID   string `json:"reportId"`
+	Actor      string `json:"actor"`
+	Action     string `json:"action"`
+	Status     string `json:"status"`
+	Resolution string `json:"resolution"`
+	Note       string `json:"note"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+// 举报不存在时返回的错误
+var ErrReportNotFound = errors.New("report doesn't exist")
+
+// 更新举报时其状态已被其他管理员修改时返回的错误
+var ErrReportChanged = errors.New("the report has been changed by another admin, please reload it")
+
+const reportColumns = "id, reporter, target, reason, details, message_at, message, status, assignee, resolution, suspended_until, created_at, updated_at"
+
+// @title    scanReports
+// @description   			解析reportColumns对应的查询结果
+// @auth      郑康       	2026.10.19
+// @param     *sql.Rows		查询结果
+// @return    []Report, error	举报列表, 错误信息
+func scanReports(rows *sql.Rows) ([]Report, error) {
+	defer rows.Close()
+	res := []Report{}
+	for rows.Next() {
+		var report Report
+		if err := rows.Scan(&report.ID, &report.Reporter, &report.Target, &report.Reason, &report.Details, &report.MessageAt, &report.Message,
+			&report.Status, &report.Assignee, &report.Resolution, &report.SuspendedUntil, &report.CreatedAt, &report.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, report)
+	}
+	return res, rows.Err()
+}
+
+// @title    insertReportAction
+// @description   			在事务中追加一条审计记录
+// @auth      郑康       	2026.10.19
+// @param     *sql.Tx, *ReportAction	事务, 审计记录
+// @return    error			错误信息
+func insertReportAction(tx *sql.Tx, action *ReportAction) error {
+	_, err := tx.Exec("INSERT INTO im.report_action (id, report_id, actor, action, status, resolution, note, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		action.ID, action.ReportID, action.Actor, action.Action, action.Status, action.Resolution, action.Note, action.CreatedAt)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "insertReportAction", "insert into report_action", err.Error())
+	}
+	return err
+}
+
+// @title    InsertReport
+// @description   			在一个事务中保存举报及其第一条审计记录
+// @auth      郑康       	2026.10.19
+// @param     *Report, *ReportAction	举报, 审计记录
+// @return    error			错误信息
+func InsertReport(report *Report, action *ReportAction) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec("INSERT INTO im.report ("+reportColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		report.ID, report.Reporter, report.Target, report.Reason, report.Details, report.MessageAt, report.Message,
+		report.Status, report.Assignee, report.Resolution, report.SuspendedUntil, report.CreatedAt, report.UpdatedAt); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "InsertReport", "insert into report", err.Error())
+		return err
+	}
+	if err = insertReportAction(tx, action); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// @title    FindReports
+// @description   			按创建时间倒序查询举报
+// @auth      郑康       	2026.10.19
+// @param     string, int	状态(为空时不限), 最多返回的条数
+// @return    []Report, error	举报列表, 错误信息
+func FindReports(status string, limit int) ([]Report, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT "+reportColumns+" FROM im.report WHERE (? = '' OR status = ?) ORDER BY created_at DESC LIMIT ?", status, status, limit)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindReports", "select from report", err.Error())
+		return nil, err
+	}
+	return scanReports(rows)
+}
+
+// @title    FindReport
+// @description   			按id查询举报
+// @auth      郑康       	2026.10.19
+// @param     string		举报id
+// @return    *Report, error	举报, 错误信息，不存在时为ErrReportNotFound
+func FindReport(reportID string) (*Report, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT "+reportColumns+" FROM im.report WHERE id = ?", reportID)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindReport", "select from report", err.Error())
+		return nil, err
+	}
+	reports, err := scanReports(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, ErrReportNotFound
+	}
+	return &reports[0], nil
+}
+
+// @title    UpdateReport
+// @description   			在一个事务中更新举报的状态、处理人和处理结果并追加审计记录，只有举报仍处于读取时的状态from时才会更新，避免并发的处理互相覆盖
+// @auth      郑康       	2026.10.19
+// @param     *Report, string, *ReportAction	更新后的举报, 读取时的状态, 审计记录
+// @return    error			错误信息，举报不存在时为ErrReportNotFound，状态已改变时为ErrReportChanged
+func UpdateReport(report *Report, from string, action *ReportAction) error {
+	if mysqlDB == nil {
+		return errors.New("DataBase does't initialise, pointer is nil")
+	}
+	tx, err := mysqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	//锁定举报行后再比较状态, mysql的RowsAffected不计算值没有变化的行(如重新分配给同一处理人), 不能单独用来判断是否被修改
+	var status string
+	err = tx.QueryRow("SELECT status FROM im.report WHERE id = ? FOR UPDATE", report.ID).Scan(&status)
+	if err == sql.ErrNoRows {
+		_ = tx.Rollback()
+		return ErrReportNotFound
+	} else if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if status != from {
+		_ = tx.Rollback()
+		return ErrReportChanged
+	}
+	if _, err = tx.Exec("UPDATE im.report SET status = ?, assignee = ?, resolution = ?, suspended_until = ?, updated_at = ? WHERE id = ? AND status = ?",
+		report.Status, report.Assignee, report.Resolution, report.SuspendedUntil, report.UpdatedAt, report.ID, from); err != nil {
+		_ = tx.Rollback()
+		logger.SetToLogger(logrus.ErrorLevel, "UpdateReport", "update report", err.Error())
+		return err
+	}
+	if err = insertReportAction(tx, action); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// @title    FindReportActions
+// @description   			按时间顺序查询举报的全部审计记录
+// @auth      郑康       	2026.10.19
+// @param     string		举报id
+// @return    []ReportAction, error	审计记录, 错误信息
+func FindReportActions(reportID string) ([]ReportAction, error) {
+	if mysqlDB == nil {
+		return nil, errors.New("DataBase does't initialise, pointer is nil")
+	}
+	rows, err := mysqlDB.Query("SELECT id, report_id, actor, action, status, resolution, note, created_at FROM im.report_action "+
+		"WHERE report_id = ? ORDER BY created_at, seq", reportID)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindReportActions", "select from report_action", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+	res := []ReportAction{}
+	for rows.Next() {
+		var action ReportAction
+		if err := rows.Scan(&action.ID, &action.ReportID, &action.Actor, &action.Action, &action.Status, &action.Resolution, &action.Note, &action.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, action)
+	}
+	return res, rows.Err()
+}
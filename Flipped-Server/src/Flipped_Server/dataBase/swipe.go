@@ -0,0 +1,186 @@
+// @Title  swipe.go
+// @Description  To provide the like/pass decisions and the matches stored in MongoDB to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/logger"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// 存放滑动记录和配对记录的集合名称
+const (
+	swipeCollectionName = "swipe"
+	matchCollectionName = "match"
+)
+
+// Swipe表示SourceUser对TargetUser做出的喜欢(Liked为true)或跳过的决定
+type Swipe struct {
+	SourceUser string `bson:"sourceUser" json:"sourceUser"`
+	TargetUser string `bson:"targetUser" json:"targetUser"`
+	Liked      bool   `bson:"liked" json:"liked"`
+	CreatedAt  int64  `bson:"createdAt" json:"createdAt"`
+}
+
+// Match表示两个互相喜欢的用户配对成功，UserA和UserB按字典序保存，UnmatchedAt不为0时表示已解除配对
+type Match struct {
+	UserA       string `bson:"userA" json:"userA"`
+	UserB       string `bson:"userB" json:"userB"`
+	CreatedAt   int64  `bson:"createdAt" json:"createdAt"`
+	UnmatchedAt int64  `bson:"unmatchedAt" json:"unmatchedAt"`
+}
+
+// @title    NewMatch
+// @description   			创建配对记录，两个用户名按字典序排列，保证同一对用户只对应一条记录
+// @auth      郑康       	2026.10.19
+// @param     string, string, int64	用户A, 用户B, 配对时间
+// @return    *Match		配对记录
+func NewMatch(userA string, userB string, createdAt int64) *Match {
+	if userA > userB {
+		userA, userB = userB, userA
+	}
+	return &Match{UserA: userA, UserB: userB, CreatedAt: createdAt}
+}
+
+// @title    Partner
+// @description   			获取配对中另一方的用户名
+// @auth      郑康       	2026.10.19
+// @param     string		其中一方的用户名
+// @return    string		另一方的用户名
+func (match *Match) Partner(username string) string {
+	if match.UserA == username {
+		return match.UserB
+	}
+	return match.UserA
+}
+
+// @title    UpsertSwipe
+// @description   			保存滑动记录，同一用户对同一目标再次滑动时覆盖之前的决定
+// @auth      郑康       	2026.10.19
+// @param     *Swipe		滑动记录
+// @return    error			错误信息
+func UpsertSwipe(swipe *Swipe) error {
+	_, err := currentDB.C(swipeCollectionName).Upsert(bson.M{"sourceUser": swipe.SourceUser, "targetUser": swipe.TargetUser}, swipe)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "UpsertSwipe", "upsert into mongodb", err.Error())
+		return err
+	}
+	return nil
+}
+
+// @title    FindSwipe
+// @description   			查询sourceUser对targetUser的滑动记录，不存在时返回mgo.ErrNotFound
+// @auth      郑康       	2026.10.19
+// @param     string, string	滑动方, 被滑动方
+// @return    *Swipe, error	滑动记录, 错误信息
+func FindSwipe(sourceUser string, targetUser string) (*Swipe, error) {
+	res := &Swipe{}
+	err := currentDB.C(swipeCollectionName).Find(bson.M{"sourceUser": sourceUser, "targetUser": targetUser}).One(res)
+	if err != nil {
+		if err != mgo.ErrNotFound {
+			logger.SetToLogger(logrus.ErrorLevel, "FindSwipe", "find data in mongodb", err.Error())
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// @title    FindSwipedUsers
+// @description   			查询用户在since(Unix时间戳, 秒)之后滑动过的全部目标用户
+// @auth      郑康       	2026.10.19
+// @param     string, int64	滑动方, 起始时间
+// @return    []string, error	目标用户列表, 错误信息
+func FindSwipedUsers(sourceUser string, since int64) ([]string, error) {
+	swipes := []Swipe{}
+	err := currentDB.C(swipeCollectionName).Find(bson.M{"sourceUser": sourceUser, "createdAt": bson.M{"$gte": since}}).Select(bson.M{"targetUser": 1}).All(&swipes)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindSwipedUsers", "find data in mongodb", err.Error())
+		return []string{}, err
+	}
+	res := make([]string, len(swipes))
+	for i := range swipes {
+		res[i] = swipes[i].TargetUser
+	}
+	return res, nil
+}
+
+// @title    FindSwipesSince
+// @description   			查询since(Unix时间戳, 秒)之后的全部滑动记录，用于离线计算推荐模型
+// @auth      郑康       	2026.10.19
+// @param     int64			起始时间
+// @return    []Swipe, error	滑动记录, 错误信息
+func FindSwipesSince(since int64) ([]Swipe, error) {
+	res := []Swipe{}
+	err := currentDB.C(swipeCollectionName).Find(bson.M{"createdAt": bson.M{"$gte": since}}).All(&res)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindSwipesSince", "find data in mongodb", err.Error())
+		return []Swipe{}, err
+	}
+	return res, nil
+}
+
+// @title    InsertMatch
+// @description   			保存一条配对记录，已存在时不重复保存
+// @auth      郑康       	2026.10.19
+// @param     *Match		配对记录
+// @return    error			错误信息
+func InsertMatch(match *Match) error {
+	_, err := currentDB.C(matchCollectionName).Upsert(bson.M{"userA": match.UserA, "userB": match.UserB}, bson.M{"$setOnInsert": match})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "InsertMatch", "upsert into mongodb", err.Error())
+		return err
+	}
+	return nil
+}
+
+// @title    MatchExists
+// @description   			判断两个用户是否处于配对状态(已解除的配对不算)
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户A, 用户B
+// @return    bool			是否已配对
+func MatchExists(userA string, userB string) bool {
+	match := NewMatch(userA, userB, 0)
+	count, err := currentDB.C(matchCollectionName).Find(bson.M{"userA": match.UserA, "userB": match.UserB, "unmatchedAt": 0}).Count()
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "MatchExists", "count data in mongodb", err.Error())
+		return false
+	}
+	return count > 0
+}
+
+// @title    FindMatches
+// @description   			查询用户的配对记录，unmatched为false时只返回仍有效的配对，为true时只返回已解除的配对
+// @auth      郑康       	2026.10.19
+// @param     string, bool	用户名, 是否查询已解除的配对
+// @return    []Match, error	配对记录(按配对时间从新到旧), 错误信息
+func FindMatches(username string, unmatched bool) ([]Match, error) {
+	selector := bson.M{"$or": []bson.M{{"userA": username}, {"userB": username}}, "unmatchedAt": 0}
+	if unmatched {
+		selector["unmatchedAt"] = bson.M{"$gt": 0}
+	}
+	res := []Match{}
+	err := currentDB.C(matchCollectionName).Find(selector).Sort("-createdAt").All(&res)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "FindMatches", "find data in mongodb", err.Error())
+		return []Match{}, err
+	}
+	return res, nil
+}
+
+// @title    SetUnmatched
+// @description   			解除两个用户的配对，保留记录用于避免再次推荐，配对不存在时返回mgo.ErrNotFound
+// @auth      郑康       	2026.10.19
+// @param     string, string, int64	用户A, 用户B, 解除时间
+// @return    error			错误信息
+func SetUnmatched(userA string, userB string, unmatchedAt int64) error {
+	match := NewMatch(userA, userB, 0)
+	err := currentDB.C(matchCollectionName).Update(bson.M{"userA": match.UserA, "userB": match.UserB, "unmatchedAt": 0}, bson.M{"$set": bson.M{"unmatchedAt": unmatchedAt}})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "SetUnmatched", "update data in mongodb", err.Error())
+		return err
+	}
+	return nil
+}
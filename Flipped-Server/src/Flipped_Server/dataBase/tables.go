@@ -5,10 +5,9 @@
 package dataBase
 
 import (
+	"errors"
 	"fmt"
-	"math"
 	"strconv"
-	"strings"
 )
 
 type UserInfo UserInfoTable
@@ -60,43 +59,35 @@ func MakeUserInfoStruct(data *map[string]string) (*UserInfoTable, error) {
 	return &userInfo, nil
 }
 
-func CalculateSimilarity(user1 *UserInfoTable, user2 *UserInfoTable) float32 {
-	var similarity float32 = 1.0
-	if user1.UserType == user2.UserType {
-		similarity *= 0.6
-	} else {
-		similarity *= 0.4
+// @title    ApplyFields
+// @description   			将以数据库列名为键的字段值写入结构体，用于部分更新
+// @auth      郑康       	2026.10.19
+// @param     map[string]string	列名与新值
+// @return    error			错误信息
+func (userInfo *UserInfoTable) ApplyFields(fields map[string]string) error {
+	for key, value := range fields {
+		switch key {
+		case "email":
+			userInfo.Email = value
+		case "photo":
+			userInfo.Photo = value
+		case "realName":
+			userInfo.RealName = value
+		case "profession":
+			userInfo.Profession = value
+		case "region":
+			userInfo.Region = value
+		case "hobby":
+			userInfo.Hobby = value
+		case "age":
+			integer, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			userInfo.Age = integer
+		default:
+			return errors.New("unexpected field: " + key)
+		}
 	}
-	user1EmailSuffix := strings.Split(user1.Email, "@")[1]
-	user2EmailSuffix := strings.Split(user2.Email, "@")[1]
-	if user1EmailSuffix == user2EmailSuffix {
-		similarity *= 0.6
-	} else {
-		similarity *= 0.4
-	}
-
-	if int(math.Abs(float64(user1.Age-user2.Age))) < 5 {
-		similarity *= 0.7
-	} else {
-		similarity *= 0.3
-	}
-
-	if user1.Region == user2.Region {
-		similarity *= 0.9
-	} else {
-		similarity *= 0.1
-	}
-
-	if user1.Profession == user2.Profession {
-		similarity *= 0.9
-	} else {
-		similarity *= 0.1
-	}
-
-	if strings.Contains(user1.Hobby, user2.Hobby) || strings.Contains(user2.Hobby, user1.Hobby) {
-		similarity *= 0.8
-	} else {
-		similarity *= 0.2
-	}
-	return similarity
+	return nil
 }
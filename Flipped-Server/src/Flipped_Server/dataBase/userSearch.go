@@ -0,0 +1,160 @@
+// @Title  userSearch.go
+// @Description  To provide searching users by username prefix and profile filters to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package dataBase
+
+import (
+	"Flipped_Server/utils"
+	"sort"
+	"strings"
+)
+
+// UserQuery描述一次用户搜索，字符串条件为空、年龄为0、UserType小于0、Tags为空时表示不限制，停用的账号不会被搜索到
+type UserQuery struct {
+	NamePrefix string
+	Region     string
+	Profession string
+	Hobby      string
+	MinAge     int
+	MaxAge     int
+	UserType   int
+	Tags       []string //已规范化的标签名，只搜索至少拥有其中一个标签的用户
+	Excluded   []string
+	Offset     int
+	Limit      int
+}
+
+// @title    Matches
+// @description   			判断用户是否满足除兴趣标签以外的搜索条件，标签条件由存储根据用户与标签的对应关系判断
+// @auth      郑康       	2026.10.19
+// @param     *UserInfoTable	用户信息
+// @return    bool			是否满足
+func (query *UserQuery) Matches(user *UserInfoTable) bool {
+	if !strings.HasPrefix(strings.ToLower(user.Username), strings.ToLower(query.NamePrefix)) {
+		return false
+	}
+	if utils.Contains(query.Excluded, user.Username) {
+		return false
+	}
+	if query.Region != "" && user.Region != query.Region {
+		return false
+	}
+	if query.Profession != "" && user.Profession != query.Profession {
+		return false
+	}
+	if query.Hobby != "" && !strings.Contains(strings.ToLower(user.Hobby), strings.ToLower(query.Hobby)) {
+		return false
+	}
+	if query.MinAge > 0 && user.Age < query.MinAge {
+		return false
+	}
+	if query.MaxAge > 0 && user.Age > query.MaxAge {
+		return false
+	}
+	if query.UserType >= 0 && user.UserType != query.UserType {
+		return false
+	}
+	return true
+}
+
+// @title    SortByRelevance
+// @description   			按相关度排序：用户名与搜索词完全相同的排在最前，其余按用户名长度(越接近搜索词越靠前)和字典序排列
+// @auth      郑康       	2026.10.19
+// @param     []*UserInfoTable	用户列表
+// @return    void
+func (query *UserQuery) SortByRelevance(users []*UserInfoTable) {
+	prefix := strings.ToLower(query.NamePrefix)
+	sort.SliceStable(users, func(i, j int) bool {
+		exactI := prefix != "" && strings.ToLower(users[i].Username) == prefix
+		exactJ := prefix != "" && strings.ToLower(users[j].Username) == prefix
+		if exactI != exactJ {
+			return exactI
+		}
+		if len(users[i].Username) != len(users[j].Username) {
+			return len(users[i].Username) < len(users[j].Username)
+		}
+		return users[i].Username < users[j].Username
+	})
+}
+
+// @title    BuildSearchSQL
+// @description   			根据搜索条件拼接带占位符的select语句，排序规则与SortByRelevance一致
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    string, []interface{}	sql语句, 占位符参数
+func (query *UserQuery) BuildSearchSQL() (string, []interface{}) {
+	conditions := []string{activeAccountCondition}
+	var args []interface{}
+	if query.NamePrefix != "" {
+		conditions = append(conditions, "username LIKE ?")
+		args = append(args, escapeLike(query.NamePrefix)+"%")
+	}
+	if len(query.Tags) > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM im.user_tag ut JOIN im.tag t ON ut.tag_id = t.id "+
+			"WHERE ut.username = userinfo.username AND t.name IN ("+placeholders(len(query.Tags))+"))")
+		for i := range query.Tags {
+			args = append(args, query.Tags[i])
+		}
+	}
+	if len(query.Excluded) > 0 {
+		conditions = append(conditions, "username NOT IN (?"+strings.Repeat(", ?", len(query.Excluded)-1)+")")
+		for i := range query.Excluded {
+			args = append(args, query.Excluded[i])
+		}
+	}
+	if query.Region != "" {
+		conditions = append(conditions, "region = ?")
+		args = append(args, query.Region)
+	}
+	if query.Profession != "" {
+		conditions = append(conditions, "profession = ?")
+		args = append(args, query.Profession)
+	}
+	if query.Hobby != "" {
+		conditions = append(conditions, "hobby LIKE ?")
+		args = append(args, "%"+escapeLike(query.Hobby)+"%")
+	}
+	if query.MinAge > 0 {
+		conditions = append(conditions, "age >= ?")
+		args = append(args, query.MinAge)
+	}
+	if query.MaxAge > 0 {
+		conditions = append(conditions, "age <= ?")
+		args = append(args, query.MaxAge)
+	}
+	if query.UserType >= 0 {
+		conditions = append(conditions, "user_type = ?")
+		args = append(args, query.UserType)
+	}
+	SQL := "SELECT * FROM im.userinfo WHERE " + strings.Join(conditions, " AND ")
+	SQL += " ORDER BY LOWER(username) = LOWER(?) DESC, CHAR_LENGTH(username), username LIMIT ? OFFSET ?"
+	args = append(args, query.NamePrefix, query.Limit, query.Offset)
+	return SQL, args
+}
+
+// @title    SearchUsers
+// @description   			在mysql中按搜索条件查询用户
+// @auth      郑康       	2026.10.19
+// @param     *UserQuery	搜索条件
+// @return    []*UserInfoTable, error	用户列表, 错误信息
+func SearchUsers(query *UserQuery) ([]*UserInfoTable, error) {
+	SQL, args := query.BuildSearchSQL()
+	res, err := ExecSelectSQL(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return []*UserInfoTable{}, nil
+	}
+	return res, nil
+}
+
+// @title    escapeLike
+// @description   			转义LIKE语句中的通配符
+// @auth      郑康       	2026.10.19
+// @param     string		原始字符串
+// @return    string		转义后的字符串
+func escapeLike(str string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(str)
+}
@@ -0,0 +1,84 @@
+// @Title  exif.go
+// @Description  To read the orientation from the EXIF segment of a jpeg image before the metadata is dropped
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package imagePipeline
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// EXIF中方向标签的编号
+const exifOrientationTag = 0x0112
+
+// @title    jpegOrientation
+// @description   			在jpeg的APP1(Exif)段中查找IFD0的方向标签，找不到或格式有误时返回1(正常方向)
+// @auth      郑康       	2026.10.19
+// @param     []byte		jpeg文件内容
+// @return    int			EXIF方向, 1到8
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return 1
+		}
+		marker := data[offset+1]
+		//SOS之后是图像数据，不会再有APP段
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		offset += 2 + length
+	}
+	return 1
+}
+
+// @title    tiffOrientation
+// @description   			解析Exif段中的TIFF结构，读取IFD0中的方向标签
+// @auth      郑康       	2026.10.19
+// @param     []byte		TIFF数据
+// @return    int			EXIF方向, 1到8
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
@@ -0,0 +1,224 @@
+// @Title  pipeline.go
+// @Description  To validate, normalize and re-encode uploaded images and generate their thumbnails
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package imagePipeline
+
+import (
+	"Flipped_Server/initialSetting"
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// 处理上传图片时可能返回的错误，均为客户端的错误
+var (
+	ErrEmpty             = errors.New("image is empty")
+	ErrTooLarge          = errors.New("image is too large")
+	ErrUnsupportedFormat = errors.New("image format is unsupported, please upload a jpeg, png or gif image")
+	ErrTooManyPixels     = errors.New("image dimensions are too large")
+	ErrCorrupt           = errors.New("image is corrupt")
+)
+
+// @title    IsRejected
+// @description   			判断错误是否是因为上传的图片不合法，而不是服务器的错误
+// @auth      郑康       	2026.10.19
+// @param     error			错误信息
+// @return    bool			是否是图片不合法
+func IsRejected(err error) bool {
+	return err == ErrEmpty || err == ErrTooLarge || err == ErrUnsupportedFormat || err == ErrTooManyPixels || err == ErrCorrupt
+}
+
+// 重新编码后的图片格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// Config描述上传图片的限制、保存的原图大小以及缩略图的尺寸
+type Config struct {
+	MaxBytes          int64          //上传图片的最大字节数
+	MaxInputDimension int            //上传图片的宽和高都不能超过该值，在完整解码之前检查
+	MaxInputPixels    int64          //上传图片的宽与高之积不能超过该值，在完整解码之前检查，限制解码占用的内存
+	MaxDimension      int            //保存的原图最长边超过该值时等比缩小
+	JPEGQuality       int            //重新编码jpeg时的质量
+	Thumbnails        map[string]int //缩略图名称与最长边，如列表用的list、卡片用的card
+}
+
+// Variant是处理后的一张图片，原图的Name为空
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Result是一张上传图片的处理结果，Thumbnails按名称排列
+type Result struct {
+	Format     string
+	Original   Variant
+	Thumbnails []Variant
+}
+
+// @title    DefaultConfig
+// @description   			获取配置文件中没有图片配置时使用的默认配置
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    Config		默认配置
+func DefaultConfig() Config {
+	return Config{
+		MaxBytes:          10 << 20,
+		MaxInputDimension: 8000,
+		MaxInputPixels:    24000000,
+		MaxDimension:      2048,
+		JPEGQuality:       85,
+		Thumbnails:        map[string]int{"list": 160, "card": 640},
+	}
+}
+
+// @title    LoadConfig
+// @description   			读取配置文件中的image项，缺少的字段使用默认值
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    Config		图片配置
+func LoadConfig() Config {
+	config := DefaultConfig()
+	settings := initialSetting.ImageConfig
+	if value, ok := settings["maxBytes"].(float64); ok && value > 0 {
+		config.MaxBytes = int64(value)
+	}
+	if value, ok := settings["maxInputDimension"].(float64); ok && value > 0 {
+		config.MaxInputDimension = int(value)
+	}
+	if value, ok := settings["maxInputPixels"].(float64); ok && value > 0 {
+		config.MaxInputPixels = int64(value)
+	}
+	if value, ok := settings["maxDimension"].(float64); ok && value > 0 {
+		config.MaxDimension = int(value)
+	}
+	if value, ok := settings["jpegQuality"].(float64); ok && value >= 1 && value <= 100 {
+		config.JPEGQuality = int(value)
+	}
+	if thumbnails, ok := settings["thumbnails"].(map[string]interface{}); ok {
+		config.Thumbnails = make(map[string]int, len(thumbnails))
+		for name, size := range thumbnails {
+			if value, ok := size.(float64); ok && value > 0 {
+				config.Thumbnails[name] = int(value)
+			}
+		}
+	}
+	return config
+}
+
+// @title    Sniff
+// @description   			根据文件内容(而不是文件名)判断图片格式
+// @auth      郑康       	2026.10.19
+// @param     []byte		文件内容
+// @return    string, error	jpeg、png或gif, 不支持的格式返回ErrUnsupportedFormat
+func Sniff(data []byte) (string, error) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return "jpeg", nil
+	case "image/png":
+		return "png", nil
+	case "image/gif":
+		return "gif", nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// @title    Process
+// @description   			校验上传的图片，按EXIF方向摆正后丢弃全部元数据(包括GPS)，缩小到最大尺寸并重新编码，同时生成缩略图
+// @auth      郑康       	2026.10.19
+// @param     []byte, Config	文件内容, 图片配置
+// @return    *Result, error	处理结果, 错误信息
+func Process(data []byte, config Config) (*Result, error) {
+	if len(data) == 0 {
+		return nil, ErrEmpty
+	}
+	if config.MaxBytes > 0 && int64(len(data)) > config.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	format, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+	//先只解析图片头，避免解码尺寸过大的图片耗尽内存
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if imageConfig.Width <= 0 || imageConfig.Height <= 0 {
+		return nil, ErrCorrupt
+	}
+	if config.MaxInputDimension > 0 && (imageConfig.Width > config.MaxInputDimension || imageConfig.Height > config.MaxInputDimension) {
+		return nil, ErrTooManyPixels
+	}
+	if config.MaxInputPixels > 0 && int64(imageConfig.Width)*int64(imageConfig.Height) > config.MaxInputPixels {
+		return nil, ErrTooManyPixels
+	}
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	default:
+		//gif只保留第一帧
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	rgba := toRGBA(img)
+	if format == "jpeg" {
+		rgba = applyOrientation(rgba, jpegOrientation(data))
+	}
+	outputFormat := FormatPNG
+	if format == "jpeg" {
+		outputFormat = FormatJPEG
+	}
+	res := &Result{Format: outputFormat}
+	original := fit(rgba, config.MaxDimension)
+	if res.Original, err = encode(original, "", outputFormat, config.JPEGQuality); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(config.Thumbnails))
+	for name := range config.Thumbnails {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		thumbnail, err := encode(fit(original, config.Thumbnails[name]), name, outputFormat, config.JPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		res.Thumbnails = append(res.Thumbnails, thumbnail)
+	}
+	return res, nil
+}
+
+// @title    encode
+// @description   			按格式编码图片，编码器不会写入任何元数据
+// @auth      郑康       	2026.10.19
+// @param     *image.RGBA, string, string, int	图片, 名称, 格式, jpeg质量
+// @return    Variant, error	编码后的图片, 错误信息
+func encode(img *image.RGBA, name string, format string, quality int) (Variant, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == FormatJPEG {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return Variant{}, errors.New("fail to encode " + format + " image of size " + strconv.Itoa(img.Rect.Dx()) + "x" + strconv.Itoa(img.Rect.Dy()) + ": " + err.Error())
+	}
+	return Variant{Name: name, Width: img.Rect.Dx(), Height: img.Rect.Dy(), Data: buf.Bytes()}, nil
+}
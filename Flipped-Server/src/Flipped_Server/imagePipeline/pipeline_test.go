@@ -0,0 +1,114 @@
+package imagePipeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width int, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// withExif在jpeg的SOI之后插入只包含方向标签和一段GPS文本的Exif段
+func withExif(data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(8))
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(1))
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{exifOrientationTag, 3})
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(1))
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("GPSLatitude 30.5")
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	header := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+	res := append([]byte{}, data[:2]...)
+	res = append(res, header...)
+	res = append(res, segment...)
+	return append(res, data[2:]...)
+}
+
+func TestProcessPNG(t *testing.T) {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, testImage(300, 150))
+	config := DefaultConfig()
+	config.MaxDimension = 200
+	config.Thumbnails = map[string]int{"list": 20, "card": 100}
+	res, err := Process(buf.Bytes(), config)
+	assert.NoError(t, err)
+	assert.Equal(t, FormatPNG, res.Format)
+	assert.Equal(t, 200, res.Original.Width)
+	assert.Equal(t, 100, res.Original.Height)
+	assert.Len(t, res.Thumbnails, 2)
+	assert.Equal(t, "card", res.Thumbnails[0].Name)
+	assert.Equal(t, 100, res.Thumbnails[0].Width)
+	assert.Equal(t, "list", res.Thumbnails[1].Name)
+	assert.Equal(t, 10, res.Thumbnails[1].Height)
+	decoded, err := png.Decode(bytes.NewReader(res.Thumbnails[1].Data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 20, 10), decoded.Bounds())
+}
+
+func TestProcessJPEGStripsExif(t *testing.T) {
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, testImage(40, 20), nil)
+	data := withExif(buf.Bytes(), 6)
+	assert.Equal(t, 6, jpegOrientation(data))
+	res, err := Process(data, DefaultConfig())
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJPEG, res.Format)
+	//方向为6时顺时针旋转90度，宽高互换
+	assert.Equal(t, 20, res.Original.Width)
+	assert.Equal(t, 40, res.Original.Height)
+	assert.False(t, bytes.Contains(res.Original.Data, []byte("Exif")))
+	assert.False(t, bytes.Contains(res.Original.Data, []byte("GPSLatitude")))
+	assert.Equal(t, 1, jpegOrientation(res.Original.Data))
+}
+
+func TestProcessRejects(t *testing.T) {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, testImage(100, 10))
+	config := DefaultConfig()
+	_, err := Process(nil, config)
+	assert.Equal(t, ErrEmpty, err)
+	_, err = Process([]byte("<html>not an image</html>"), config)
+	assert.Equal(t, ErrUnsupportedFormat, err)
+	_, err = Process(buf.Bytes()[:40], config)
+	assert.Equal(t, ErrCorrupt, err)
+
+	config.MaxInputDimension = 50
+	_, err = Process(buf.Bytes(), config)
+	assert.Equal(t, ErrTooManyPixels, err)
+	//宽和高都在限制内, 但总像素数超出预算
+	config.MaxInputDimension = 0
+	config.MaxInputPixels = 999
+	_, err = Process(buf.Bytes(), config)
+	assert.Equal(t, ErrTooManyPixels, err)
+	config.MaxBytes = 10
+	_, err = Process(buf.Bytes(), config)
+	assert.Equal(t, ErrTooLarge, err)
+}
+
+func TestApplyOrientation(t *testing.T) {
+	img := testImage(3, 2)
+	rotated := applyOrientation(img, 8)
+	assert.Equal(t, image.Rect(0, 0, 2, 3), rotated.Rect)
+	//逆时针旋转90度后，原图右上角的像素位于左上角
+	assert.Equal(t, img.RGBAAt(2, 0), rotated.RGBAAt(0, 0))
+	flipped := applyOrientation(img, 2)
+	assert.Equal(t, img.RGBAAt(0, 1), flipped.RGBAAt(2, 1))
+	assert.Equal(t, img, applyOrientation(img, 1))
+}
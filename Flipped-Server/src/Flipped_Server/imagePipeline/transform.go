@@ -0,0 +1,114 @@
+// @Title  transform.go
+// @Description  To provide the pixel level transforms of the image pipeline: orientation and downscaling
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package imagePipeline
+
+import (
+	"image"
+	"image/draw"
+)
+
+// @title    toRGBA
+// @description   			将任意格式的图片转换为起点为(0,0)的RGBA图片
+// @auth      郑康       	2026.10.19
+// @param     image.Image	图片
+// @return    *image.RGBA	RGBA图片
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	res := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(res, res.Rect, img, bounds.Min, draw.Src)
+	return res
+}
+
+// @title    applyOrientation
+// @description   			按EXIF方向(1到8)旋转或翻转图片，使其按正常方向显示，其他值时原样返回
+// @auth      郑康       	2026.10.19
+// @param     *image.RGBA, int	图片, EXIF方向
+// @return    *image.RGBA	摆正后的图片
+func applyOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	//source计算目标像素(x, y)对应的原图像素
+	source := map[int]func(x, y int) (int, int){
+		2: func(x, y int) (int, int) { return width - 1 - x, y },
+		3: func(x, y int) (int, int) { return width - 1 - x, height - 1 - y },
+		4: func(x, y int) (int, int) { return x, height - 1 - y },
+		5: func(x, y int) (int, int) { return y, x },
+		6: func(x, y int) (int, int) { return y, height - 1 - x },
+		7: func(x, y int) (int, int) { return width - 1 - y, height - 1 - x },
+		8: func(x, y int) (int, int) { return width - 1 - y, x },
+	}[orientation]
+	res := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			srcX, srcY := source(x, y)
+			copy(res.Pix[res.PixOffset(x, y):res.PixOffset(x, y)+4], img.Pix[img.PixOffset(srcX, srcY):img.PixOffset(srcX, srcY)+4])
+		}
+	}
+	return res
+}
+
+// @title    fit
+// @description   			将图片等比缩小到最长边不超过maxSide，不会放大，maxSide不大于0时原样返回
+// @auth      郑康       	2026.10.19
+// @param     *image.RGBA, int	图片, 最长边
+// @return    *image.RGBA	缩小后的图片
+func fit(img *image.RGBA, maxSide int) *image.RGBA {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if maxSide <= 0 || (width <= maxSide && height <= maxSide) {
+		return img
+	}
+	dstWidth, dstHeight := maxSide, maxSide
+	if width >= height {
+		dstHeight = maxInt(1, height*maxSide/width)
+	} else {
+		dstWidth = maxInt(1, width*maxSide/height)
+	}
+	return resize(img, dstWidth, dstHeight)
+}
+
+// @title    resize
+// @description   			使用区域平均(box filter)缩小图片，每个目标像素取其覆盖的原图像素的平均值
+// @auth      郑康       	2026.10.19
+// @param     *image.RGBA, int, int	图片, 目标宽度, 目标高度
+// @return    *image.RGBA	缩小后的图片
+func resize(img *image.RGBA, dstWidth int, dstHeight int) *image.RGBA {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	res := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		srcY0, srcY1 := y*height/dstHeight, maxInt((y+1)*height/dstHeight, y*height/dstHeight+1)
+		for x := 0; x < dstWidth; x++ {
+			srcX0, srcX1 := x*width/dstWidth, maxInt((x+1)*width/dstWidth, x*width/dstWidth+1)
+			var sum [4]int
+			for srcY := srcY0; srcY < srcY1; srcY++ {
+				offset := img.PixOffset(srcX0, srcY)
+				for srcX := srcX0; srcX < srcX1; srcX++ {
+					for channel := 0; channel < 4; channel++ {
+						sum[channel] += int(img.Pix[offset+channel])
+					}
+					offset += 4
+				}
+			}
+			count := (srcY1 - srcY0) * (srcX1 - srcX0)
+			offset := res.PixOffset(x, y)
+			for channel := 0; channel < 4; channel++ {
+				res.Pix[offset+channel] = uint8(sum[channel] / count)
+			}
+		}
+	}
+	return res
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -10,7 +10,17 @@ var (
 	configData     map[string]interface{}
 	DataBaseConfig map[string]interface{}
 	LoggerConfig   map[string]interface{}
-	AESKey         string
+	//推荐相关的配置，配置文件中没有该项时为nil，使用者需自行提供默认值
+	RecommendConfig map[string]interface{}
+	//图片处理相关的配置，配置文件中没有该项时为nil
+	ImageConfig map[string]interface{}
+	//图片文件存储的配置，配置文件中没有该项时为nil
+	StorageConfig map[string]interface{}
+	//内容审核的规则链，配置文件中没有该项时为nil
+	ModerationConfig map[string]interface{}
+	//管理员的配置，配置文件中没有该项时为nil
+	AdminConfig map[string]interface{}
+	AESKey      string
 )
 
 func InitSettings(path string) {
@@ -32,4 +42,9 @@ func InitSettings(path string) {
 	AESKey = configData["key"].(string)
 	DataBaseConfig = configData["dataBase"].(map[string]interface{})
 	LoggerConfig = configData["logger"].(map[string]interface{})
+	RecommendConfig, _ = configData["recommendation"].(map[string]interface{})
+	ImageConfig, _ = configData["image"].(map[string]interface{})
+	StorageConfig, _ = configData["storage"].(map[string]interface{})
+	ModerationConfig, _ = configData["moderation"].(map[string]interface{})
+	AdminConfig, _ = configData["admin"].(map[string]interface{})
 }
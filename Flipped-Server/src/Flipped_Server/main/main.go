@@ -7,6 +7,7 @@ package main
 import (
 	"Flipped_Server/initialSetting"
 	"Flipped_Server/network"
+	"Flipped_Server/repository"
 	"Flipped_Server/utils"
 	"runtime"
 )
@@ -21,10 +22,11 @@ func main() {
 	utils.ExitFlag = make(chan bool)
 
 	initialSetting.InitSettings("")
-	socketServer := network.SocketServer{IPAddr: "", Port: 8082}
+	stores := repository.InitBackendStores()
+	socketServer := network.SocketServer{IPAddr: "", Port: 8082, Stores: stores}
 	go socketServer.Run()
 
-	httpServer := network.HttpServer{IPAddr: "", Port: 8081}
+	httpServer := network.HttpServer{IPAddr: "", Port: 8081, Stores: stores}
 	httpServer.Run()
 	<-utils.ExitFlag
 }
@@ -51,7 +51,7 @@ func SendMessageToClient(recorder *utils.Recorder) {
 		logger.SetToLogger(logrus.ErrorLevel, "SendMessageToClient", "error to marshal Recorder to json bytes", err.Error())
 		return
 	}
-	count, err := conn.Write(buf)
+	count, err := utils.WriteToConnection(conn, buf)
 	if err != nil {
 		logger.SetToLogger(logrus.ErrorLevel, "SendMessageToClient", "error to write bytes to client", err.Error())
 		return
@@ -0,0 +1,78 @@
+// @Title  main.go
+// @Description  The migrate command to upgrade, rollback or inspect the schema of the mysql database
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package main
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/initialSetting"
+	"Flipped_Server/logger"
+	"Flipped_Server/migration"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `usage: migrate [-settings path] [-to version] <up|down|status>
+  up      upgrade to the given version, or to the latest version if -to is omitted
+  down    rollback to the given version, or only the latest migration if -to is omitted
+          (-to 0 rolls back everything, including the userinfo table)
+  status  list every migration and whether it has been applied`
+
+// @title    main
+// @description   migrate命令入口，读取配置并连接mysql后执行对应的迁移操作
+// @auth      郑康             2026.10.19
+// @param     void
+// @return    void
+func main() {
+	settingsPath := flag.String("settings", "", "path of the settings file, ./defaultSettings.json by default")
+	target := flag.Int("to", -1, "target schema version")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	initialSetting.InitSettings(*settingsPath)
+	logger.InitLog()
+	dataBase.Init()
+	db := dataBase.GetMySqlClient()
+	if db == nil {
+		fmt.Fprintln(os.Stderr, "fail to connect to mysql, see the log for details")
+		os.Exit(1)
+	}
+	defer dataBase.CloseMySqlClient()
+
+	var err error
+	switch flag.Arg(0) {
+	case "up":
+		var version int
+		version, err = migration.Up(db, migration.Migrations, *target)
+		fmt.Printf("schema version: %d\n", version)
+	case "down":
+		var version int
+		version, err = migration.Down(db, migration.Migrations, *target)
+		fmt.Printf("schema version: %d\n", version)
+	case "status":
+		var status []migration.MigrationStatus
+		status, err = migration.Status(db, migration.Migrations)
+		for i := range status {
+			mark := " "
+			if status[i].Applied {
+				mark = "x"
+			}
+			fmt.Printf("[%s] %3d %s\n", mark, status[i].Version, status[i].Name)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,209 @@
+// @Title  migration.go
+// @Description  To provide versioned schema migrations of the mysql database to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package migration
+
+import (
+	"Flipped_Server/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strconv"
+)
+
+// 记录已执行迁移版本的数据表
+const versionTable = "schema_version"
+
+// Migration表示一次数据库结构变更，Up用于升级，Down用于回滚，均按顺序逐条执行
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// MigrationStatus表示某一迁移版本在当前数据库中的执行情况
+type MigrationStatus struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+// @title    Validate
+// @description   			检查迁移列表的版本号是否从1开始连续递增，且每个迁移都包含升级和回滚语句
+// @auth      郑康       	2026.10.19
+// @param     []Migration	迁移列表
+// @return    error			错误信息
+func Validate(migrations []Migration) error {
+	for i := range migrations {
+		if migrations[i].Version != i+1 {
+			return fmt.Errorf("migration %q has version %d, expect %d", migrations[i].Name, migrations[i].Version, i+1)
+		}
+		if len(migrations[i].Up) == 0 || len(migrations[i].Down) == 0 {
+			return fmt.Errorf("migration %d should have both up and down scripts", migrations[i].Version)
+		}
+	}
+	return nil
+}
+
+// @title    pendingMigrations
+// @description   			计算从当前版本升级到目标版本需要执行的迁移，目标版本小于等于0时表示升级到最新版本
+// @auth      郑康       	2026.10.19
+// @param     []Migration, int, int	迁移列表, 当前版本, 目标版本
+// @return    []Migration	需要执行的迁移(按版本升序)
+func pendingMigrations(migrations []Migration, current int, target int) []Migration {
+	if target <= 0 || target > len(migrations) {
+		target = len(migrations)
+	}
+	var res []Migration
+	for i := range migrations {
+		if migrations[i].Version > current && migrations[i].Version <= target {
+			res = append(res, migrations[i])
+		}
+	}
+	return res
+}
+
+// @title    rollbackMigrations
+// @description   			计算从当前版本回滚到目标版本需要撤销的迁移，目标版本小于0时只回滚最近一次迁移
+// @auth      郑康       	2026.10.19
+// @param     []Migration, int, int	迁移列表, 当前版本, 目标版本
+// @return    []Migration	需要撤销的迁移(按版本降序)
+func rollbackMigrations(migrations []Migration, current int, target int) []Migration {
+	if target < 0 {
+		target = current - 1
+	}
+	var res []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Version <= current && migrations[i].Version > target {
+			res = append(res, migrations[i])
+		}
+	}
+	return res
+}
+
+// @title    ensureVersionTable
+// @description   			若schema_version表不存在则创建
+// @auth      郑康       	2026.10.19
+// @param     *sql.DB		数据库连接
+// @return    error			错误信息
+func ensureVersionTable(db *sql.DB) error {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS " + versionTable + " (\n" +
+		"version INT NOT NULL,\n" +
+		"name VARCHAR(128) NOT NULL,\n" +
+		"applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+		"PRIMARY KEY (version)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+	return err
+}
+
+// @title    CurrentVersion
+// @description   			查询数据库当前的迁移版本，尚未执行任何迁移时返回0
+// @auth      郑康       	2026.10.19
+// @param     *sql.DB		数据库连接
+// @return    int, error	当前版本, 错误信息
+func CurrentVersion(db *sql.DB) (int, error) {
+	if db == nil {
+		return 0, errors.New("mysql client is not initialized")
+	}
+	if err := ensureVersionTable(db); err != nil {
+		return 0, err
+	}
+	var version sql.NullInt64
+	err := db.QueryRow("SELECT MAX(version) FROM " + versionTable).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// @title    Up
+// @description   			将数据库升级到目标版本，目标版本小于等于0时升级到最新版本
+// @auth      郑康       	2026.10.19
+// @param     *sql.DB, []Migration, int	数据库连接, 迁移列表, 目标版本
+// @return    int, error	升级后的版本, 错误信息
+func Up(db *sql.DB, migrations []Migration, target int) (int, error) {
+	if err := Validate(migrations); err != nil {
+		return 0, err
+	}
+	current, err := CurrentVersion(db)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "Up", "read current schema version", err.Error())
+		return 0, err
+	}
+	for _, m := range pendingMigrations(migrations, current, target) {
+		err = apply(db, m.Up, "INSERT INTO "+versionTable+" (version, name) VALUES (?, ?)", m.Version, m.Name)
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "Up", "apply migration "+strconv.Itoa(m.Version)+" "+m.Name, err.Error())
+			return current, fmt.Errorf("migration %d (%s): %v", m.Version, m.Name, err)
+		}
+		logger.SetToLogger(logrus.InfoLevel, "Up", "succeed to apply migration "+strconv.Itoa(m.Version), m.Name)
+		current = m.Version
+	}
+	return current, nil
+}
+
+// @title    Down
+// @description   			将数据库回滚到目标版本，目标版本为0时撤销全部迁移，小于0时只回滚最近一次迁移
+// @auth      郑康       	2026.10.19
+// @param     *sql.DB, []Migration, int	数据库连接, 迁移列表, 目标版本
+// @return    int, error	回滚后的版本, 错误信息
+func Down(db *sql.DB, migrations []Migration, target int) (int, error) {
+	if err := Validate(migrations); err != nil {
+		return 0, err
+	}
+	current, err := CurrentVersion(db)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "Down", "read current schema version", err.Error())
+		return 0, err
+	}
+	for _, m := range rollbackMigrations(migrations, current, target) {
+		err = apply(db, m.Down, "DELETE FROM "+versionTable+" WHERE version = ?", m.Version)
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "Down", "revert migration "+strconv.Itoa(m.Version)+" "+m.Name, err.Error())
+			return current, fmt.Errorf("migration %d (%s): %v", m.Version, m.Name, err)
+		}
+		logger.SetToLogger(logrus.InfoLevel, "Down", "succeed to revert migration "+strconv.Itoa(m.Version), m.Name)
+		current = m.Version - 1
+	}
+	return current, nil
+}
+
+// @title    Status
+// @description   			列出每个迁移版本是否已经执行
+// @auth      郑康       	2026.10.19
+// @param     *sql.DB, []Migration	数据库连接, 迁移列表
+// @return    []MigrationStatus, error	迁移状态列表, 错误信息
+func Status(db *sql.DB, migrations []Migration) ([]MigrationStatus, error) {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]MigrationStatus, len(migrations))
+	for i := range migrations {
+		res[i] = MigrationStatus{
+			Version: migrations[i].Version,
+			Name:    migrations[i].Name,
+			Applied: migrations[i].Version <= current,
+		}
+	}
+	return res, nil
+}
+
+// @title    apply
+// @description   			依次执行脚本语句，全部成功后才更新schema_version表
+// mysql的DDL语句会隐式提交，无法放在事务中回滚，某条语句失败时之前的语句已经生效，版本不会被记录，错误中包含失败语句的序号以便人工处理
+// @auth      郑康       	2026.10.19
+// @param     *sql.DB, []string, string, ...interface{}	数据库连接, 脚本语句, 版本表语句, 版本表语句参数
+// @return    error			错误信息
+func apply(db *sql.DB, statements []string, versionSQL string, args ...interface{}) error {
+	for i, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("statement %d of %d failed, the statements before it have been applied: %v", i+1, len(statements), err)
+		}
+	}
+	_, err := db.Exec(versionSQL, args...)
+	return err
+}
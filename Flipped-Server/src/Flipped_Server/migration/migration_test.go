@@ -0,0 +1,41 @@
+package migration
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "one", Up: []string{"up1"}, Down: []string{"down1"}},
+	{Version: 2, Name: "two", Up: []string{"up2"}, Down: []string{"down2"}},
+	{Version: 3, Name: "three", Up: []string{"up3"}, Down: []string{"down3"}},
+}
+
+func versions(migrations []Migration) []int {
+	res := []int{}
+	for i := range migrations {
+		res = append(res, migrations[i].Version)
+	}
+	return res
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, nil, Validate(Migrations))
+	assert.Equal(t, nil, Validate(testMigrations))
+	assert.NotEqual(t, nil, Validate([]Migration{{Version: 2, Name: "gap", Up: []string{"up"}, Down: []string{"down"}}}))
+	assert.NotEqual(t, nil, Validate([]Migration{{Version: 1, Name: "no down", Up: []string{"up"}}}))
+}
+
+func TestPendingMigrations(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, versions(pendingMigrations(testMigrations, 0, 0)))
+	assert.Equal(t, []int{2}, versions(pendingMigrations(testMigrations, 1, 2)))
+	assert.Equal(t, []int{}, versions(pendingMigrations(testMigrations, 3, 0)))
+}
+
+func TestRollbackMigrations(t *testing.T) {
+	assert.Equal(t, []int{3, 2, 1}, versions(rollbackMigrations(testMigrations, 3, 0)))
+	assert.Equal(t, []int{3}, versions(rollbackMigrations(testMigrations, 3, 2)))
+	assert.Equal(t, []int{}, versions(rollbackMigrations(testMigrations, 1, 1)))
+	assert.Equal(t, []int{3}, versions(rollbackMigrations(testMigrations, 3, -1)))
+	assert.Equal(t, []int{}, versions(rollbackMigrations(testMigrations, 0, -1)))
+}
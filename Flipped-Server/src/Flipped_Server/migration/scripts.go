@@ -0,0 +1,257 @@
+// @Title  scripts.go
+// @Description  The up and down scripts of every schema version of the mysql database 'im'
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package migration
+
+// Migrations按版本号升序排列，只能在末尾追加新版本，已发布的版本不可修改
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_userinfo",
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS userinfo (\n" +
+				"pid VARCHAR(36) NOT NULL,\n" +
+				"username VARCHAR(20) NOT NULL,\n" +
+				"password VARCHAR(20) NOT NULL,\n" +
+				"user_type INT NOT NULL DEFAULT 0,\n" +
+				"email VARCHAR(64) NOT NULL,\n" +
+				"photo VARCHAR(255) NOT NULL DEFAULT '',\n" +
+				"realName VARCHAR(32) NOT NULL DEFAULT '',\n" +
+				"profession VARCHAR(32) NOT NULL DEFAULT '',\n" +
+				"age INT NOT NULL DEFAULT 0,\n" +
+				"region VARCHAR(64) NOT NULL DEFAULT '',\n" +
+				"hobby VARCHAR(255) NOT NULL DEFAULT '',\n" +
+				"PRIMARY KEY (pid),\n" +
+				"UNIQUE KEY uk_userinfo_username (username)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS userinfo",
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_userinfo_created_at",
+		Up: []string{
+			"ALTER TABLE userinfo ADD COLUMN created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP",
+		},
+		Down: []string{
+			"ALTER TABLE userinfo DROP COLUMN created_at",
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_interest_tags",
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS tag (\n" +
+				"id INT NOT NULL AUTO_INCREMENT,\n" +
+				"name VARCHAR(32) NOT NULL,\n" +
+				"PRIMARY KEY (id),\n" +
+				"UNIQUE KEY uk_tag_name (name)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+			"CREATE TABLE IF NOT EXISTS user_tag (\n" +
+				"username VARCHAR(20) NOT NULL,\n" +
+				"tag_id INT NOT NULL,\n" +
+				"PRIMARY KEY (username, tag_id),\n" +
+				"KEY idx_user_tag_tag (tag_id),\n" +
+				"CONSTRAINT fk_user_tag_tag FOREIGN KEY (tag_id) REFERENCES tag (id) ON DELETE CASCADE\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+			"INSERT IGNORE INTO tag (name) VALUES " +
+				"('reading'), ('writing'), ('music'), ('singing'), ('dancing'), ('movies'), ('photography'), ('painting'), " +
+				"('travel'), ('hiking'), ('running'), ('cycling'), ('swimming'), ('basketball'), ('football'), ('badminton'), " +
+				"('fitness'), ('yoga'), ('cooking'), ('food'), ('coffee'), ('games'), ('anime'), ('programming'), " +
+				"('pets'), ('fashion'), ('volunteering'), ('languages')",
+			//原有的爱好文本中与标签名完全相同的词直接迁移为用户标签
+			"INSERT IGNORE INTO user_tag (username, tag_id)\n" +
+				"SELECT u.username, t.id FROM userinfo u JOIN tag t\n" +
+				"ON FIND_IN_SET(t.name, REPLACE(REPLACE(REPLACE(LOWER(u.hobby), '，', ','), ' ', ''), ';', ',')) > 0",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS user_tag",
+			"DROP TABLE IF EXISTS tag",
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create_user_photo",
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS user_photo (\n" +
+				"id VARCHAR(36) NOT NULL,\n" +
+				"username VARCHAR(20) NOT NULL,\n" +
+				"path VARCHAR(255) NOT NULL,\n" +
+				"position INT NOT NULL DEFAULT 0,\n" +
+				"is_primary TINYINT(1) NOT NULL DEFAULT 0,\n" +
+				"size INT NOT NULL DEFAULT 0,\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (id),\n" +
+				"KEY idx_user_photo_username (username, position)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+			//注册时上传的头像作为相册中的第一张主照片
+			"INSERT INTO user_photo (id, username, path, position, is_primary, created_at)\n" +
+				"SELECT UUID(), username, photo, 0, 1, UNIX_TIMESTAMP(created_at) FROM userinfo WHERE photo <> ''",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS user_photo",
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create_image_blob",
+		Up: []string{
+			//以内容的sha256保存的文件，ref_count为引用该文件的图片尺寸数
+			"CREATE TABLE IF NOT EXISTS image_blob (\n" +
+				"hash CHAR(64) NOT NULL,\n" +
+				"format VARCHAR(8) NOT NULL,\n" +
+				"size INT NOT NULL DEFAULT 0,\n" +
+				"ref_count INT NOT NULL DEFAULT 0,\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (hash)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+			//对外公开的图片id是随机的，不暴露内容的哈希
+			"CREATE TABLE IF NOT EXISTS image (\n" +
+				"id VARCHAR(36) NOT NULL,\n" +
+				"owner VARCHAR(20) NOT NULL,\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (id),\n" +
+				"KEY idx_image_owner (owner)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+			"CREATE TABLE IF NOT EXISTS image_variant (\n" +
+				"image_id VARCHAR(36) NOT NULL,\n" +
+				"name VARCHAR(16) NOT NULL,\n" +
+				"blob_hash CHAR(64) NOT NULL,\n" +
+				"width INT NOT NULL DEFAULT 0,\n" +
+				"height INT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (image_id, name),\n" +
+				"KEY idx_image_variant_blob (blob_hash),\n" +
+				"CONSTRAINT fk_image_variant_image FOREIGN KEY (image_id) REFERENCES image (id) ON DELETE CASCADE\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS image_variant",
+			"DROP TABLE IF EXISTS image",
+			"DROP TABLE IF EXISTS image_blob",
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create_flagged_content",
+		Up: []string{
+			//被内容审核规则标记或拒绝的聊天消息和资料，content保存用户提交的原文
+			"CREATE TABLE IF NOT EXISTS flagged_content (\n" +
+				"id VARCHAR(36) NOT NULL,\n" +
+				"username VARCHAR(20) NOT NULL,\n" +
+				"source VARCHAR(16) NOT NULL,\n" +
+				"target VARCHAR(64) NOT NULL DEFAULT '',\n" +
+				"content TEXT NOT NULL,\n" +
+				"rules VARCHAR(255) NOT NULL DEFAULT '',\n" +
+				"rejected TINYINT(1) NOT NULL DEFAULT 0,\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (id),\n" +
+				"KEY idx_flagged_content_created_at (created_at),\n" +
+				"KEY idx_flagged_content_username (username)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS flagged_content",
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create_report",
+		Up: []string{
+			//message为举报引用的消息在提交举报时的快照
+			"CREATE TABLE IF NOT EXISTS report (\n" +
+				"id VARCHAR(36) NOT NULL,\n" +
+				"reporter VARCHAR(20) NOT NULL,\n" +
+				"target VARCHAR(20) NOT NULL,\n" +
+				"reason VARCHAR(32) NOT NULL,\n" +
+				"details VARCHAR(512) NOT NULL DEFAULT '',\n" +
+				"message_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"message TEXT NOT NULL,\n" +
+				"status VARCHAR(16) NOT NULL,\n" +
+				"assignee VARCHAR(20) NOT NULL DEFAULT '',\n" +
+				"resolution VARCHAR(16) NOT NULL DEFAULT '',\n" +
+				"suspended_until BIGINT NOT NULL DEFAULT 0,\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"updated_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (id),\n" +
+				"KEY idx_report_status (status, created_at),\n" +
+				"KEY idx_report_target (target)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+			//审计记录只追加不修改，seq保证同一秒内的记录按写入顺序排列
+			"CREATE TABLE IF NOT EXISTS report_action (\n" +
+				"seq BIGINT NOT NULL AUTO_INCREMENT,\n" +
+				"id VARCHAR(36) NOT NULL,\n" +
+				"report_id VARCHAR(36) NOT NULL,\n" +
+				"actor VARCHAR(20) NOT NULL,\n" +
+				"action VARCHAR(16) NOT NULL,\n" +
+				"status VARCHAR(16) NOT NULL,\n" +
+				"resolution VARCHAR(16) NOT NULL DEFAULT '',\n" +
+				"note VARCHAR(512) NOT NULL DEFAULT '',\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (seq),\n" +
+				"UNIQUE KEY uk_report_action_id (id),\n" +
+				"KEY idx_report_action_report (report_id, created_at)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS report_action",
+			"DROP TABLE IF EXISTS report",
+		},
+	},
+	{
+		Version: 8,
+		Name:    "add_userinfo_status",
+		Up: []string{
+			"ALTER TABLE userinfo ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active', " +
+				"ADD COLUMN status_until BIGINT NOT NULL DEFAULT 0, " +
+				"ADD COLUMN status_reason VARCHAR(512) NOT NULL DEFAULT ''",
+		},
+		Down: []string{
+			"ALTER TABLE userinfo DROP COLUMN status_reason, DROP COLUMN status_until, DROP COLUMN status",
+		},
+	},
+	{
+		Version: 9,
+		Name:    "create_image_reference",
+		Up: []string{
+			//聊天消息和举报快照对图片的引用，发送消息和提交举报时写入，清理任务据此判断图片是否仍被使用
+			"CREATE TABLE IF NOT EXISTS image_reference (\n" +
+				"name VARCHAR(100) NOT NULL,\n" +
+				"source VARCHAR(16) NOT NULL,\n" +
+				"ref VARCHAR(48) NOT NULL,\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (name, source, ref),\n" +
+				"KEY idx_image_reference_ref (source, ref)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS image_reference",
+		},
+	},
+	{
+		Version: 10,
+		Name:    "create_account_status_change",
+		Up: []string{
+			//管理员修改账号状态(停用、封禁、恢复)的审计记录，只追加不修改
+			"CREATE TABLE IF NOT EXISTS account_status_change (\n" +
+				"seq BIGINT NOT NULL AUTO_INCREMENT,\n" +
+				"id VARCHAR(36) NOT NULL,\n" +
+				"username VARCHAR(20) NOT NULL,\n" +
+				"actor VARCHAR(20) NOT NULL,\n" +
+				"from_status VARCHAR(16) NOT NULL,\n" +
+				"status VARCHAR(16) NOT NULL,\n" +
+				"until BIGINT NOT NULL DEFAULT 0,\n" +
+				"reason VARCHAR(512) NOT NULL DEFAULT '',\n" +
+				"created_at BIGINT NOT NULL DEFAULT 0,\n" +
+				"PRIMARY KEY (seq),\n" +
+				"UNIQUE KEY uk_account_status_change_id (id),\n" +
+				"KEY idx_account_status_change_user (username, created_at)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS account_status_change",
+		},
+	},
+}
@@ -0,0 +1,103 @@
+// @Title  config.go
+// @Description  To build the moderation rule chain from the settings
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package moderation
+
+import (
+	"Flipped_Server/initialSetting"
+	"encoding/json"
+	"errors"
+)
+
+// 规则的类型
+const (
+	TypeWords = "words"
+	TypeRegex = "regex"
+)
+
+// RuleConfig描述规则链中的一条规则，words类型使用Words，regex类型使用Pattern
+type RuleConfig struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Action  Action   `json:"action"`
+	Words   []string `json:"words"`
+	Pattern string   `json:"pattern"`
+}
+
+// @title    DefaultRules
+// @description   			获取配置文件中没有moderation项时使用的规则链：屏蔽脏话，记录手机号和链接
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    []RuleConfig	规则链
+func DefaultRules() []RuleConfig {
+	return []RuleConfig{
+		{Name: "profanity", Type: TypeWords, Action: ActionMask, Words: []string{"fuck", "shit", "bitch", "asshole", "傻逼", "操你妈", "他妈的"}},
+		{Name: "phone", Type: TypeRegex, Action: ActionFlag, Pattern: `\b(?:\+?86[\s-]?)?1[3-9]\d(?:[\s-]?\d){8}\b`},
+		{Name: "link", Type: TypeRegex, Action: ActionFlag, Pattern: `(?:https?://|www\.)[^\s]+|\b[a-z0-9-]+\.(?:com|cn|net|org|io|me|top|xyz)\b`},
+	}
+}
+
+// @title    NewRule
+// @description   			按配置创建规则
+// @auth      郑康       	2026.10.19
+// @param     RuleConfig	规则配置
+// @return    Rule, error	规则, 配置不合法时的错误
+func NewRule(config RuleConfig) (Rule, error) {
+	if config.Name == "" {
+		return nil, errors.New("moderation rule should have a name")
+	}
+	if config.Action != ActionMask && config.Action != ActionFlag && config.Action != ActionReject {
+		return nil, errors.New("action of moderation rule '" + config.Name + "' should be 'mask', 'flag' or 'reject'")
+	}
+	switch config.Type {
+	case TypeWords:
+		return NewWordRule(config.Name, config.Action, config.Words), nil
+	case TypeRegex:
+		rule, err := NewRegexRule(config.Name, config.Action, config.Pattern)
+		if err != nil {
+			return nil, errors.New("pattern of moderation rule '" + config.Name + "' is invalid: " + err.Error())
+		}
+		return rule, nil
+	}
+	return nil, errors.New("type of moderation rule '" + config.Name + "' should be 'words' or 'regex'")
+}
+
+// @title    NewModerator
+// @description   			按配置创建规则链
+// @auth      郑康       	2026.10.19
+// @param     []RuleConfig	规则配置
+// @return    *Moderator, error	内容审核器, 配置不合法时的错误
+func NewModerator(configs []RuleConfig) (*Moderator, error) {
+	moderator := &Moderator{Rules: make([]Rule, 0, len(configs))}
+	for _, config := range configs {
+		rule, err := NewRule(config)
+		if err != nil {
+			return nil, err
+		}
+		moderator.Rules = append(moderator.Rules, rule)
+	}
+	return moderator, nil
+}
+
+// @title    LoadModerator
+// @description   			读取配置文件中moderation项的rules创建规则链，没有该项时使用DefaultRules
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    *Moderator, error	内容审核器, 配置不合法时的错误
+func LoadModerator() (*Moderator, error) {
+	rules, ok := initialSetting.ModerationConfig["rules"]
+	if !ok {
+		return NewModerator(DefaultRules())
+	}
+	//规则是对象数组，借助json转换为RuleConfig
+	buf, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	configs := []RuleConfig{}
+	if err := json.Unmarshal(buf, &configs); err != nil {
+		return nil, errors.New("moderation rules are invalid: " + err.Error())
+	}
+	return NewModerator(configs)
+}
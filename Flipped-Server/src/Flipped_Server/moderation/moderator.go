@@ -0,0 +1,133 @@
+// @Title  moderator.go
+// @Description  To check the text written by users against a chain of moderation rules and decide whether to mask, flag or reject it
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package moderation
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Action是规则命中后的处理方式
+type Action string
+
+const (
+	ActionMask   Action = "mask"   //将命中的内容替换为*后继续使用
+	ActionFlag   Action = "flag"   //原样使用，并记录以便人工审核
+	ActionReject Action = "reject" //拒绝整段内容，同样记录以便人工审核
+)
+
+// Rule是规则链中的一条规则，Find返回命中的内容在原文中的字节区间[Start, End)
+type Rule interface {
+	Name() string
+	Action() Action
+	Find(text string) []Span
+}
+
+// Span是原文中的一个字节区间
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Finding是一条规则的一次命中
+type Finding struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"action"`
+	Span
+}
+
+// Verdict是一段内容的检查结果，Text是按mask规则处理后的内容
+type Verdict struct {
+	Text     string
+	Findings []Finding
+}
+
+// @title    Rejected
+// @description   			判断内容是否被拒绝
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    bool
+func (verdict Verdict) Rejected() bool {
+	return verdict.has(ActionReject)
+}
+
+// @title    Flagged
+// @description   			判断内容是否需要记录以便人工审核，被拒绝的内容同样需要记录
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    bool
+func (verdict Verdict) Flagged() bool {
+	return verdict.has(ActionFlag) || verdict.has(ActionReject)
+}
+
+func (verdict Verdict) has(action Action) bool {
+	for _, finding := range verdict.Findings {
+		if finding.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// @title    Rules
+// @description   			获取命中的规则名称，按首次命中的顺序去重
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    []string		规则名称
+func (verdict Verdict) Rules() []string {
+	res := []string{}
+	seen := make(map[string]bool)
+	for _, finding := range verdict.Findings {
+		if !seen[finding.Rule] {
+			seen[finding.Rule] = true
+			res = append(res, finding.Rule)
+		}
+	}
+	return res
+}
+
+// Moderator按顺序使用规则链检查内容
+type Moderator struct {
+	Rules []Rule
+}
+
+// @title    Check
+// @description   			使用全部规则检查内容，命中mask规则的字符(空白除外)替换为*，命中的区间均以原文为准
+// @auth      郑康       	2026.10.19
+// @param     string		内容
+// @return    Verdict		检查结果
+func (moderator *Moderator) Check(text string) Verdict {
+	verdict := Verdict{Text: text, Findings: []Finding{}}
+	var masked []bool
+	for _, rule := range moderator.Rules {
+		for _, span := range rule.Find(text) {
+			verdict.Findings = append(verdict.Findings, Finding{Rule: rule.Name(), Action: rule.Action(), Span: span})
+			if rule.Action() != ActionMask {
+				continue
+			}
+			if masked == nil {
+				masked = make([]bool, len(text))
+			}
+			for i := span.Start; i < span.End; i++ {
+				masked[i] = true
+			}
+		}
+	}
+	sort.SliceStable(verdict.Findings, func(i, j int) bool {
+		return verdict.Findings[i].Start < verdict.Findings[j].Start
+	})
+	if masked == nil {
+		return verdict
+	}
+	res := make([]rune, 0, len(text))
+	for i, r := range text {
+		if masked[i] && !unicode.IsSpace(r) {
+			r = '*'
+		}
+		res = append(res, r)
+	}
+	verdict.Text = string(res)
+	return verdict
+}
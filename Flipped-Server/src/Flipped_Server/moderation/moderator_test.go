@@ -0,0 +1,93 @@
+package moderation
+
+import (
+	"Flipped_Server/initialSetting"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWordRule(t *testing.T) {
+	rule := NewWordRule("profanity", ActionMask, []string{"Fuck", "ass", "傻逼", "  "})
+	assert.Equal(t, []string{"fuck", "ass", "傻逼"}, rule.words)
+
+	//大小写、全角、代替字母的符号和分隔字符都能识别
+	for _, text := range []string{"FUCK", "f.u.c.k", "f u c k", "ｆｕｃｋ", "f*u*c*k!", "phuck fuck"} {
+		assert.NotEmpty(t, rule.Find(text), text)
+	}
+	//拉丁词需要独立成词
+	for _, text := range []string{"class", "assign", "as sign", "passage"} {
+		assert.Empty(t, rule.Find(text), text)
+	}
+	assert.Equal(t, []Span{{Start: 4, End: 11}}, rule.Find("you 傻 逼"))
+	assert.Equal(t, []Span{{Start: 4, End: 7}}, rule.Find("big ass!"))
+	assert.Equal(t, []Span{{Start: 5, End: 8}, {Start: 9, End: 12}}, rule.Find("what a$$ ass"))
+}
+
+func TestRegexRule(t *testing.T) {
+	moderator, err := NewModerator(DefaultRules())
+	assert.NoError(t, err)
+	phone, link := moderator.Rules[1], moderator.Rules[2]
+	assert.Equal(t, []Span{{Start: 8, End: 19}}, phone.Find("call me 13912345678"))
+	assert.Len(t, phone.Find("call me 139 1234 5678"), 1)
+	assert.Len(t, phone.Find("我的手机号是１３９１２３４５６７８"), 1)
+	assert.Empty(t, phone.Find("order 20231391234567890"))
+	assert.Len(t, link.Find("see https://evil.example/x and www.spam.cn"), 2)
+	assert.Len(t, link.Find("加我qq.com"), 1)
+	assert.Empty(t, link.Find("/images/abc/list"))
+
+	_, err = NewRegexRule("broken", ActionFlag, "(")
+	assert.Error(t, err)
+}
+
+func TestModeratorCheck(t *testing.T) {
+	moderator, _ := NewModerator([]RuleConfig{
+		{Name: "profanity", Type: TypeWords, Action: ActionMask, Words: []string{"fuck", "傻逼"}},
+		{Name: "phone", Type: TypeRegex, Action: ActionFlag, Pattern: `\d{11}`},
+		{Name: "scam", Type: TypeWords, Action: ActionReject, Words: []string{"bitcoin"}},
+	})
+
+	verdict := moderator.Check("hello")
+	assert.Equal(t, "hello", verdict.Text)
+	assert.False(t, verdict.Flagged())
+	assert.False(t, verdict.Rejected())
+
+	verdict = moderator.Check("f u c k you 傻逼, 13912345678")
+	assert.Equal(t, "* * * * you **, 13912345678", verdict.Text)
+	assert.Equal(t, []string{"profanity", "phone"}, verdict.Rules())
+	assert.True(t, verdict.Flagged())
+	assert.False(t, verdict.Rejected())
+
+	verdict = moderator.Check("buy B1TCOIN now")
+	assert.True(t, verdict.Rejected())
+	assert.True(t, verdict.Flagged())
+	assert.Equal(t, []string{"scam"}, verdict.Rules())
+}
+
+func TestNewRule(t *testing.T) {
+	_, err := NewRule(RuleConfig{Name: "a", Type: TypeWords, Action: "delete"})
+	assert.Error(t, err)
+	_, err = NewRule(RuleConfig{Name: "a", Type: "ai", Action: ActionFlag})
+	assert.Error(t, err)
+	_, err = NewRule(RuleConfig{Type: TypeWords, Action: ActionFlag})
+	assert.Error(t, err)
+	_, err = NewRule(RuleConfig{Name: "a", Type: TypeRegex, Action: ActionFlag, Pattern: "["})
+	assert.Contains(t, err.Error(), "'a'")
+}
+
+func TestLoadModerator(t *testing.T) {
+	defer func() { initialSetting.ModerationConfig = nil }()
+	moderator, err := LoadModerator()
+	assert.NoError(t, err)
+	assert.Len(t, moderator.Rules, len(DefaultRules()))
+
+	initialSetting.ModerationConfig = map[string]interface{}{"rules": []interface{}{
+		map[string]interface{}{"name": "spam", "type": "words", "action": "reject", "words": []interface{}{"spam"}},
+	}}
+	moderator, err = LoadModerator()
+	assert.NoError(t, err)
+	assert.True(t, moderator.Check("SPAM").Rejected())
+
+	initialSetting.ModerationConfig = map[string]interface{}{"rules": "spam"}
+	_, err = LoadModerator()
+	assert.Error(t, err)
+}
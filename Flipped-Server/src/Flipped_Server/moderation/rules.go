@@ -0,0 +1,183 @@
+// @Title  rules.go
+// @Description  To provide the word list and regular expression rules and the text normalization they rely on
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 常见的以数字或符号代替字母的写法
+var leetReplacer = map[rune]rune{'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i'}
+
+// normalized是规范化后的文本，starts[i]和ends[i]是第i个字节对应的字符在原文中的字节区间
+type normalized struct {
+	text   string
+	starts []int
+	ends   []int
+}
+
+// @title    normalize
+// @description   			将全角字符转换为半角并转为小写；squash为true时还将常见的代替写法还原为字母，并去掉空白、标点、符号和零宽字符，用于识别"f.u.c.k"、"傻 逼"等规避写法
+// @auth      郑康       	2026.10.19
+// @param     string, bool	原文, 是否去掉分隔字符
+// @return    normalized	规范化后的文本
+func normalize(text string, squash bool) normalized {
+	var builder strings.Builder
+	res := normalized{starts: make([]int, 0, len(text)), ends: make([]int, 0, len(text))}
+	for i, r := range text {
+		size := utf8.RuneLen(r)
+		if r == utf8.RuneError {
+			size = 1
+		}
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		} else if r == 0x3000 {
+			r = ' '
+		}
+		r = unicode.ToLower(r)
+		if squash {
+			if replaced, ok := leetReplacer[r]; ok {
+				r = replaced
+			}
+			if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+				continue
+			}
+		}
+		n, _ := builder.WriteRune(r)
+		for j := 0; j < n; j++ {
+			res.starts = append(res.starts, i)
+			res.ends = append(res.ends, i+size)
+		}
+	}
+	res.text = builder.String()
+	return res
+}
+
+// @title    span
+// @description   			将规范化文本中的字节区间转换为原文中的字节区间
+// @auth      郑康       	2026.10.19
+// @param     int, int		规范化文本中的起止位置
+// @return    Span			原文中的区间
+func (text normalized) span(start int, end int) Span {
+	return Span{Start: text.starts[start], End: text.ends[end-1]}
+}
+
+// WordRule在规范化后的文本中查找词表中的词，只由字母组成的拉丁词要求在原文中独立成词，避免误伤包含它的其他单词
+type WordRule struct {
+	name   string
+	action Action
+	words  []string
+}
+
+// @title    NewWordRule
+// @description   			创建词表规则，词表按与文本相同的方式规范化，规范化后为空的词被忽略
+// @auth      郑康       	2026.10.19
+// @param     string, Action, []string	规则名称, 处理方式, 词表
+// @return    *WordRule	词表规则
+func NewWordRule(name string, action Action, words []string) *WordRule {
+	rule := &WordRule{name: name, action: action}
+	for _, word := range words {
+		if normalizedWord := normalize(word, true).text; normalizedWord != "" {
+			rule.words = append(rule.words, normalizedWord)
+		}
+	}
+	return rule
+}
+
+func (rule *WordRule) Name() string {
+	return rule.name
+}
+
+func (rule *WordRule) Action() Action {
+	return rule.action
+}
+
+func (rule *WordRule) Find(text string) []Span {
+	res := []Span{}
+	squashed := normalize(text, true)
+	for _, word := range rule.words {
+		latin := isLatinWord(word)
+		for offset := 0; offset < len(squashed.text); {
+			index := strings.Index(squashed.text[offset:], word)
+			if index < 0 {
+				break
+			}
+			start := offset + index
+			span := squashed.span(start, start+len(word))
+			if !latin || isWordBoundary(text, span) {
+				res = append(res, span)
+			}
+			offset = start + len(word)
+		}
+	}
+	return res
+}
+
+// @title    isLatinWord
+// @description   			判断词是否只由拉丁字母组成
+// @auth      郑康       	2026.10.19
+// @param     string		词
+// @return    bool
+func isLatinWord(word string) bool {
+	for _, r := range word {
+		if !unicode.Is(unicode.Latin, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// @title    isWordBoundary
+// @description   			判断原文中的区间前后是否都不是字母
+// @auth      郑康       	2026.10.19
+// @param     string, Span	原文, 区间
+// @return    bool
+func isWordBoundary(text string, span Span) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:span.Start])
+	after, _ := utf8.DecodeRuneInString(text[span.End:])
+	return !unicode.IsLetter(before) && !unicode.IsLetter(after)
+}
+
+// RegexRule在转换为半角和小写的文本中匹配正则表达式，用于识别手机号、链接等
+type RegexRule struct {
+	name    string
+	action  Action
+	pattern *regexp.Regexp
+}
+
+// @title    NewRegexRule
+// @description   			创建正则表达式规则
+// @auth      郑康       	2026.10.19
+// @param     string, Action, string	规则名称, 处理方式, 正则表达式
+// @return    *RegexRule, error	正则表达式规则, 正则表达式不合法时的错误
+func NewRegexRule(name string, action Action, pattern string) (*RegexRule, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexRule{name: name, action: action, pattern: compiled}, nil
+}
+
+func (rule *RegexRule) Name() string {
+	return rule.name
+}
+
+func (rule *RegexRule) Action() Action {
+	return rule.action
+}
+
+func (rule *RegexRule) Find(text string) []Span {
+	res := []Span{}
+	folded := normalize(text, false)
+	for _, match := range rule.pattern.FindAllStringIndex(folded.text, -1) {
+		if match[1] > match[0] {
+			res = append(res, folded.span(match[0], match[1]))
+		}
+	}
+	return res
+}
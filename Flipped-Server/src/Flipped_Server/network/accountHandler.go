@@ -0,0 +1,229 @@
+// @Title  accountHandler.go
+// @Description  To enforce the suspension and ban of accounts and provide the admin http handler of changing the status of an account
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 账号被停用时返回给客户端的状态码(http状态码和socket的ResultCode)，与token无效时的401区分
+const StatusAccountRestricted = http.StatusLocked
+
+// AccountRestrictedError表示账号处于停用状态，Status中包含停用类型、期限和原因
+type AccountRestrictedError struct {
+	Status *dataBase.AccountStatus
+}
+
+func (err *AccountRestrictedError) Error() string {
+	msg := "your account is banned"
+	if err.Status.Status == dataBase.AccountSuspended {
+		msg = "your account is suspended until " + time.Unix(err.Status.Until, 0).Format("2006-01-02 15:04:05")
+	}
+	if err.Status.Reason != "" {
+		msg += ": " + err.Status.Reason
+	}
+	return msg
+}
+
+// AccountStatusUnavailableError表示无法读取账号的状态, 此时拒绝请求而不是放行, 避免存储故障时被停用的账号恢复使用
+type AccountStatusUnavailableError struct {
+	Err error
+}
+
+func (err *AccountStatusUnavailableError) Error() string {
+	return "can't check the status of your account now, please try it again later"
+}
+
+// @title    checkAccountStatus
+// @description   检查账号是否处于停用状态, 优先读取与token一起缓存在会话存储中的状态, 未缓存时查询用户存储并写入缓存
+// @auth      郑康             2026.10.19
+// @param     repository.SessionStore, repository.UserStore, string	  会话存储, 用户存储, 用户名
+// @return    error	  账号停用时为*AccountRestrictedError, 无法读取状态时为*AccountStatusUnavailableError, 否则为nil
+func checkAccountStatus(sessions repository.SessionStore, users repository.UserStore, username string) error {
+	status, err := sessions.ReadAccountStatus(username)
+	if err != nil || status == nil {
+		status, err = users.AccountStatus(username)
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "checkAccountStatus", "find account status of "+username, err.Error())
+			return &AccountStatusUnavailableError{Err: err}
+		}
+		if err := sessions.SaveAccountStatus(status); err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "checkAccountStatus", "cache account status of "+username, err.Error())
+		}
+	}
+	if status.IsRestricted(time.Now().Unix()) {
+		return &AccountRestrictedError{Status: status}
+	}
+	return nil
+}
+
+// @title    tokenErrorStatus
+// @description   根据解析token的错误选择返回给客户端的状态码, 账号停用时为StatusAccountRestricted, 无法读取账号状态时为503, 否则为401
+// @auth      郑康             2026.10.19
+// @param     error	  解析token的错误
+// @return    int	  状态码
+func tokenErrorStatus(err error) int {
+	switch err.(type) {
+	case *AccountRestrictedError:
+		return StatusAccountRestricted
+	case *AccountStatusUnavailableError:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusUnauthorized
+}
+
+// @title    restrictAccount
+// @description   保存账号的新状态及审计记录并更新会话存储中缓存的状态, 账号被停用时立即使其token失效、断开其socket连接并通知好友其已下线
+// @auth      郑康             2026.10.19
+// @param     string, *dataBase.AccountStatus	  操作的管理员, 账号的新状态
+// @return    error	  错误信息
+func (server *HttpServer) restrictAccount(actor string, status *dataBase.AccountStatus) error {
+	change := &dataBase.AccountStatusChange{ID: utils.GeneratorUUID(), Actor: actor, CreatedAt: time.Now().Unix()}
+	if err := server.Stores.Users.SetAccountStatus(status, change); err != nil {
+		return err
+	}
+	//缓存未更新时旧的状态仍会生效, 因此返回错误让管理员重试
+	if err := server.Stores.Sessions.SaveAccountStatus(status); err != nil {
+		return err
+	}
+	if !status.IsRestricted(time.Now().Unix()) {
+		return nil
+	}
+	if err := server.Stores.Sessions.DeleteToken(status.Username); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "restrictAccount", "delete token of "+status.Username, err.Error())
+	}
+	disconnectUser(status.Username, (&AccountRestrictedError{Status: status}).Error())
+	userWentOffline(server.Stores, status.Username)
+	return nil
+}
+
+// @title    disconnectUser
+// @description   向与Socket服务器保持连接的用户推送账号停用的通知后关闭连接, 用户不在线时直接忽略
+// @auth      郑康             2026.10.19
+// @param     string, string	  用户名, 通知内容
+// @return    void
+func disconnectUser(username string, content string) {
+	conn := utils.RemoveUserConnection(username)
+	if conn == nil {
+		return
+	}
+	buf, _ := json.Marshal(utils.NotificationMsg{
+		Notification: utils.NotifyAccountRestricted,
+		MsgContent:   content,
+	})
+	_, _ = utils.WriteToConnection(conn, buf)
+	_ = conn.Close()
+	logger.SetToLogger(logrus.InfoLevel, "disconnectUser", "close the connection of restricted user "+username, "")
+}
+
+// @title    accountStatusHandler
+// @description   管理员修改账号的状态: active(恢复)、suspended(停用hours小时)或banned(永久停用), reason为展示给用户的原因
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) accountStatusHandler(context *gin.Context) {
+	admin, ok := server.authenticateAdmin(context, "accountStatusHandler")
+	if !ok {
+		return
+	}
+	targetUser := context.Param("name")
+	status := &dataBase.AccountStatus{
+		Username: targetUser,
+		Status:   context.DefaultQuery("status", ""),
+		Reason:   strings.TrimSpace(context.DefaultQuery("reason", "")),
+	}
+	code := http.StatusOK
+	msg := "succeed to change the status of the account"
+	switch status.Status {
+	case dataBase.AccountActive, dataBase.AccountBanned:
+	case dataBase.AccountSuspended:
+		hours, err := strconv.Atoi(context.DefaultQuery("hours", ""))
+		if err != nil || hours < 1 || hours > maxSuspensionHours {
+			code = http.StatusBadRequest
+			msg = "'hours' should be an integer between 1 and " + strconv.Itoa(maxSuspensionHours)
+		}
+		status.Until = time.Now().Add(time.Duration(hours) * time.Hour).Unix()
+	default:
+		code = http.StatusBadRequest
+		msg = "'status' should be active, suspended or banned"
+	}
+	if code == http.StatusOK && utf8.RuneCountInString(status.Reason) > maxReportTextLength {
+		code = http.StatusBadRequest
+		msg = "'reason' is too long, please make its length less than " + strconv.Itoa(maxReportTextLength)
+	} else if code == http.StatusOK && targetUser == admin {
+		code = http.StatusBadRequest
+		msg = "can't change the status of your own account"
+	} else if code == http.StatusOK && !server.Stores.Users.DoesUserExist(targetUser) {
+		code = http.StatusNotFound
+		msg = "target user doesn't exist"
+	}
+	if code != http.StatusOK {
+		context.JSON(code, gin.H{
+			"message": msg,
+			"data":    "",
+		})
+		return
+	}
+	if err := server.restrictAccount(admin, status); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "accountStatusHandler", "change the status of "+targetUser, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	logger.SetToLogger(logrus.InfoLevel, "accountStatusHandler", admin+" changes the status of "+targetUser, status.Status)
+	context.JSON(http.StatusOK, gin.H{
+		"message": msg,
+		"data":    status,
+	})
+}
+
+// @title    accountHistoryHandler
+// @description   管理员查看账号当前的状态及修改状态的全部审计记录
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) accountHistoryHandler(context *gin.Context) {
+	if _, ok := server.authenticateAdmin(context, "accountHistoryHandler"); !ok {
+		return
+	}
+	targetUser := context.Param("name")
+	if !server.Stores.Users.DoesUserExist(targetUser) {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "target user doesn't exist",
+			"data":    "",
+		})
+		return
+	}
+	status, err := server.Stores.Users.AccountStatus(targetUser)
+	var history []dataBase.AccountStatusChange
+	if err == nil {
+		history, err = server.Stores.Users.AccountStatusChanges(targetUser)
+	}
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "accountHistoryHandler", "find account status of "+targetUser, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find the status of the account",
+		"data":    gin.H{"status": status, "history": history},
+	})
+}
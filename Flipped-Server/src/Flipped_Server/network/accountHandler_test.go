@@ -0,0 +1,154 @@
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestSuspendAndReinstateAccount(t *testing.T) {
+	server, router := newMemoryServer()
+	server.Admins = []string{"MrSecond"}
+	first := loginAs(t, router, "MrFirst")
+	admin := loginAs(t, router, "MrSecond")
+
+	//被停用的用户保持着socket连接
+	client, conn := net.Pipe()
+	utils.RWLock.Lock()
+	utils.UserConnectionMap = map[string]net.Conn{"MrFirst": conn}
+	utils.RWLock.Unlock()
+	received := make(chan utils.NotificationMsg, 1)
+	go func() {
+		var notification utils.NotificationMsg
+		_ = json.NewDecoder(client).Decode(&notification)
+		received <- notification
+	}()
+
+	code, _ := serve(router, "POST", "/admin/users/MrFirst/status?status=suspended", admin)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/admin/users/MrSecond/status?status=banned", admin)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/admin/users/MrFirst/status?status=banned", first)
+	assert.Equal(t, 403, code)
+	code, _ = serve(router, "POST", "/admin/users/MrFirst/status?status=suspended&hours=2&reason=spam", admin)
+	assert.Equal(t, 200, code)
+
+	notification := <-received
+	assert.Equal(t, utils.NotifyAccountRestricted, notification.Notification)
+	assert.Contains(t, notification.MsgContent, "spam")
+	assert.False(t, utils.IsUserConnected("MrFirst"))
+	assert.False(t, server.Stores.Sessions.HasToken("MrFirst"))
+
+	code, jsonData := serve(router, "GET", "/profile", first)
+	assert.Equal(t, 401, code)
+	code, jsonData = serve(router, "POST", "/login?username=MrFirst&password=123456", "")
+	assert.Equal(t, StatusAccountRestricted, code)
+	assert.Equal(t, "suspended", jsonData["data"].(map[string]interface{})["status"])
+
+	code, _ = serve(router, "POST", "/admin/users/MrFirst/status?status=active", admin)
+	assert.Equal(t, 200, code)
+	first = loginAs(t, router, "MrFirst")
+	code, _ = serve(router, "GET", "/profile", first)
+	assert.Equal(t, 200, code)
+
+	//每次修改都留有审计记录, 包括恢复账号
+	code, _ = serve(router, "GET", "/admin/users/MrFirst/status", first)
+	assert.Equal(t, 403, code)
+	code, jsonData = serve(router, "GET", "/admin/users/MrFirst/status", admin)
+	assert.Equal(t, 200, code)
+	history := jsonData["data"].(map[string]interface{})["history"].([]interface{})
+	assert.Len(t, history, 2)
+	reinstated := history[1].(map[string]interface{})
+	assert.Equal(t, "MrSecond", reinstated["actor"])
+	assert.Equal(t, "suspended", reinstated["from"])
+	assert.Equal(t, "active", reinstated["status"])
+
+	//停用期间仍然有效的token同样会被拒绝
+	status, _ := server.Stores.Users.AccountStatus("MrFirst")
+	status.Status = "banned"
+	_ = server.Stores.Users.SetAccountStatus(status, &dataBase.AccountStatusChange{Actor: "MrSecond"})
+	_ = server.Stores.Sessions.SaveAccountStatus(status)
+	code, jsonData = serve(router, "GET", "/profile", first)
+	assert.Equal(t, StatusAccountRestricted, code)
+	assert.Equal(t, "your account is banned", jsonData["message"])
+	code, jsonData = serve(router, "GET", "/heartBeat", first)
+	assert.Equal(t, StatusAccountRestricted, code)
+	assert.Equal(t, "your account is banned", jsonData["data"])
+	code, _ = serve(router, "GET", "/heartBeat", "invalid")
+	assert.Equal(t, 401, code)
+}
+
+func TestResolveReportWithBan(t *testing.T) {
+	server, router := newMemoryServer()
+	server.Admins = []string{"MrSecond"}
+	admin := loginAs(t, router, "MrSecond")
+
+	_, jsonData := serve(router, "POST", "/reports?username=MrFirst&reason=fake", admin)
+	id := jsonData["data"].(map[string]interface{})["id"].(string)
+	code, _ := serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=ban", admin)
+	assert.Equal(t, 200, code)
+
+	status, _ := server.Stores.Users.AccountStatus("MrFirst")
+	assert.Equal(t, "banned", status.Status)
+	assert.Equal(t, "reported for fake", status.Reason)
+	code, _ = serve(router, "POST", "/login?username=MrFirst&password=123456", "")
+	assert.Equal(t, StatusAccountRestricted, code)
+}
+
+// failingAccountStore模拟无法读取账号状态的用户存储
+type failingAccountStore struct {
+	repository.UserStore
+}
+
+func (store *failingAccountStore) AccountStatus(username string) (*dataBase.AccountStatus, error) {
+	return nil, errors.New("mysql is down")
+}
+
+// uncachedSessionStore模拟账号状态的缓存已过期
+type uncachedSessionStore struct {
+	repository.SessionStore
+}
+
+func (store *uncachedSessionStore) ReadAccountStatus(username string) (*dataBase.AccountStatus, error) {
+	return nil, nil
+}
+
+func TestAccountStatusUnavailable(t *testing.T) {
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	//登录时缓存的状态在读取失败时仍然有效
+	server.Stores.Users = &failingAccountStore{UserStore: server.Stores.Users}
+	code, _ := serve(router, "GET", "/profile", first)
+	assert.Equal(t, 200, code)
+
+	//缓存过期后无法读取状态时拒绝请求, 而不是放行
+	server.Stores.Sessions = &uncachedSessionStore{SessionStore: server.Stores.Sessions}
+	code, _ = serve(router, "GET", "/profile", first)
+	assert.Equal(t, 503, code)
+	code, _ = serve(router, "GET", "/friendList", first)
+	assert.Equal(t, 503, code)
+	code, _ = serve(router, "POST", "/login?username=MrFirst&password=123456", "")
+	assert.Equal(t, 503, code)
+}
+
+func TestConflictingResolveDoesNotRestrict(t *testing.T) {
+	server, router := newMemoryServer()
+	server.Admins = []string{"MrSecond"}
+	admin := loginAs(t, router, "MrSecond")
+	_, jsonData := serve(router, "POST", "/reports?username=MrFirst&reason=spam", admin)
+	id := jsonData["data"].(map[string]interface{})["id"].(string)
+	code, _ := serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=dismissed", admin)
+	assert.Equal(t, 200, code)
+
+	server.Stores.Reports = &staleReportStore{ReportStore: server.Stores.Reports}
+	code, _ = serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=ban", admin)
+	assert.Equal(t, 409, code)
+	status, _ := server.Stores.Users.AccountStatus("MrFirst")
+	assert.Equal(t, dataBase.AccountActive, status.Status)
+	loginAs(t, router, "MrFirst")
+}
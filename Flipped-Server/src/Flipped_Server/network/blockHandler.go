@@ -0,0 +1,130 @@
+// @Title  blockHandler.go
+// @Description  To provide the http handlers of blocking and unblocking users
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// @title    blockUser
+// @description   拉黑用户, 同时解除双方的好友关系并删除双方之间待处理的好友请求
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) blockUser(context *gin.Context) {
+	sourceUser, ok := server.authenticate(context, "blockUser")
+	if !ok {
+		return
+	}
+	targetUser := context.DefaultQuery("username", "")
+	status := http.StatusOK
+	msg := "succeed to block the user"
+	if targetUser == "" {
+		status = http.StatusBadRequest
+		msg = "key 'username' is required"
+	} else if targetUser == sourceUser {
+		status = http.StatusBadRequest
+		msg = "can't block yourself"
+	} else if !server.Stores.Users.DoesUserExist(targetUser) {
+		status = http.StatusNotFound
+		msg = "target user doesn't exist"
+	} else if err := server.Stores.Blocks.Block(sourceUser, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "blockUser", "add user to block list", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if err := server.cutTies(sourceUser, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "blockUser", "remove friendship and friend requests", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	}
+	context.JSON(status, gin.H{
+		"message": msg,
+		"data":    "",
+	})
+}
+
+// @title    cutTies
+// @description   解除两个用户之间的好友关系并删除双方之间待处理的好友请求, 不存在的请求不视为错误
+// @auth      郑康             2026.10.19
+// @param     string, string	  发起拉黑的用户, 被拉黑的用户
+// @return    error			  错误信息
+func (server *HttpServer) cutTies(sourceUser string, targetUser string) error {
+	if err := server.Stores.Friends.DeleteFriend(sourceUser, targetUser); err != nil {
+		return err
+	}
+	if err := server.Stores.Friends.DeleteFriend(targetUser, sourceUser); err != nil {
+		return err
+	}
+	if err := server.Stores.FriendRequests.RemoveRequest(sourceUser, targetUser); err != nil && err != dataBase.ErrFriendRequestNotFound {
+		return err
+	}
+	if err := server.Stores.FriendRequests.RemoveRequest(targetUser, sourceUser); err != nil && err != dataBase.ErrFriendRequestNotFound {
+		return err
+	}
+	return nil
+}
+
+// @title    unblockUser
+// @description   将用户移出黑名单, 不会恢复原有的好友关系
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) unblockUser(context *gin.Context) {
+	sourceUser, ok := server.authenticate(context, "unblockUser")
+	if !ok {
+		return
+	}
+	targetUser := context.DefaultQuery("username", "")
+	status := http.StatusOK
+	msg := "succeed to unblock the user"
+	if targetUser == "" {
+		status = http.StatusBadRequest
+		msg = "key 'username' is required"
+	} else if blocked, err := server.Stores.Blocks.IsBlocked(sourceUser, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "unblockUser", "check block list", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if !blocked {
+		status = http.StatusNotFound
+		msg = "the user is not in your block list"
+	} else if err := server.Stores.Blocks.Unblock(sourceUser, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "unblockUser", "remove user from block list", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	}
+	context.JSON(status, gin.H{
+		"message": msg,
+		"data":    "",
+	})
+}
+
+// @title    blockListHandler
+// @description   获取当前用户拉黑的全部用户
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) blockListHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "blockListHandler")
+	if !ok {
+		return
+	}
+	blockList, err := server.Stores.Blocks.GetBlockList(username)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "blockListHandler", "get block list", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find block list",
+		"data":    blockList,
+	})
+}
@@ -0,0 +1,89 @@
+package network
+
+import (
+	"Flipped_Server/repository"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// failingBlockStore模拟无法读取黑名单的存储
+type failingBlockStore struct {
+	repository.BlockStore
+}
+
+func (store *failingBlockStore) IsBlocked(sourceUser string, targetUser string) (bool, error) {
+	return false, errors.New("block list is unavailable")
+}
+
+// failingFriendStore模拟无法解除好友关系的存储
+type failingFriendStore struct {
+	repository.FriendStore
+}
+
+func (store *failingFriendStore) DeleteFriend(sourceUser string, targetUser string) error {
+	return errors.New("friend list is unavailable")
+}
+
+func TestBlockUser(t *testing.T) {
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	second := loginAs(t, router, "MrSecond")
+	_ = server.Stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+
+	code, _ := serve(router, "POST", "/blockUser?username=MrFirst", first)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/blockUser?username=MrSecond", first)
+	assert.Equal(t, 200, code)
+	_, jsonData := serve(router, "GET", "/blockList", first)
+	assert.Equal(t, []interface{}{"MrSecond"}, jsonData["data"])
+
+	friendList, _ := server.Stores.Friends.GetFriendList("MrSecond")
+	assert.Empty(t, friendList)
+	code, _ = serve(router, "POST", "/sendFriendRequest?friend=MrFirst", second)
+	assert.Equal(t, 403, code)
+	_, err := server.selectSimilarUser("MrSecond")
+	assert.Error(t, err)
+
+	code, _ = serve(router, "POST", "/unblockUser?username=MrSecond", second)
+	assert.Equal(t, 404, code)
+	code, _ = serve(router, "POST", "/unblockUser?username=MrSecond", first)
+	assert.Equal(t, 200, code)
+	code, _ = serve(router, "POST", "/sendFriendRequest?friend=MrFirst", second)
+	assert.Equal(t, 200, code)
+	recommended, err := server.selectSimilarUser("MrSecond")
+	assert.NoError(t, err)
+	assert.Equal(t, "MrFirst", recommended.Username)
+}
+
+func TestBlockListFailureDeniesRequests(t *testing.T) {
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	server.Stores.Blocks = &failingBlockStore{BlockStore: server.Stores.Blocks}
+
+	code, _ := serve(router, "POST", "/sendFriendRequest?friend=MrSecond", first)
+	assert.Equal(t, 500, code)
+	code, _ = serve(router, "POST", "/swipe?username=MrSecond&action=like", first)
+	assert.Equal(t, 500, code)
+	code, _ = serve(router, "GET", "/users/MrSecond", first)
+	assert.Equal(t, 500, code)
+	assert.False(t, server.Stores.FriendRequests.HasRequest("MrFirst", "MrSecond"))
+}
+
+func TestBlockUserFailsWhenFriendshipCannotBeRemoved(t *testing.T) {
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	_ = server.Stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+	friends := server.Stores.Friends
+	server.Stores.Friends = &failingFriendStore{FriendStore: friends}
+
+	code, _ := serve(router, "POST", "/blockUser?username=MrSecond", first)
+	assert.Equal(t, 500, code)
+
+	//没有待处理的好友请求时拉黑仍然成功
+	server.Stores.Friends = friends
+	code, _ = serve(router, "POST", "/blockUser?username=MrSecond", first)
+	assert.Equal(t, 200, code)
+	friendList, _ := server.Stores.Friends.GetFriendList("MrFirst")
+	assert.NotContains(t, friendList, "MrSecond")
+}
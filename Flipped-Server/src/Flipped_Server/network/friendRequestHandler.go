@@ -0,0 +1,204 @@
+// @Title  friendRequestHandler.go
+// @Description  To provide the http handlers of the friend request workflow
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// @title    sendFriendRequest
+// @description   向用户发送好友请求, 对方在线时通过Socket推送通知
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) sendFriendRequest(context *gin.Context) {
+	sourceUser, ok := server.authenticate(context, "sendFriendRequest")
+	if !ok {
+		return
+	}
+	targetUser := context.DefaultQuery("friend", "")
+	status := http.StatusOK
+	msg := "succeed to send friend request"
+	if targetUser == "" {
+		status = http.StatusBadRequest
+		msg = "key 'friend' is required"
+	} else if targetUser == sourceUser {
+		status = http.StatusBadRequest
+		msg = "can't send a friend request to yourself"
+	} else if !server.Stores.Users.DoesUserExist(targetUser) {
+		status = http.StatusNotFound
+		msg = "target user doesn't exist"
+	} else if blocked, err := repository.IsBlockedBetween(server.Stores.Blocks, sourceUser, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "sendFriendRequest", "check block list", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if blocked {
+		status = http.StatusForbidden
+		msg = "you can't send a friend request to the user"
+	} else if friendList, _ := server.Stores.Friends.GetFriendList(sourceUser); utils.Contains(friendList, targetUser) {
+		status = http.StatusConflict
+		msg = "target user is already your friend"
+	} else if server.Stores.FriendRequests.HasRequest(sourceUser, targetUser) {
+		status = http.StatusConflict
+		msg = "you have already sent a friend request to the user"
+	} else if server.Stores.FriendRequests.HasRequest(targetUser, sourceUser) {
+		status = http.StatusConflict
+		msg = "the user has already sent you a friend request, please accept it"
+	} else if err := server.Stores.FriendRequests.SendRequest(sourceUser, targetUser); err == dataBase.ErrFriendRequestExists {
+		//并发发送时由唯一索引保证只保存一条
+		status = http.StatusConflict
+		msg = "you have already sent a friend request to the user"
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "sendFriendRequest", "save friend request", err.Error())
+		status = http.StatusInternalServerError
+		msg = "there is something wrong when sending friend request, please try it latter"
+	} else {
+		notifyUser(targetUser, utils.NotifyFriendRequest, sourceUser, sourceUser+" wants to be your friend")
+	}
+	context.JSON(status, gin.H{
+		"message": msg,
+		"data":    "",
+	})
+}
+
+// @title    friendRequestListHandler
+// @description   获取收到的(direction=incoming, 默认)或发出的(direction=outgoing)待处理好友请求
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) friendRequestListHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "friendRequestListHandler")
+	if !ok {
+		return
+	}
+	direction := context.DefaultQuery("direction", "incoming")
+	if direction != "incoming" && direction != "outgoing" {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "value of 'direction' should be 'incoming' or 'outgoing'",
+			"data":    "",
+		})
+		return
+	}
+	requests, err := server.Stores.FriendRequests.ListIncoming(username)
+	if direction == "outgoing" {
+		requests, err = server.Stores.FriendRequests.ListOutgoing(username)
+	}
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "friendRequestListHandler", "list "+direction+" friend requests", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find friend requests",
+		"data":    requests,
+	})
+}
+
+// @title    acceptFriendRequest
+// @description   接受好友请求, 双方互相成为好友并通知请求的发送方
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) acceptFriendRequest(context *gin.Context) {
+	username, ok := server.authenticate(context, "acceptFriendRequest")
+	if !ok {
+		return
+	}
+	sourceUser := context.DefaultQuery("friend", "")
+	status := http.StatusOK
+	msg := "succeed to accept friend request"
+	if sourceUser == "" {
+		status = http.StatusBadRequest
+		msg = "key 'friend' is required"
+	} else if !server.Stores.FriendRequests.HasRequest(sourceUser, username) {
+		status = http.StatusNotFound
+		msg = "the friend request doesn't exist"
+	} else if blocked, err := repository.IsBlockedBetween(server.Stores.Blocks, sourceUser, username); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "acceptFriendRequest", "check block list", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if blocked {
+		status = http.StatusForbidden
+		msg = "you can't add the user as your friend"
+	} else if err := server.Stores.FriendRequests.RemoveRequest(sourceUser, username); err == dataBase.ErrFriendRequestNotFound {
+		//先删除请求, 并发接受同一请求时只有一方成功
+		status = http.StatusNotFound
+		msg = "the friend request doesn't exist"
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "acceptFriendRequest", "remove friend request", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if err := server.Stores.Friends.AddMutualFriends(sourceUser, username); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "acceptFriendRequest", "add mutual friends", err.Error())
+		//恢复被删除的请求以便用户重试
+		_ = server.Stores.FriendRequests.SendRequest(sourceUser, username)
+		status = http.StatusInternalServerError
+		msg = "there is something wrong when adding friend, please try it latter"
+	} else {
+		notifyUser(sourceUser, utils.NotifyFriendRequestAccepted, username, username+" accepted your friend request")
+	}
+	context.JSON(status, gin.H{
+		"message": msg,
+		"data":    "",
+	})
+}
+
+// @title    declineFriendRequest
+// @description   拒绝收到的好友请求
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) declineFriendRequest(context *gin.Context) {
+	username, ok := server.authenticate(context, "declineFriendRequest")
+	if !ok {
+		return
+	}
+	server.removeFriendRequest(context, context.DefaultQuery("friend", ""), username, "succeed to decline friend request")
+}
+
+// @title    cancelFriendRequest
+// @description   撤回自己发出的好友请求
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) cancelFriendRequest(context *gin.Context) {
+	username, ok := server.authenticate(context, "cancelFriendRequest")
+	if !ok {
+		return
+	}
+	server.removeFriendRequest(context, username, context.DefaultQuery("friend", ""), "succeed to cancel friend request")
+}
+
+func (server *HttpServer) removeFriendRequest(context *gin.Context, sourceUser string, targetUser string, successMsg string) {
+	status := http.StatusOK
+	msg := successMsg
+	if sourceUser == "" || targetUser == "" {
+		status = http.StatusBadRequest
+		msg = "key 'friend' is required"
+	} else if !server.Stores.FriendRequests.HasRequest(sourceUser, targetUser) {
+		status = http.StatusNotFound
+		msg = "the friend request doesn't exist"
+	} else if err := server.Stores.FriendRequests.RemoveRequest(sourceUser, targetUser); err == dataBase.ErrFriendRequestNotFound {
+		status = http.StatusNotFound
+		msg = "the friend request doesn't exist"
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "removeFriendRequest", "remove friend request", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	}
+	context.JSON(status, gin.H{
+		"message": msg,
+		"data":    "",
+	})
+}
@@ -0,0 +1,61 @@
+package network
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFriendRequestAccept(t *testing.T) {
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	second := loginAs(t, router, "MrSecond")
+
+	code, _ := serve(router, "POST", "/sendFriendRequest?friend=Nobody", first)
+	assert.Equal(t, 404, code)
+	code, _ = serve(router, "POST", "/sendFriendRequest?friend=MrFirst", first)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/addFriend?friend=MrSecond", first)
+	assert.Equal(t, 200, code)
+	code, _ = serve(router, "POST", "/sendFriendRequest?friend=MrSecond", first)
+	assert.Equal(t, 409, code)
+	code, _ = serve(router, "POST", "/sendFriendRequest?friend=MrFirst", second)
+	assert.Equal(t, 409, code)
+
+	friendList, _ := server.Stores.Friends.GetFriendList("MrFirst")
+	assert.Empty(t, friendList)
+
+	_, jsonData := serve(router, "GET", "/friendRequests", second)
+	assert.Len(t, jsonData["data"], 1)
+	_, jsonData = serve(router, "GET", "/friendRequests?direction=outgoing", first)
+	assert.Len(t, jsonData["data"], 1)
+
+	code, _ = serve(router, "POST", "/acceptFriendRequest?friend=MrFirst", second)
+	assert.Equal(t, 200, code)
+	friendList, _ = server.Stores.Friends.GetFriendList("MrFirst")
+	assert.Equal(t, []string{"MrSecond"}, friendList)
+	friendList, _ = server.Stores.Friends.GetFriendList("MrSecond")
+	assert.Equal(t, []string{"MrFirst"}, friendList)
+
+	_, jsonData = serve(router, "GET", "/friendRequests", second)
+	assert.Len(t, jsonData["data"], 0)
+	code, _ = serve(router, "POST", "/acceptFriendRequest?friend=MrFirst", second)
+	assert.Equal(t, 404, code)
+}
+
+func TestFriendRequestDeclineAndCancel(t *testing.T) {
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	second := loginAs(t, router, "MrSecond")
+
+	serve(router, "POST", "/sendFriendRequest?friend=MrSecond", first)
+	code, _ := serve(router, "POST", "/declineFriendRequest?friend=MrFirst", second)
+	assert.Equal(t, 200, code)
+	assert.False(t, server.Stores.FriendRequests.HasRequest("MrFirst", "MrSecond"))
+
+	serve(router, "POST", "/sendFriendRequest?friend=MrSecond", first)
+	code, _ = serve(router, "POST", "/cancelFriendRequest?friend=MrFirst", first)
+	assert.Equal(t, 404, code)
+	code, _ = serve(router, "POST", "/cancelFriendRequest?friend=MrSecond", first)
+	assert.Equal(t, 200, code)
+	assert.False(t, server.Stores.FriendRequests.HasRequest("MrFirst", "MrSecond"))
+}
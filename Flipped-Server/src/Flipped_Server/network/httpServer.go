@@ -5,12 +5,15 @@
 package network
 
 import (
+	"Flipped_Server/blobStorage"
 	"Flipped_Server/dataBase"
+	"Flipped_Server/imagePipeline"
 	"Flipped_Server/logger"
-	"Flipped_Server/sqlmapper"
+	"Flipped_Server/moderation"
+	"Flipped_Server/recommend"
+	"Flipped_Server/repository"
 	"Flipped_Server/utils"
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
@@ -18,7 +21,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
-	"sync"
+	"time"
 )
 
 // IFunction接口包含了http路由处理函数
@@ -33,42 +36,116 @@ type IFunction interface {
 	closeServer(context *gin.Context)
 	addFriend(context *gin.Context)
 	deleteFriend(context *gin.Context)
+	sendFriendRequest(context *gin.Context)
+	friendRequestListHandler(context *gin.Context)
+	acceptFriendRequest(context *gin.Context)
+	declineFriendRequest(context *gin.Context)
+	cancelFriendRequest(context *gin.Context)
+	blockUser(context *gin.Context)
+	unblockUser(context *gin.Context)
+	blockListHandler(context *gin.Context)
+	profileHandler(context *gin.Context)
+	updateProfileHandler(context *gin.Context)
+	userProfileHandler(context *gin.Context)
+	searchUsersHandler(context *gin.Context)
+	swipeHandler(context *gin.Context)
+	matchListHandler(context *gin.Context)
+	unmatchHandler(context *gin.Context)
+	messageHistoryHandler(context *gin.Context)
+	recommendationsHandler(context *gin.Context)
+	updateLocationHandler(context *gin.Context)
+	tagsHandler(context *gin.Context)
+	setTagsHandler(context *gin.Context)
+	photosHandler(context *gin.Context)
+	uploadPhotoHandler(context *gin.Context)
+	deletePhotoHandler(context *gin.Context)
+	reorderPhotosHandler(context *gin.Context)
+	setPrimaryPhotoHandler(context *gin.Context)
+	imageHandler(context *gin.Context)
+	reportHandler(context *gin.Context)
+	adminReportsHandler(context *gin.Context)
+	adminReportHandler(context *gin.Context)
+	triageReportHandler(context *gin.Context)
+	resolveReportHandler(context *gin.Context)
+	accountStatusHandler(context *gin.Context)
+	accountHistoryHandler(context *gin.Context)
 }
 
-// HttpServer结构体包含了Http服务器绑定的IP地址和端口号
+// HttpServer结构体包含了Http服务器绑定的IP地址和端口号, 以及处理请求时使用的存储、推荐器、图片配置、图片文件的存储、图片文件的清理任务、内容审核器和管理员列表
 type HttpServer struct {
-	IPAddr string
-	Port   int
+	IPAddr      string
+	Port        int
+	Stores      *repository.Stores
+	Recommender *recommend.Recommender
+	Images      *imagePipeline.Config
+	Blobs       blobStorage.BlobStore
+	Sweeper     *blobStorage.Sweeper
+	Moderator   *moderation.Moderator
+	Admins      []string
 }
 
 // 全局变量，gin实例
 var (
 	Router *gin.Engine
-	lock   = &sync.Mutex{}
 )
 
 // @title    Run
-// @description   绑定路由处理函数、初始化mysql数据库、日志模块、初始化Redis数据库、初始化MongoDB数据库、启动Http服务器
+// @description   初始化存储、绑定路由处理函数、启动推荐和图片清理的后台任务以及Http服务器
 // @auth      郑康             2020.5.17
 // @param     void
 // @return    void
 func (server *HttpServer) Run() {
 	gin.SetMode(gin.ReleaseMode)
-	Router = gin.Default()
-	server.bindRouteAndHandler()
-	dataBase.Init()
-	logger.InitLog()
-	dataBase.RedisClientInit()
-	dataBase.InitializeMongoDB()
-	_ = Router.Run(server.IPAddr + ":" + strconv.Itoa(server.Port))
+	router := server.SetupRouter()
+	server.Recommender.RunBackgroundJobs(nil)
+	go server.Sweeper.Run(nil)
+	_ = router.Run(server.IPAddr + ":" + strconv.Itoa(server.Port))
 }
 
+// @title    SetupRouter
+// @description   未注入存储时初始化mysql数据库、日志模块、Redis数据库、MongoDB数据库, 未注入推荐器和图片配置时按配置文件创建, 未注入图片文件的存储及其清理任务、内容审核器和管理员列表时按配置文件创建, 然后创建gin实例并绑定路由
+// @auth      郑康             2026.10.19
+// @param     void
+// @return    *gin.Engine	  gin实例
 func (server *HttpServer) SetupRouter() *gin.Engine {
+	if server.Stores == nil {
+		server.Stores = repository.InitBackendStores()
+	}
+	if server.Recommender == nil {
+		server.Recommender = recommend.NewRecommender(server.Stores, recommend.LoadConfig())
+	}
+	if server.Images == nil {
+		config := imagePipeline.LoadConfig()
+		server.Images = &config
+	}
+	if server.Blobs == nil {
+		blobs, err := blobStorage.LoadStore()
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "SetupRouter", "create the storage of images", err.Error())
+			panic(err)
+		}
+		server.Blobs = blobs
+	}
+	if server.Sweeper == nil {
+		thumbnails := make([]string, 0, len(server.Images.Thumbnails))
+		for name := range server.Images.Thumbnails {
+			thumbnails = append(thumbnails, name)
+		}
+		server.Sweeper = &blobStorage.Sweeper{Stores: server.Stores, Blobs: server.Blobs, Config: blobStorage.LoadSweeperConfig(thumbnails)}
+	}
+	if server.Moderator == nil {
+		moderator, err := moderation.LoadModerator()
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "SetupRouter", "create the moderator", err.Error())
+			panic(err)
+		}
+		server.Moderator = moderator
+	}
+	if server.Admins == nil {
+		server.Admins = loadAdmins()
+	}
+	Router = gin.Default()
 	server.bindRouteAndHandler()
-	dataBase.Init()
-	logger.InitLog()
-	dataBase.RedisClientInit()
-	dataBase.InitializeMongoDB()
 	return Router
 }
 
@@ -82,12 +159,45 @@ func (server *HttpServer) bindRouteAndHandler() {
 	Router.POST("/register", server.registerHandler)
 	Router.GET("/friendList", server.friendsListHandler)
 	Router.GET("/recommendUser", server.recommendedFriendsListHandler)
+	Router.GET("/recommendations", server.recommendationsHandler)
 	Router.GET("/heartBeat", server.heartBeatHandler)
 	Router.GET("/onlineUserNumber", server.countOnlineUserNumber)
 	Router.POST("/isAlive", server.judgeUserAlive)
 	Router.GET("/closeServer", server.closeServer)
 	Router.POST("/addFriend", server.addFriend)
 	Router.POST("/deleteFriend", server.deleteFriend)
+	Router.POST("/sendFriendRequest", server.sendFriendRequest)
+	Router.GET("/friendRequests", server.friendRequestListHandler)
+	Router.POST("/acceptFriendRequest", server.acceptFriendRequest)
+	Router.POST("/declineFriendRequest", server.declineFriendRequest)
+	Router.POST("/cancelFriendRequest", server.cancelFriendRequest)
+	Router.POST("/blockUser", server.blockUser)
+	Router.POST("/unblockUser", server.unblockUser)
+	Router.GET("/blockList", server.blockListHandler)
+	Router.GET("/profile", server.profileHandler)
+	Router.PATCH("/profile", server.updateProfileHandler)
+	Router.PUT("/profile/tags", server.setTagsHandler)
+	Router.GET("/tags", server.tagsHandler)
+	Router.GET("/photos", server.photosHandler)
+	Router.POST("/photos", server.uploadPhotoHandler)
+	Router.DELETE("/photos/:id", server.deletePhotoHandler)
+	Router.PUT("/photos/order", server.reorderPhotosHandler)
+	Router.POST("/photos/:id/primary", server.setPrimaryPhotoHandler)
+	Router.GET("/images/:id", server.imageHandler)
+	Router.GET("/images/:id/:variant", server.imageHandler)
+	Router.GET("/users/:name", server.userProfileHandler)
+	Router.POST("/swipe", server.swipeHandler)
+	Router.GET("/matches", server.matchListHandler)
+	Router.POST("/unmatch", server.unmatchHandler)
+	Router.GET("/messages", server.messageHistoryHandler)
+	Router.POST("/location", server.updateLocationHandler)
+	Router.POST("/reports", server.reportHandler)
+	Router.GET("/admin/reports", server.adminReportsHandler)
+	Router.GET("/admin/reports/:id", server.adminReportHandler)
+	Router.POST("/admin/reports/:id/triage", server.triageReportHandler)
+	Router.POST("/admin/reports/:id/resolve", server.resolveReportHandler)
+	Router.GET("/admin/users/:name/status", server.accountHistoryHandler)
+	Router.POST("/admin/users/:name/status", server.accountStatusHandler)
 }
 
 // @title    registerHandler
@@ -97,6 +207,15 @@ func (server *HttpServer) bindRouteAndHandler() {
 // @return    void
 func (server *HttpServer) registerHandler(context *gin.Context) {
 	var res bytes.Buffer
+	//先限制请求体的大小再解析表单, checkRegister会读取表单
+	if err := server.parseUploadForm(context); err == errUploadTooLarge {
+		context.String(http.StatusRequestEntityTooLarge, err.Error())
+		return
+	} else if err != nil {
+		logger.SetToLogger(logrus.InfoLevel, "registerHandler", "parse the uploaded form", err.Error())
+		context.String(http.StatusBadRequest, "upload file is unacceptable")
+		return
+	}
 	responseStr, status, err := checkRegister(context)
 	if err != nil {
 		context.String(status, responseStr)
@@ -106,22 +225,17 @@ func (server *HttpServer) registerHandler(context *gin.Context) {
 		email := context.DefaultQuery("email", "")
 		password := context.DefaultQuery("password", "")
 
-		if isUserNameRepeated(name) {
+		if server.Stores.Users.DoesUserExist(name) {
 			context.String(http.StatusBadRequest, "username already exists, please set another username")
 			logger.SetToLogger(logrus.InfoLevel, "registerHandler", "repeated username", "")
 			return
 		}
 
-		form, _ := context.MultipartForm()
-		if len(form.File["photo"]) == 0 {
+		photo, err := uploadedPhotoOf(context)
+		if err == nil && photo == nil {
 			context.String(http.StatusBadRequest, "key 'photo' should be in request body and value of it should be a image")
 			return
 		}
-		photoInfo := form.File["photo"][0]
-
-		photoName := photoInfo.Filename
-		photo := make([]byte, photoInfo.Size)
-		file, err := photoInfo.Open()
 
 		if err1 != nil {
 			logger.SetToLogger(logrus.ErrorLevel, "registerHandler", "error to convert user_type", err1.Error())
@@ -129,14 +243,25 @@ func (server *HttpServer) registerHandler(context *gin.Context) {
 			responseStr = "wrong data type of 'userType'"
 		}
 		if err != nil {
-			logger.SetToLogger(logrus.ErrorLevel, "registerHandler", "error to open photoInfo", err.Error())
+			logger.SetToLogger(logrus.ErrorLevel, "registerHandler", "error to read the uploaded photo", err.Error())
 			status = http.StatusBadRequest
 			responseStr = "upload file is unacceptable"
-
 		} else {
-			count, _ := file.Read(photo)
-			res.WriteString(fmt.Sprintf("Photo total %d bytes\n", count))
-			defer file.Close()
+			res.WriteString(fmt.Sprintf("Photo total %d bytes\n", len(photo)))
+		}
+		if status != http.StatusOK {
+			context.String(status, responseStr)
+			return
+		}
+		//校验并重新编码头像，图片不合法时不创建用户
+		image, err := server.saveImage(name, photo)
+		if imagePipeline.IsRejected(err) {
+			context.String(http.StatusBadRequest, err.Error())
+			return
+		} else if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "registerHandler", "save the photo", err.Error())
+			context.String(http.StatusInternalServerError, "some error occur in the server, Please try again")
+			return
 		}
 
 		res.WriteString(fmt.Sprintf("type: %d, name: %s, email: %s, password: %s\n", userType, name, email, password))
@@ -147,7 +272,7 @@ func (server *HttpServer) registerHandler(context *gin.Context) {
 			Password:   password,
 			UserType:   userType,
 			Email:      email,
-			Photo:      utils.GetImageURL(photoName, photo),
+			Photo:      image.ID,
 			RealName:   "",
 			Profession: "",
 			Age:        0,
@@ -155,12 +280,26 @@ func (server *HttpServer) registerHandler(context *gin.Context) {
 			Hobby:      "",
 		}
 
-		err2 := sqlmapper.Insert(registerTable, "userinfo")
+		err2 := server.Stores.Users.InsertUser(&registerTable)
 
 		if err2 != nil {
 			status = http.StatusInternalServerError
 			responseStr = "Get an error when insert into DataBase"
 			logger.SetToLogger(logrus.ErrorLevel, "registerHandler", "Insert into database using 'sqlmapper.Insert'", err2.Error())
+			server.releaseImage(image.ID)
+		} else {
+			//注册时上传的头像作为相册中的第一张主照片
+			err2 = server.Stores.Photos.AddPhoto(&dataBase.Photo{
+				ID:        utils.GeneratorUUID(),
+				Username:  name,
+				Path:      registerTable.Photo,
+				Primary:   true,
+				Size:      image.Variants[dataBase.OriginalVariant].Blob.Size,
+				CreatedAt: time.Now().Unix(),
+			}, maxGalleryPhotos)
+			if err2 != nil {
+				logger.SetToLogger(logrus.ErrorLevel, "registerHandler", "add the photo to the gallery", err2.Error())
+			}
 		}
 
 		if status == http.StatusOK {
@@ -169,14 +308,14 @@ func (server *HttpServer) registerHandler(context *gin.Context) {
 			context.String(status, responseStr)
 		}
 
-		_ = dataBase.InitUserFriendList(name)
+		_ = server.Stores.Friends.InitFriendList(name)
 		logger.SetToLogger(logrus.InfoLevel, "registerHandler", "receive Request from client", "response: "+responseStr+", Status: "+strconv.Itoa(status))
 	}
 }
 
 // @title    loginHandler
-// @description   登录路由的处理函数
-// @auth      郑康             2020.5.17
+// @description   登录路由的处理函数, 账号被停用时拒绝登录并返回停用的类型、期限和原因
+// @auth      郑康             2026.10.19
 // @param     *gin.Context	  gin的上下文指针
 // @return    void
 func (server *HttpServer) loginHandler(context *gin.Context) {
@@ -187,7 +326,7 @@ func (server *HttpServer) loginHandler(context *gin.Context) {
 	var msg string = ""
 	var data interface{}
 
-	userInfo, err := dataBase.FindUserInfo(username, pwd)
+	userInfo, err := server.Stores.Users.FindUserInfo(username, pwd)
 	if err != nil {
 		logger.Logger.WithFields(logrus.Fields{
 			"Function": "loginHandler",
@@ -206,10 +345,18 @@ func (server *HttpServer) loginHandler(context *gin.Context) {
 		status = http.StatusNotFound
 		msg = "account does't exist or wrong username or wrong password"
 		data = ""
+	} else if statusErr := checkAccountStatus(server.Stores.Sessions, server.Stores.Users, username); statusErr != nil {
+		status = tokenErrorStatus(statusErr)
+		msg = statusErr.Error()
+		data = ""
+		if restricted, ok := statusErr.(*AccountRestrictedError); ok {
+			logger.SetToLogger(logrus.InfoLevel, "loginHandler", "refuse to login restricted account", username)
+			data = restricted.Status
+		}
 	} else {
 		var tokenStr string
-		if dataBase.KeyExists(username, 0) {
-			tokenStr, err = dataBase.ReadFromRedis(username)
+		if server.Stores.Sessions.HasToken(username) {
+			tokenStr, err = server.Stores.Sessions.ReadToken(username)
 			if err != nil {
 				status = http.StatusInternalServerError
 				msg = "there is something going wrong with the server, please try it again"
@@ -222,7 +369,7 @@ func (server *HttpServer) loginHandler(context *gin.Context) {
 				}
 			}
 		} else {
-			tokenStr, err = GenerateToken(username)
+			tokenStr, err = GenerateToken(server.Stores.Sessions, username)
 			if err != nil {
 				status = http.StatusInternalServerError
 				msg = "there is something going wrong with the server, please try it again"
@@ -243,28 +390,30 @@ func (server *HttpServer) loginHandler(context *gin.Context) {
 	})
 }
 
-// @title    loginHandler
-// @description   获取好友列表路由的处理函数
+// @title    friendsListHandler
+// @description   获取好友列表路由的处理函数, withProfile=true时返回每个好友的公开资料
 // @auth      郑康             2020.5.28
 // @param     *gin.Context	  gin的上下文指针
 // @return    void
 func (server *HttpServer) friendsListHandler(context *gin.Context) {
 	tokenStr := context.Request.Header.Get("token")
-	username, err := ParseToken(tokenStr)
+	username, err := ParseToken(server.Stores.Sessions, server.Stores.Users, tokenStr)
 	if err != nil {
 		logger.SetToLogger(logrus.ErrorLevel, "friendsListHandler", "Parse Token which sent by client", "")
-		context.JSON(http.StatusUnauthorized, gin.H{
+		context.JSON(tokenErrorStatus(err), gin.H{
 			"message": "some error occur when parsing tokenStr, Please login again",
 			"data":    err.Error(),
 		})
 	} else {
-		friendList, err := dataBase.GetFriendListByUserName(username)
+		friendList, err := server.Stores.Friends.GetFriendList(username)
 		if err != nil {
 			logger.SetToLogger(logrus.ErrorLevel, "friendsListHandler", "execute GetFriendListByName function", err.Error())
 			context.JSON(http.StatusInternalServerError, gin.H{
 				"message": "some error occur when parsing tokenStr",
 				"data":    err.Error(),
 			})
+		} else if withProfile, _ := strconv.ParseBool(context.DefaultQuery("withProfile", "false")); withProfile {
+			server.friendProfilesHandler(context, friendList)
 		} else {
 			context.JSON(http.StatusOK, gin.H{
 				"message": "succeed to find friend list",
@@ -274,64 +423,72 @@ func (server *HttpServer) friendsListHandler(context *gin.Context) {
 	}
 }
 
-// @title    loginHandler
-// @description   获取好友列表路由的处理函数
-// @auth      郑康             2020.5.28
-// @param     *gin.Context	  gin的上下文指针
+// @title    friendProfilesHandler
+// @description   通过一次批量查询返回好友列表中每个好友的公开资料
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, []string	  gin的上下文指针, 好友列表
 // @return    void
-func (server *HttpServer) recommendedFriendsListHandler(context *gin.Context) {
-	tokenStr := context.Request.Header.Get("token")
-	username, err := ParseToken(tokenStr)
+func (server *HttpServer) friendProfilesHandler(context *gin.Context, friendList []string) {
+	friends, err := server.Stores.Users.FindUsersByNames(friendList)
 	if err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "recommendedFriendsListHandler", "Parse Token which sent by client", "")
-		context.JSON(http.StatusUnauthorized, gin.H{
-			"message": "some error occur when parsing tokenStr, Please login again",
-			"data":    err.Error(),
-		})
-		return
-	}
-	selectedUser, err := dataBase.SelectSimilarUser(username)
-	if err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "recommendedFriendsListHandler", "select similar user", err.Error())
+		logger.SetToLogger(logrus.ErrorLevel, "friendProfilesHandler", "find profiles of friends", err.Error())
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": "some error occur in the server, Please try again",
 			"data":    err.Error(),
 		})
 		return
 	}
-	imageStr, err := utils.Image2Base64(selectedUser.Photo)
+	profiles := make([]gin.H, len(friends))
+	for i := range friends {
+		profiles[i] = publicProfileOf(friends[i])
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find friend list",
+		"data":    profiles,
+	})
+}
+
+// @title    recommendedFriendsListHandler
+// @description   从推荐队列中取出一个推荐用户, 返回其公开资料、兴趣标签和相册, 队列耗尽时返回404
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) recommendedFriendsListHandler(context *gin.Context) {
+	tokenStr := context.Request.Header.Get("token")
+	username, err := ParseToken(server.Stores.Sessions, server.Stores.Users, tokenStr)
 	if err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "recommendedFriendsListHandler", "convert image to Base64 string", err.Error())
-		context.JSON(http.StatusInternalServerError, gin.H{
-			"message": "some error occur in the server, Please try again",
+		logger.SetToLogger(logrus.ErrorLevel, "recommendedFriendsListHandler", "Parse Token which sent by client", "")
+		context.JSON(tokenErrorStatus(err), gin.H{
+			"message": "some error occur when parsing tokenStr, Please login again",
 			"data":    err.Error(),
 		})
 		return
 	}
-	selectedUser.Photo = imageStr
-	dataJson, err := json.Marshal(selectedUser)
-	if err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "recommendedFriendsListHandler", "convert Struct to Json", err.Error())
-		context.JSON(http.StatusInternalServerError, gin.H{
-			"message": "some error occur in the server, Please try again",
-			"data":    err.Error(),
+	selectedUser, err := server.selectSimilarUser(username)
+	if err == recommend.ErrNoCandidate {
+		//推荐队列已经耗尽是正常的状态, 不是服务器的错误
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+			"data":    "",
 		})
 		return
-	}
-
-	var dataMap map[string]interface{}
-	err = json.Unmarshal(dataJson, &dataMap)
-	if err != nil {
-		logger.SetToLogger(logrus.ErrorLevel, "recommendedFriendsListHandler", "convert Json to Map", err.Error())
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "recommendedFriendsListHandler", "select similar user", err.Error())
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": "some error occur in the server, Please try again",
 			"data":    err.Error(),
 		})
 		return
 	}
+	//与批量推荐相同, 只返回公开资料、兴趣标签和相册
+	tags := server.userTagsOf([]string{username, selectedUser.Username})
+	profile := publicProfileOf(selectedUser)
+	profile["Tags"] = tags[selectedUser.Username]
+	profile["CommonTags"] = commonTags(tags[username], tags[selectedUser.Username])
+	profile["Photos"] = server.galleriesOf([]string{selectedUser.Username})[selectedUser.Username]
 	context.JSON(http.StatusOK, gin.H{
 		"message": "succeed to handle the request",
-		"data":    dataMap,
+		"data":    profile,
 	})
 }
 
@@ -340,15 +497,14 @@ func (server *HttpServer) heartBeatHandler(context *gin.Context) {
 	msg := "succeed to handle the request"
 	data := ""
 	tokenStr := context.Request.Header.Get("token")
-	username, err := ParseToken(tokenStr)
+	username, err := ParseToken(server.Stores.Sessions, server.Stores.Users, tokenStr)
 	if err != nil {
 		logger.SetToLogger(logrus.ErrorLevel, "heartBeatHandler", "parse token error", err.Error())
-		status = http.StatusUnauthorized
+		status = tokenErrorStatus(err)
 		msg = "token is invalid, Please login again"
 		data = err.Error()
-		return
 	} else {
-		go dataBase.UpdateUserStatus(username, lock)
+		go userCameOnline(server.Stores, username)
 	}
 
 	context.JSON(status, gin.H{
@@ -358,7 +514,7 @@ func (server *HttpServer) heartBeatHandler(context *gin.Context) {
 }
 
 func (server *HttpServer) countOnlineUserNumber(context *gin.Context) {
-	num, err := dataBase.CountOnlineUsers()
+	num, err := server.Stores.Presence.CountOnline()
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": "some error occur in the server, Please try again",
@@ -378,10 +534,10 @@ func (server *HttpServer) judgeUserAlive(context *gin.Context) {
 	msg := "succeed to handle the request"
 	data := ""
 	tokenStr := context.Request.Header.Get("token")
-	_, err := ParseToken(tokenStr)
+	_, err := ParseToken(server.Stores.Sessions, server.Stores.Users, tokenStr)
 	if err != nil {
 		logger.SetToLogger(logrus.ErrorLevel, "heartBeatHandler", "parse token error", err.Error())
-		status = http.StatusUnauthorized
+		status = tokenErrorStatus(err)
 		msg = "token is invalid, Please login again"
 		data = err.Error()
 	} else {
@@ -390,7 +546,7 @@ func (server *HttpServer) judgeUserAlive(context *gin.Context) {
 			msg = "the user you want to query can't be empty"
 		} else {
 			msg = "succeed top handle the request"
-			if dataBase.KeyExists(targetUser, 2) {
+			if server.Stores.Presence.IsOnline(targetUser) {
 				data = "user: " + targetUser + " is alive"
 			} else {
 				data = "user: " + targetUser + " is not alive"
@@ -407,36 +563,13 @@ func (server *HttpServer) closeServer(context *gin.Context) {
 	os.Exit(1)
 }
 
+// @title    addFriend
+// @description   添加好友路由的处理函数, 好友关系需要对方同意, 因此等同于发送好友请求
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
 func (server *HttpServer) addFriend(context *gin.Context) {
-	status := http.StatusOK
-	msg := "succeed to handle the request"
-	data := ""
-	tokenStr := context.Request.Header.Get("token")
-	targetFriend := context.DefaultQuery("friend", "")
-	if targetFriend == "" {
-		status = http.StatusBadRequest
-		msg = "key 'friend' is required"
-		data = ""
-	} else {
-		sourceUser, err := ParseToken(tokenStr)
-		if err != nil {
-			logger.SetToLogger(logrus.ErrorLevel, "addFriend", "parse token error", err.Error())
-			status = http.StatusUnauthorized
-			msg = "token is invalid, Please login again"
-			data = err.Error()
-		} else {
-			err := dataBase.AddFriend(sourceUser, targetFriend)
-			if err != nil {
-				status = http.StatusInternalServerError
-				msg = "there is something wrong when adding friend, please try it latter"
-				data = err.Error()
-			}
-		}
-	}
-	context.JSONP(status, gin.H{
-		"message": msg,
-		"data":    data,
-	})
+	server.sendFriendRequest(context)
 }
 
 func (server *HttpServer) deleteFriend(context *gin.Context) {
@@ -450,14 +583,14 @@ func (server *HttpServer) deleteFriend(context *gin.Context) {
 		msg = "key 'friend' is required"
 		data = ""
 	} else {
-		sourceUser, err := ParseToken(tokenStr)
+		sourceUser, err := ParseToken(server.Stores.Sessions, server.Stores.Users, tokenStr)
 		if err != nil {
 			logger.SetToLogger(logrus.ErrorLevel, "deleteFriend", "parse token error", err.Error())
-			status = http.StatusUnauthorized
+			status = tokenErrorStatus(err)
 			msg = "token is invalid, Please login again"
 			data = err.Error()
 		} else {
-			err := dataBase.DeleteFriend(sourceUser, targetFriend)
+			err := server.Stores.Friends.DeleteFriend(sourceUser, targetFriend)
 			if err != nil {
 				status = http.StatusInternalServerError
 				msg = "there is something wrong in server when delete your friend, please try it latter"
@@ -471,6 +604,37 @@ func (server *HttpServer) deleteFriend(context *gin.Context) {
 	})
 }
 
+// @title    authenticate
+// @description   解析请求头中的token, token无效时直接向客户端返回401, 账号被停用时返回StatusAccountRestricted及停用的类型、期限和原因, 无法读取账号状态时返回503
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string	  gin的上下文指针, 调用方函数名(用于日志)
+// @return    string, bool	  用户名, token是否有效
+func (server *HttpServer) authenticate(context *gin.Context, function string) (string, bool) {
+	username, err := ParseToken(server.Stores.Sessions, server.Stores.Users, context.Request.Header.Get("token"))
+	if restricted, ok := err.(*AccountRestrictedError); ok {
+		logger.SetToLogger(logrus.InfoLevel, function, "refuse the request of restricted account", restricted.Status.Username)
+		context.JSON(StatusAccountRestricted, gin.H{
+			"message": restricted.Error(),
+			"data":    restricted.Status,
+		})
+		return "", false
+	} else if _, ok := err.(*AccountStatusUnavailableError); ok {
+		context.JSON(http.StatusServiceUnavailable, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return "", false
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, function, "parse token error", err.Error())
+		context.JSON(http.StatusUnauthorized, gin.H{
+			"message": "token is invalid, Please login again",
+			"data":    err.Error(),
+		})
+		return "", false
+	}
+	return username, true
+}
+
 func checkRegister(context *gin.Context) (string, int, error) {
 	status := http.StatusOK
 	responseStr := ""
@@ -504,6 +668,10 @@ func checkRegister(context *gin.Context) (string, int, error) {
 		status = http.StatusBadRequest
 		responseStr = "parameter: 'photo' is required"
 		err = errors.New("parameter: 'photo' is required")
+	} else if name == searchUserName {
+		status = http.StatusBadRequest
+		responseStr = "the name '" + searchUserName + "' is reserved, please choose another one"
+		err = errors.New("the name is reserved")
 	} else if len(password) > 20 || len(name) > 20 {
 		status = http.StatusBadRequest
 		responseStr = "username or password is too long, please make its length less than 20"
@@ -516,11 +684,15 @@ func checkRegister(context *gin.Context) (string, int, error) {
 	return responseStr, status, err
 }
 
-func isUserNameRepeated(username string) bool {
-	userInfo, _ := dataBase.FindUserInfo(username, "")
-	if userInfo == nil {
-		return false
-	} else {
-		return true
+// @title    selectSimilarUser
+// @description   从预先计算的推荐队列中取出与当前用户最相似的一个用户, 排除规则与批量推荐相同
+// @auth      郑康             2026.10.19
+// @param     string	  当前用户名
+// @return    *dataBase.UserInfoTable, error	  推荐用户, 错误信息
+func (server *HttpServer) selectSimilarUser(username string) (*dataBase.UserInfoTable, error) {
+	user, err := server.Recommender.Queue.Next(username)
+	if err != nil && err != recommend.ErrNoCandidate {
+		logger.SetToLogger(logrus.ErrorLevel, "selectSimilarUser", "recommend users for "+username, err.Error())
 	}
+	return user, err
 }
@@ -1,7 +1,10 @@
 package network
 
 import (
+	"Flipped_Server/blobStorage"
+	"Flipped_Server/dataBase"
 	"Flipped_Server/initialSetting"
+	"Flipped_Server/repository"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
@@ -100,3 +103,63 @@ func TestAddFriend(t *testing.T) {
 	assert.Equal(t, 200, resp.StatusCode)
 	defer resp.Body.Close()
 }
+
+// newMemoryServer创建一个使用内存存储的HttpServer, 并注册用户MrFirst和MrSecond
+func newMemoryServer() (*HttpServer, http.Handler) {
+	server := &HttpServer{IPAddr: "127.0.0.1", Port: 8081, Stores: repository.NewMemoryStores(), Blobs: blobStorage.NewMemoryStore()}
+	for _, name := range []string{"MrFirst", "MrSecond"} {
+		_ = server.Stores.Users.InsertUser(&dataBase.UserInfoTable{Username: name, Password: "123456", Email: name + "@qq.com"})
+		_ = server.Stores.Friends.InitFriendList(name)
+	}
+	return server, server.SetupRouter()
+}
+
+func newMemoryRouter() http.Handler {
+	_, router := newMemoryServer()
+	return router
+}
+
+func serve(router http.Handler, method string, url string, token string) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, nil)
+	if token != "" {
+		req.Header.Add("token", token)
+	}
+	router.ServeHTTP(w, req)
+	jsonData := make(map[string]interface{})
+	_ = json.Unmarshal(w.Body.Bytes(), &jsonData)
+	return w.Code, jsonData
+}
+
+func loginAs(t *testing.T, router http.Handler, username string) string {
+	code, jsonData := serve(router, "POST", "/login?username="+username+"&password=123456", "")
+	assert.Equal(t, 200, code)
+	return jsonData["data"].(map[string]interface{})["token"].(string)
+}
+
+func TestLoginWithMemoryStores(t *testing.T) {
+	_, router := newMemoryServer()
+	code, jsonData := serve(router, "POST", "/login?username=MrFirst&password=wrong", "")
+	assert.Equal(t, 404, code)
+	assert.Equal(t, "account does't exist or wrong username or wrong password", jsonData["message"])
+
+	token := loginAs(t, router, "MrFirst")
+	assert.Equal(t, token, loginAs(t, router, "MrFirst"))
+}
+
+func TestFriendListWithMemoryStores(t *testing.T) {
+	code, _ := serve(newMemoryRouter(), "GET", "/friendList", "invalid")
+	assert.Equal(t, 401, code)
+
+	server, router := newMemoryServer()
+	_ = server.Stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+	token := loginAs(t, router, "MrFirst")
+	code, jsonData := serve(router, "GET", "/friendList", token)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []interface{}{"MrSecond"}, jsonData["data"])
+
+	code, _ = serve(router, "POST", "/deleteFriend?friend=MrSecond", token)
+	assert.Equal(t, 200, code)
+	_, jsonData = serve(router, "GET", "/friendList", token)
+	assert.Equal(t, []interface{}{}, jsonData["data"])
+}
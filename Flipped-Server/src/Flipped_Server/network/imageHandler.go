@@ -0,0 +1,139 @@
+// @Title  imageHandler.go
+// @Description  To serve the stored images by their public ids with http caching and range requests
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/blobStorage"
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 旧版本以内容的sha256命名的原图, 文件名即可作为ETag, 内容永远不会改变
+var contentAddressedImage = regexp.MustCompile(`^([0-9a-f]{64})\.[a-z]+$`)
+
+// @title    imageHandler
+// @description   按公开id和尺寸(默认原图)返回图片, 支持ETag/If-None-Match、Cache-Control和Range, 图片可被<img>直接引用, 不需要token
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) imageHandler(context *gin.Context) {
+	imageID := context.Param("id")
+	variant := context.Param("variant")
+	if variant == "" {
+		variant = dataBase.OriginalVariant
+	}
+	images, err := server.Stores.Images.FindImages([]string{imageID})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "imageHandler", "find image "+imageID, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	if image, ok := images[imageID]; ok {
+		if stored, ok := image.Variants[variant]; ok {
+			//同一id和尺寸对应的Blob不会改变
+			server.serveImageFile(context, blobStorage.BlobKey(stored.Blob), "image/"+stored.Blob.Format, stored.Blob.Hash, true)
+			return
+		}
+	} else if variant == dataBase.OriginalVariant && isLegacyImageName(imageID) {
+		server.serveImageFile(context, imageID, "", "", false)
+		return
+	}
+	context.JSON(http.StatusNotFound, gin.H{
+		"message": "image doesn't exist",
+		"data":    "",
+	})
+}
+
+// @title    isLegacyImageName
+// @description   判断id是否可能是存储根目录下旧版本上传的图片文件名, 防止路径穿越
+// @auth      郑康             2026.10.19
+// @param     string	  图片id
+// @return    bool
+func isLegacyImageName(imageID string) bool {
+	return imageID != "" && !strings.HasPrefix(imageID, ".") && filepath.Base(imageID) == imageID && !strings.ContainsAny(imageID, `/\`)
+}
+
+// @title    serveImageFile
+// @description   以流的方式返回存储中的图片, Range请求只读取请求的部分, etag为空时按文件名或大小和修改时间生成, 内容不会改变的图片允许客户端长期缓存, 其他图片需要按ETag重新验证
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string, string, string, bool	  gin的上下文指针, 文件的Key, Content-Type(为空时按文件名判断), ETag, 内容是否不会改变
+// @return    void
+func (server *HttpServer) serveImageFile(context *gin.Context, key string, contentType string, etag string, immutable bool) {
+	reader, object, err := server.Blobs.Open(key)
+	if err == blobStorage.ErrNotExist {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "image doesn't exist",
+			"data":    "",
+		})
+		return
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "serveImageFile", "open image "+key, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+	if etag == "" {
+		etag, immutable = imageETag(path.Base(key), object)
+	}
+	context.Header("ETag", `"`+etag+`"`)
+	if contentType != "" {
+		context.Header("Content-Type", contentType)
+	}
+	if immutable {
+		context.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		context.Header("Cache-Control", "public, max-age=86400")
+	}
+	//ServeContent会处理If-None-Match、If-Modified-Since和Range
+	http.ServeContent(context.Writer, context.Request, path.Base(key), object.ModTime, reader)
+}
+
+// @title    imageETag
+// @description   生成旧版本图片的ETag, 以内容命名的原图直接使用文件名中的哈希, 其他图片使用大小和修改时间, 不需要读取文件内容
+// @auth      郑康             2026.10.19
+// @param     string, blobStorage.Object	  图片文件名, 文件信息
+// @return    string, bool	  ETag, 内容是否不会改变
+func imageETag(name string, object blobStorage.Object) (string, bool) {
+	if match := contentAddressedImage.FindStringSubmatch(name); match != nil {
+		return match[1], true
+	}
+	return strconv.FormatInt(object.ModTime.UnixNano(), 16) + "-" + strconv.FormatInt(object.Size, 16), false
+}
+
+// @title    imageURL
+// @description   将图片id(或旧版本保存的文件路径)转换为客户端可以访问的原图url, 没有图片时返回空字符串
+// @auth      郑康             2026.10.19
+// @param     string	  图片id或文件路径
+// @return    string	  图片url
+func imageURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	return "/images/" + filepath.Base(path)
+}
+
+// @title    thumbnailPath
+// @description   根据旧版本原图的路径得到对应缩略图的路径
+// @auth      郑康             2026.10.19
+// @param     string, string	  原图路径, 缩略图名称
+// @return    string	  缩略图路径
+func thumbnailPath(path string, name string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + name + ext
+}
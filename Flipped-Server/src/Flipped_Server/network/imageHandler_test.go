@@ -0,0 +1,76 @@
+package network
+
+import (
+	"Flipped_Server/blobStorage"
+	"Flipped_Server/dataBase"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func fetchImage(router http.Handler, url string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", url, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestImageHandler(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	code, jsonData := uploadPhoto(router, "POST", "/photos", token, "image_test.png")
+	assert.Equal(t, 200, code)
+	galleries, _ := server.Stores.Photos.ListPhotos([]string{"MrFirst"})
+	images, _ := server.Stores.Images.FindImages([]string{galleries["MrFirst"][0].Path})
+	image := images[galleries["MrFirst"][0].Path]
+
+	photo := jsonData["data"].([]interface{})[0].(map[string]interface{})
+	url := photo["photo"].(string)
+	assert.Equal(t, "/images/"+image.ID, url)
+	_, profile := serve(router, "GET", "/profile", token)
+	assert.Equal(t, url, profile["data"].(map[string]interface{})["Photo"])
+
+	w := fetchImage(router, url, nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"`+image.Variants[dataBase.OriginalVariant].Blob.Hash+`"`, etag)
+	length := w.Body.Len()
+	assert.NotEqual(t, 0, length)
+
+	w = fetchImage(router, url, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, 304, w.Code)
+	assert.Equal(t, 0, w.Body.Len())
+
+	w = fetchImage(router, url, map[string]string{"Range": "bytes=0-7"})
+	assert.Equal(t, 206, w.Code)
+	assert.Equal(t, "\x89PNG\r\n\x1a\n", w.Body.String())
+	assert.Equal(t, "bytes 0-7/"+strconv.Itoa(length), w.Header().Get("Content-Range"))
+
+	thumbnail := photo["thumbnails"].(map[string]interface{})["list"].(string)
+	assert.Equal(t, url+"/list", thumbnail)
+	w = fetchImage(router, thumbnail, nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `"`+image.Variants["list"].Blob.Hash+`"`, w.Header().Get("ETag"))
+	assert.Equal(t, 404, fetchImage(router, url+"/huge", nil).Code)
+
+	//旧版本直接保存在图片目录下的图片需要按ETag重新验证
+	_ = server.Blobs.Put("legacy.png", []byte("legacy"))
+	w = fetchImage(router, "/images/legacy.png", nil)
+	assert.Equal(t, 200, w.Code)
+	assert.NotContains(t, w.Header().Get("Cache-Control"), "immutable")
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	assert.Equal(t, 404, fetchImage(router, "/images/legacy.png/list", nil).Code)
+
+	assert.Equal(t, 404, fetchImage(router, "/images/unknown.png", nil).Code)
+	//Blob只能通过公开id访问
+	assert.Equal(t, 404, fetchImage(router, "/images/"+blobStorage.BlobDir, nil).Code)
+	assert.Equal(t, 404, fetchImage(router, "/images/..%2FdefaultSettings.json", nil).Code)
+	assert.Equal(t, 404, fetchImage(router, "/images/.gitkeep", nil).Code)
+}
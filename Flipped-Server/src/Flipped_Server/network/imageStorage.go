@@ -0,0 +1,97 @@
+// @Title  imageStorage.go
+// @Description  To store the processed images as content addressed blobs with random public ids and reference counts
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/blobStorage"
+	"Flipped_Server/dataBase"
+	"Flipped_Server/imagePipeline"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"time"
+)
+
+// @title    saveImage
+// @description   校验并重新编码上传的图片, 原图和各缩略图以内容的sha256保存为Blob, 相同内容只保存一份, 并生成随机的公开id
+// @auth      郑康             2026.10.19
+// @param     string, []byte	  上传者的用户名, 上传的图片
+// @return    *dataBase.Image, error	  图片, 错误信息(图片不合法时见imagePipeline.IsRejected)
+func (server *HttpServer) saveImage(owner string, data []byte) (*dataBase.Image, error) {
+	result, err := imagePipeline.Process(data, *server.Images)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	image := &dataBase.Image{
+		ID:        utils.GeneratorUUID(),
+		Owner:     owner,
+		CreatedAt: now,
+		Variants:  make(map[string]dataBase.ImageVariant, len(result.Thumbnails)+1),
+	}
+	result.Original.Name = dataBase.OriginalVariant
+	for _, variant := range append([]imagePipeline.Variant{result.Original}, result.Thumbnails...) {
+		hash := sha256.Sum256(variant.Data)
+		blob := dataBase.Blob{Hash: hex.EncodeToString(hash[:]), Format: result.Format, Size: len(variant.Data), CreatedAt: now}
+		if err := server.writeBlob(blob, variant.Data); err != nil {
+			return nil, err
+		}
+		image.Variants[variant.Name] = dataBase.ImageVariant{Name: variant.Name, Width: variant.Width, Height: variant.Height, Blob: blob}
+	}
+	//文件先于引用写入, 写入引用失败时留下的文件由清理任务回收
+	if err := server.Stores.Images.AddImage(image); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// @title    releaseImage
+// @description   释放一张图片的引用, 不再被引用的Blob文件不在此处删除, 由清理任务在宽限期后隔离并回收, 避免与正在写入相同内容的上传冲突, 旧版本的文件路径不做处理, 失败时只记录日志
+// @auth      郑康             2026.10.19
+// @param     string	  图片id或旧版本的文件路径
+// @return    void
+func (server *HttpServer) releaseImage(imageID string) {
+	if imageID == "" || filepath.Base(imageID) != imageID {
+		return
+	}
+	if err := server.Stores.Images.ReleaseImage(imageID); err != nil && err != dataBase.ErrImageNotFound {
+		logger.SetToLogger(logrus.ErrorLevel, "releaseImage", "release image "+imageID, err.Error())
+	}
+}
+
+// @title    recordImageReferences
+// @description   记录消息或举报快照中以/images/引用的图片, 使清理任务不会回收这些图片, 内容中没有图片时不访问存储
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string, string, string	  存储集合, 内容来源, 会话标识或举报id, 内容
+// @return    error	  错误信息
+func recordImageReferences(stores *repository.Stores, source string, ref string, content string) error {
+	names := dataBase.ImageNamesIn(content)
+	if len(names) == 0 {
+		return nil
+	}
+	now := time.Now().Unix()
+	references := make([]dataBase.ImageReference, len(names))
+	for i, name := range names {
+		references[i] = dataBase.ImageReference{Name: name, Source: source, Ref: ref, CreatedAt: now}
+	}
+	return stores.Images.AddReferences(references)
+}
+
+// @title    writeBlob
+// @description   保存Blob文件, 已存在时不再写入
+// @auth      郑康             2026.10.19
+// @param     dataBase.Blob, []byte	  Blob, 文件内容
+// @return    error	  错误信息
+func (server *HttpServer) writeBlob(blob dataBase.Blob, data []byte) error {
+	exists, err := server.Blobs.Exists(blobStorage.BlobKey(blob))
+	if err != nil || exists {
+		return err
+	}
+	return server.Blobs.Put(blobStorage.BlobKey(blob), data)
+}
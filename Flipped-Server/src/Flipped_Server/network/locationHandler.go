@@ -0,0 +1,75 @@
+// @Title  locationHandler.go
+// @Description  To provide the http handler of reporting locations and the approximate distances between users
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// @title    updateLocationHandler
+// @description   上报当前用户的经纬度, 服务器只保存取整后的粗略位置
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) updateLocationHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "updateLocationHandler")
+	if !ok {
+		return
+	}
+	latitude, latErr := strconv.ParseFloat(context.DefaultQuery("latitude", ""), 64)
+	longitude, lngErr := strconv.ParseFloat(context.DefaultQuery("longitude", ""), 64)
+	if latErr != nil || lngErr != nil || !utils.IsValidCoordinate(latitude, longitude) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'latitude' should be between -90 and 90 and 'longitude' should be between -180 and 180",
+			"data":    "",
+		})
+		return
+	}
+	latitude, longitude = utils.RoundCoordinate(latitude), utils.RoundCoordinate(longitude)
+	if err := server.Stores.Locations.UpdateLocation(username, latitude, longitude); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "updateLocationHandler", "update location of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	server.invalidateRecommendQueue(username)
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to update location",
+		"data": gin.H{
+			"latitude":  latitude,
+			"longitude": longitude,
+		},
+	})
+}
+
+// @title    approximateDistances
+// @description   计算当前用户与其他用户之间的近似距离(千米), 任一方没有上报位置时不在结果中
+// @auth      郑康             2026.10.19
+// @param     string, []string	  当前用户名, 其他用户名列表
+// @return    map[string]int	  用户名与近似距离
+func (server *HttpServer) approximateDistances(username string, targets []string) map[string]int {
+	res := make(map[string]int)
+	current, ok := repository.LocationOf(server.Stores.Locations, username)
+	if !ok || len(targets) == 0 {
+		return res
+	}
+	locations, err := server.Stores.Locations.GetLocations(targets)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "approximateDistances", "get locations", err.Error())
+		return res
+	}
+	for target, location := range locations {
+		res[target] = utils.ApproximateKm(utils.DistanceKm(current.Latitude, current.Longitude, location.Latitude, location.Longitude))
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package network
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLocationAndDistance(t *testing.T) {
+	_, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	second := loginAs(t, router, "MrSecond")
+
+	code, _ := serve(router, "POST", "/location?latitude=95&longitude=114.3", first)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "GET", "/recommendations?sort=distance", first)
+	assert.Equal(t, 400, code)
+
+	code, jsonData := serve(router, "POST", "/location?latitude=30.59281&longitude=114.30553", first)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 30.59, jsonData["data"].(map[string]interface{})["latitude"])
+	assert.Equal(t, 114.31, jsonData["data"].(map[string]interface{})["longitude"])
+
+	_, jsonData = serve(router, "GET", "/users/MrSecond", first)
+	assert.NotContains(t, jsonData["data"], "Distance")
+	serve(router, "POST", "/location?latitude=30.52&longitude=114.36", second)
+	_, jsonData = serve(router, "GET", "/users/MrSecond", first)
+	profile := jsonData["data"].(map[string]interface{})
+	assert.Equal(t, float64(10), profile["Distance"])
+	assert.NotContains(t, profile, "latitude")
+
+	_, jsonData = serve(router, "GET", "/recommendations?sort=distance&maxDistance=5", first)
+	assert.Len(t, jsonData["data"].(map[string]interface{})["users"], 0)
+	_, jsonData = serve(router, "GET", "/recommendations?sort=distance&maxDistance=50", first)
+	users := jsonData["data"].(map[string]interface{})["users"].([]interface{})
+	assert.Len(t, users, 1)
+	assert.Equal(t, float64(10), users[0].(map[string]interface{})["Distance"])
+
+	_, jsonData = serve(router, "GET", "/users/search?name=MrS", first)
+	users = jsonData["data"].(map[string]interface{})["users"].([]interface{})
+	assert.Equal(t, float64(10), users[0].(map[string]interface{})["Distance"])
+}
@@ -0,0 +1,220 @@
+// @Title  matchHandler.go
+// @Description  To provide the http handlers of listing matches, unmatching and reading the chat history
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// 单次读取聊天记录的默认条数和最大条数
+const (
+	defaultMessageLimit = 50
+	maxMessageLimit     = 200
+)
+
+// @title    matchListHandler
+// @description   获取当前用户的全部配对(不包括存在拉黑关系的用户), 包含配对时间、对方的公开资料和最后一条消息, 按最近的互动时间从新到旧排列
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) matchListHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "matchListHandler")
+	if !ok {
+		return
+	}
+	matches, err := server.Stores.Matches.ListMatches(username)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "matchListHandler", "list matches of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	//与拉黑(或被拉黑)的用户之间的配对不再展示
+	blockedUsers, err := repository.BlockedUsersOf(server.Stores.Blocks, username)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "matchListHandler", "get block list of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	visible := matches[:0]
+	for i := range matches {
+		if !utils.Contains(blockedUsers, matches[i].Partner(username)) {
+			visible = append(visible, matches[i])
+		}
+	}
+	matches = visible
+	partners := make([]string, len(matches))
+	for i := range matches {
+		partners[i] = matches[i].Partner(username)
+	}
+	users, err := server.Stores.Users.FindUsersByNames(partners)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "matchListHandler", "find user info of matches", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	lastMessages, err := server.Stores.Messages.LastMessages(username, partners)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "matchListHandler", "find last messages of matches", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	profiles := make(map[string]*dataBase.UserInfoTable, len(users))
+	for i := range users {
+		profiles[users[i].Username] = users[i]
+	}
+	res := make([]gin.H, 0, len(matches))
+	lastActive := make([]int64, 0, len(matches))
+	for i := range matches {
+		partner := partners[i]
+		userInfo, ok := profiles[partner]
+		if !ok {
+			continue
+		}
+		item := gin.H{
+			"username":    partner,
+			"matchedAt":   matches[i].CreatedAt,
+			"profile":     publicProfileOf(userInfo),
+			"lastMessage": nil,
+		}
+		active := matches[i].CreatedAt
+		if message, ok := lastMessages[partner]; ok {
+			item["lastMessage"] = message
+			if message.CreatedAt > active {
+				active = message.CreatedAt
+			}
+		}
+		res = append(res, item)
+		lastActive = append(lastActive, active)
+	}
+	sort.Stable(byLastActive{items: res, lastActive: lastActive})
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find matches",
+		"data":    res,
+	})
+}
+
+// @title    unmatchHandler
+// @description   解除与用户的配对, 同时解除双方的好友关系, 此后双方无法读取聊天记录, 也不再向双方互相推荐
+// 聊天记录及其中引用的图片仍然保留, 以便双方在解除配对后举报其中的消息
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) unmatchHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "unmatchHandler")
+	if !ok {
+		return
+	}
+	targetUser := context.DefaultQuery("username", "")
+	status := http.StatusOK
+	msg := "succeed to unmatch the user"
+	if targetUser == "" {
+		status = http.StatusBadRequest
+		msg = "key 'username' is required"
+	} else if !server.Stores.Matches.IsMatched(username, targetUser) {
+		status = http.StatusNotFound
+		msg = "you haven't matched with the user"
+	} else if err := server.Stores.Matches.Unmatch(username, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "unmatchHandler", "unmatch "+username+" with "+targetUser, err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else {
+		_ = server.Stores.Friends.DeleteFriend(username, targetUser)
+		_ = server.Stores.Friends.DeleteFriend(targetUser, username)
+	}
+	context.JSON(status, gin.H{
+		"message": msg,
+		"data":    "",
+	})
+}
+
+// @title    messageHistoryHandler
+// @description   读取与好友之间最近的聊天记录(limit条, 默认50条), 非好友(包括已解除配对的用户)之间无法读取
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) messageHistoryHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "messageHistoryHandler")
+	if !ok {
+		return
+	}
+	targetUser := context.DefaultQuery("username", "")
+	limit, err := strconv.Atoi(context.DefaultQuery("limit", strconv.Itoa(defaultMessageLimit)))
+	if targetUser == "" || err != nil || limit < 1 || limit > maxMessageLimit {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "key 'username' is required and 'limit' should be an integer between 1 and " + strconv.Itoa(maxMessageLimit),
+			"data":    "",
+		})
+		return
+	}
+	if friendList, _ := server.Stores.Friends.GetFriendList(username); !utils.Contains(friendList, targetUser) {
+		context.JSON(http.StatusForbidden, gin.H{
+			"message": "you can only read the messages with your friends",
+			"data":    "",
+		})
+		return
+	}
+	if unmatchedUsers, err := server.Stores.Matches.ListUnmatched(username); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "messageHistoryHandler", "list unmatched users of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	} else if utils.Contains(unmatchedUsers, targetUser) {
+		context.JSON(http.StatusForbidden, gin.H{
+			"message": "you have unmatched with the user",
+			"data":    "",
+		})
+		return
+	}
+	messages, err := server.Stores.Messages.ListMessages(username, targetUser, limit)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "messageHistoryHandler", "list messages between "+username+" and "+targetUser, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find messages",
+		"data":    messages,
+	})
+}
+
+// byLastActive将配对列表按最近的互动时间从新到旧排序
+type byLastActive struct {
+	items      []gin.H
+	lastActive []int64
+}
+
+func (list byLastActive) Len() int { return len(list.items) }
+
+func (list byLastActive) Less(i, j int) bool { return list.lastActive[i] > list.lastActive[j] }
+
+func (list byLastActive) Swap(i, j int) {
+	list.items[i], list.items[j] = list.items[j], list.items[i]
+	list.lastActive[i], list.lastActive[j] = list.lastActive[j], list.lastActive[i]
+}
@@ -0,0 +1,74 @@
+package network
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestMatchListAndUnmatch(t *testing.T) {
+	server, router := newMemoryServer()
+	firstToken := loginAs(t, router, "MrFirst")
+	secondToken := loginAs(t, router, "MrSecond")
+	serve(router, "POST", "/swipe?username=MrSecond&action=like", firstToken)
+	serve(router, "POST", "/swipe?username=MrFirst&action=like", secondToken)
+	_ = server.Stores.Messages.SaveMessage("MrFirst", "MrSecond", "hello")
+	_ = server.Stores.Messages.SaveMessage("MrSecond", "MrFirst", "hi")
+
+	code, jsonData := serve(router, "GET", "/matches", firstToken)
+	assert.Equal(t, 200, code)
+	matches := jsonData["data"].([]interface{})
+	assert.Len(t, matches, 1)
+	match := matches[0].(map[string]interface{})
+	assert.Equal(t, "MrSecond", match["username"])
+	assert.NotZero(t, match["matchedAt"])
+	assert.Equal(t, "hi", match["lastMessage"].(map[string]interface{})["content"])
+
+	code, jsonData = serve(router, "GET", "/messages?username=MrSecond", firstToken)
+	assert.Equal(t, 200, code)
+	assert.Len(t, jsonData["data"], 2)
+
+	code, _ = serve(router, "POST", "/unmatch?username=MrSecond", firstToken)
+	assert.Equal(t, 200, code)
+	code, _ = serve(router, "POST", "/unmatch?username=MrSecond", firstToken)
+	assert.Equal(t, 404, code)
+
+	_, jsonData = serve(router, "GET", "/matches", secondToken)
+	assert.Len(t, jsonData["data"], 0)
+	friendList, _ := server.Stores.Friends.GetFriendList("MrSecond")
+	assert.Empty(t, friendList)
+	code, _ = serve(router, "GET", "/messages?username=MrFirst", secondToken)
+	assert.Equal(t, 403, code)
+	//重新成为好友后仍然无法读取解除配对前的聊天记录, 但记录本身保留以便举报
+	_ = server.Stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+	code, _ = serve(router, "GET", "/messages?username=MrFirst", secondToken)
+	assert.Equal(t, 403, code)
+	messages, _ := server.Stores.Messages.ListMessages("MrFirst", "MrSecond", 10)
+	assert.Len(t, messages, 2)
+	code, _ = serve(router, "POST", "/reports?username=MrFirst&reason=harassment&messageAt="+strconv.FormatInt(messages[1].CreatedAt, 10), secondToken)
+	assert.Equal(t, 200, code)
+
+	code, _ = serve(router, "POST", "/swipe?username=MrFirst&action=like", secondToken)
+	assert.Equal(t, 403, code)
+	code, jsonData = serve(router, "GET", "/recommendUser", firstToken)
+	assert.Equal(t, 404, code)
+	assert.Equal(t, "there is no user to recommend", jsonData["message"])
+}
+
+func TestMatchListHidesBlockedUsers(t *testing.T) {
+	_, router := newMemoryServer()
+	firstToken := loginAs(t, router, "MrFirst")
+	secondToken := loginAs(t, router, "MrSecond")
+	serve(router, "POST", "/swipe?username=MrSecond&action=like", firstToken)
+	serve(router, "POST", "/swipe?username=MrFirst&action=like", secondToken)
+	_, jsonData := serve(router, "GET", "/matches", secondToken)
+	assert.Len(t, jsonData["data"], 1)
+
+	code, _ := serve(router, "POST", "/blockUser?username=MrSecond", firstToken)
+	assert.Equal(t, 200, code)
+	for _, token := range []string{firstToken, secondToken} {
+		code, jsonData = serve(router, "GET", "/matches", token)
+		assert.Equal(t, 200, code)
+		assert.Len(t, jsonData["data"], 0)
+	}
+}
@@ -0,0 +1,69 @@
+// @Title  moderation.go
+// @Description  To apply the moderation rule chain to chat messages and profile fields and record the flagged content
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/moderation"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"time"
+)
+
+// 不做内容审核的资料字段
+var unmoderatedProfileFields = map[string]bool{"email": true, "age": true}
+
+// @title    moderateText
+// @description   使用规则链检查用户提交的内容, 被标记或拒绝的内容连同原文记录以便人工审核, 记录失败只写日志
+// @auth      郑康             2026.10.19
+// @param     *moderation.Moderator, *repository.Stores, string, string, string, string	  内容审核器, 存储集合, 用户名, 内容来源, 消息接收者或资料字段名, 内容
+// @return    moderation.Verdict	  检查结果
+func moderateText(moderator *moderation.Moderator, stores *repository.Stores, username string, source string, target string, text string) moderation.Verdict {
+	verdict := moderator.Check(text)
+	if !verdict.Flagged() {
+		return verdict
+	}
+	item := &dataBase.FlaggedContent{
+		ID:        utils.GeneratorUUID(),
+		Username:  username,
+		Source:    source,
+		Target:    target,
+		Content:   text,
+		Rules:     verdict.Rules(),
+		Rejected:  verdict.Rejected(),
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := stores.Moderation.AddFlagged(item); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "moderateText", "record flagged "+source+" of "+username, err.Error())
+	}
+	return verdict
+}
+
+// @title    moderateProfile
+// @description   检查需要更新的资料字段, 命中mask规则的字段保存处理后的内容
+// @auth      郑康             2026.10.19
+// @param     string, map[string]string	  用户名, 列名与新值
+// @return    error	  有字段被拒绝时返回的错误
+func (server *HttpServer) moderateProfile(username string, fields map[string]string) error {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if !unmoderatedProfileFields[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		verdict := moderateText(server.Moderator, server.Stores, username, dataBase.SourceProfile, key, fields[key])
+		if verdict.Rejected() {
+			return errors.New("'" + key + "' contains content that isn't allowed")
+		}
+		fields[key] = verdict.Text
+	}
+	return nil
+}
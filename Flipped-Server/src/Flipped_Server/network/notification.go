@@ -0,0 +1,41 @@
+// @Title  notification.go
+// @Description  To push notifications to the clients connected to the socket server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/logger"
+	"Flipped_Server/utils"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+)
+
+// @title    notifyUser
+// @description   向与Socket服务器保持连接的用户推送通知, 用户不在线时直接忽略
+// @auth      郑康             2026.10.19
+// @param     string, string, string, string	  接收通知的用户名, 通知类型, 通知来源用户, 通知内容
+// @return    bool	  是否推送成功
+func notifyUser(username string, notification string, from string, content string) bool {
+	conn := utils.GetUserConnection(username)
+	if conn == nil {
+		logger.SetToLogger(logrus.InfoLevel, "notifyUser", "user "+username+" is not connected", notification)
+		return false
+	}
+	buf, err := json.Marshal(utils.NotificationMsg{
+		Notification: notification,
+		MsgFrom:      from,
+		MsgContent:   content,
+	})
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "notifyUser", "error to marshal notification", err.Error())
+		return false
+	}
+	if _, err = utils.WriteToConnection(conn, buf); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "notifyUser", "error to write notification to "+username, err.Error())
+		//超时时通知可能只写入了一部分, 关闭连接使客户端重新连接, 连接的清理由connectionHandler完成
+		_ = conn.Close()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,343 @@
+// @Title  photoHandler.go
+// @Description  To provide the http handlers of the multi-photo profile gallery
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/imagePipeline"
+	"Flipped_Server/logger"
+	"Flipped_Server/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 每个用户相册中最多的照片数
+const maxGalleryPhotos = 6
+
+// 相册已满时返回的错误
+var errGalleryFull = errors.New("at most " + strconv.Itoa(maxGalleryPhotos) + " photos are allowed in the gallery")
+
+// @title    photosHandler
+// @description   获取当前用户的相册
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) photosHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "photosHandler")
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find photos",
+		"data":    server.galleriesOf([]string{username})[username],
+	})
+}
+
+// @title    uploadPhotoHandler
+// @description   向相册中添加一张照片(multipart表单的photo), primary=true或相册为空时设为主照片
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) uploadPhotoHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "uploadPhotoHandler")
+	if !ok {
+		return
+	}
+	if err := server.parseUploadForm(context); err != nil {
+		respondUploadError(context, "uploadPhotoHandler", err)
+		return
+	}
+	photo, err := uploadedPhotoOf(context)
+	if err != nil || photo == nil {
+		msg := "key 'photo' should be in request body and value of it should be a image"
+		if err != nil {
+			msg = "upload file is unacceptable"
+		}
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": msg,
+			"data":    "",
+		})
+		return
+	}
+	_, err = server.addPhoto(username, photo, context.DefaultQuery("primary", "false") == "true")
+	if err == errGalleryFull || imagePipeline.IsRejected(err) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "uploadPhotoHandler", "add photo of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	server.photosHandler(context)
+}
+
+// @title    deletePhotoHandler
+// @description   删除相册中的一张照片并释放其图片, 删除的是主照片时由排在最前的照片作为主照片
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) deletePhotoHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "deletePhotoHandler")
+	if !ok {
+		return
+	}
+	galleries, err := server.Stores.Photos.ListPhotos([]string{username})
+	if err == nil {
+		err = server.Stores.Photos.DeletePhoto(username, context.Param("id"))
+	}
+	if err == nil {
+		err = server.syncPrimaryPhoto(username)
+	}
+	if err == nil {
+		for _, photo := range galleries[username] {
+			if photo.ID == context.Param("id") {
+				server.releaseImage(photo.Path)
+			}
+		}
+	}
+	server.respondGalleryChange(context, username, "deletePhotoHandler", err)
+}
+
+// @title    reorderPhotosHandler
+// @description   按ids(以逗号分隔的全部照片id)重新排列相册
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) reorderPhotosHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "reorderPhotosHandler")
+	if !ok {
+		return
+	}
+	photoIDs := strings.FieldsFunc(context.DefaultQuery("ids", ""), func(r rune) bool { return r == ',' })
+	galleries, err := server.Stores.Photos.ListPhotos([]string{username})
+	if err == nil && !isPermutation(photoIDs, galleries[username]) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'ids' should contain every photo in the gallery exactly once",
+			"data":    "",
+		})
+		return
+	}
+	if err == nil {
+		err = server.Stores.Photos.Reorder(username, photoIDs)
+	}
+	server.respondGalleryChange(context, username, "reorderPhotosHandler", err)
+}
+
+// @title    setPrimaryPhotoHandler
+// @description   将相册中的一张照片设为主照片, 主照片同时作为用户的头像
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) setPrimaryPhotoHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "setPrimaryPhotoHandler")
+	if !ok {
+		return
+	}
+	err := server.Stores.Photos.SetPrimary(username, context.Param("id"))
+	if err == nil {
+		err = server.syncPrimaryPhoto(username)
+	}
+	server.respondGalleryChange(context, username, "setPrimaryPhotoHandler", err)
+}
+
+// @title    respondGalleryChange
+// @description   修改相册后的统一响应: 成功时返回最新的相册, 照片不存在时返回404
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string, string, error	  gin的上下文指针, 用户名, 处理函数名, 修改相册的错误
+// @return    void
+func (server *HttpServer) respondGalleryChange(context *gin.Context, username string, function string, err error) {
+	if err == dataBase.ErrPhotoNotFound {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, function, "update photos of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	server.photosHandler(context)
+}
+
+// @title    addPhoto
+// @description   处理并保存照片文件, 添加到相册末尾, 相册为空或primary为true时设为主照片并同步为头像
+// @auth      郑康             2026.10.19
+// @param     string, []byte, bool	  用户名, 照片内容, 是否设为主照片
+// @return    *dataBase.Photo, error	  照片, 错误信息(相册已满时为errGalleryFull, 图片不合法时见imagePipeline.IsRejected)
+func (server *HttpServer) addPhoto(username string, photo []byte, primary bool) (*dataBase.Photo, error) {
+	//提前拒绝已满的相册以免处理图片, 是否已满最终由AddPhoto原子地判断
+	galleries, err := server.Stores.Photos.ListPhotos([]string{username})
+	if err != nil {
+		return nil, err
+	}
+	if len(galleries[username]) >= maxGalleryPhotos {
+		return nil, errGalleryFull
+	}
+	image, err := server.saveImage(username, photo)
+	if err != nil {
+		return nil, err
+	}
+	record := &dataBase.Photo{
+		ID:        utils.GeneratorUUID(),
+		Username:  username,
+		Path:      image.ID,
+		Size:      image.Variants[dataBase.OriginalVariant].Blob.Size,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := server.Stores.Photos.AddPhoto(record, maxGalleryPhotos); err != nil {
+		server.releaseImage(image.ID)
+		if err == dataBase.ErrGalleryFull {
+			return nil, errGalleryFull
+		}
+		return nil, err
+	}
+	if primary || record.Position == 0 {
+		if err := server.Stores.Photos.SetPrimary(username, record.ID); err != nil {
+			return nil, err
+		}
+		record.Primary = true
+		if err := server.syncPrimaryPhoto(username); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// @title    syncPrimaryPhoto
+// @description   保证相册不为空时有且只有一张主照片, 并将主照片同步到userinfo的photo字段, 被替换的头像不在相册中时(如旧版本上传的头像)在更新成功后释放
+// @auth      郑康             2026.10.19
+// @param     string	  用户名
+// @return    error	  错误信息
+func (server *HttpServer) syncPrimaryPhoto(username string) error {
+	galleries, err := server.Stores.Photos.ListPhotos([]string{username})
+	if err != nil {
+		return err
+	}
+	photos := galleries[username]
+	path := ""
+	for i := range photos {
+		if photos[i].Primary {
+			path = photos[i].Path
+		}
+	}
+	if path == "" && len(photos) > 0 {
+		if err := server.Stores.Photos.SetPrimary(username, photos[0].ID); err != nil {
+			return err
+		}
+		path = photos[0].Path
+	}
+	userInfo, err := server.Stores.Users.FindUserInfo(username, "")
+	if err != nil {
+		return err
+	}
+	if userInfo.Photo == path {
+		return nil
+	}
+	if err := server.Stores.Users.UpdateUser(username, map[string]string{"photo": path}); err != nil {
+		return err
+	}
+	for i := range photos {
+		if photos[i].Path == userInfo.Photo {
+			return nil
+		}
+	}
+	server.releaseImage(userInfo.Photo)
+	return nil
+}
+
+// @title    galleriesOf
+// @description   批量获取用户的相册, 照片以url返回, 查询失败时只记录日志, 没有照片的用户对应空列表
+// @auth      郑康             2026.10.19
+// @param     []string	  用户名列表
+// @return    map[string][]gin.H	  用户名与按顺序排列的照片
+func (server *HttpServer) galleriesOf(usernames []string) map[string][]gin.H {
+	galleries, err := server.Stores.Photos.ListPhotos(usernames)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "galleriesOf", "find photos of users", err.Error())
+	}
+	imageIDs := []string{}
+	for _, photos := range galleries {
+		for _, photo := range photos {
+			imageIDs = append(imageIDs, photo.Path)
+		}
+	}
+	images, err := server.Stores.Images.FindImages(imageIDs)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "galleriesOf", "find images of photos", err.Error())
+	}
+	res := make(map[string][]gin.H, len(usernames))
+	for _, username := range usernames {
+		res[username] = []gin.H{}
+		for _, photo := range galleries[username] {
+			res[username] = append(res[username], gin.H{
+				"id":         photo.ID,
+				"position":   photo.Position,
+				"primary":    photo.Primary,
+				"photo":      imageURL(photo.Path),
+				"thumbnails": server.thumbnailsOf(photo.Path, images),
+			})
+		}
+	}
+	return res
+}
+
+// @title    thumbnailsOf
+// @description   获取照片各尺寸缩略图的url, 缩略图不存在(如旧版本上传的图片)时使用原图的url
+// @auth      郑康             2026.10.19
+// @param     string, map[string]dataBase.Image	  图片id或旧版本的文件路径, 已查询的图片
+// @return    map[string]string	  缩略图名称与url
+func (server *HttpServer) thumbnailsOf(path string, images map[string]dataBase.Image) map[string]string {
+	res := make(map[string]string, len(server.Images.Thumbnails))
+	image, stored := images[path]
+	for name := range server.Images.Thumbnails {
+		res[name] = imageURL(path)
+		if _, ok := image.Variants[name]; stored && ok {
+			res[name] = imageURL(path) + "/" + name
+		} else if thumbnail := filepath.Base(thumbnailPath(path, name)); !stored && isLegacyImageName(thumbnail) {
+			if exists, _ := server.Blobs.Exists(thumbnail); exists {
+				res[name] = imageURL(thumbnail)
+			}
+		}
+	}
+	return res
+}
+
+// @title    isPermutation
+// @description   判断照片id列表是否恰好包含相册中的每张照片各一次
+// @auth      郑康             2026.10.19
+// @param     []string, []dataBase.Photo	  照片id列表, 相册
+// @return    bool
+func isPermutation(photoIDs []string, photos []dataBase.Photo) bool {
+	if len(photoIDs) != len(photos) {
+		return false
+	}
+	remaining := make(map[string]bool, len(photos))
+	for _, photo := range photos {
+		remaining[photo.ID] = true
+	}
+	for _, photoID := range photoIDs {
+		if !remaining[photoID] {
+			return false
+		}
+		delete(remaining, photoID)
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+package network
+
+import (
+	"Flipped_Server/blobStorage"
+	"Flipped_Server/dataBase"
+	"Flipped_Server/imagePipeline"
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func uploadPhoto(router http.Handler, method string, url string, token string, filename string) (int, map[string]interface{}) {
+	//不同的文件名生成不同的图片, 避免以内容命名的图片被合并
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i, b := range []byte(filename) {
+		img.Pix[i%len(img.Pix)] ^= b
+	}
+	return uploadFile(router, method, url, token, filename, func(part io.Writer) {
+		_ = png.Encode(part, img)
+	})
+}
+
+func uploadFile(router http.Handler, method string, url string, token string, filename string, write func(io.Writer)) (int, map[string]interface{}) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("photo", filename)
+	write(part)
+	_ = writer.Close()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, body)
+	req.Header.Add("token", token)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+	jsonData := make(map[string]interface{})
+	_ = json.Unmarshal(w.Body.Bytes(), &jsonData)
+	return w.Code, jsonData
+}
+
+func photoIDsOf(jsonData map[string]interface{}) []string {
+	res := []string{}
+	for _, photo := range jsonData["data"].([]interface{}) {
+		res = append(res, photo.(map[string]interface{})["id"].(string))
+	}
+	return res
+}
+
+func TestPhotoGallery(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	code, jsonData := serve(router, "GET", "/photos", token)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []interface{}{}, jsonData["data"])
+
+	for i, name := range []string{"gallery_test_0.png", "gallery_test_1.png", "gallery_test_2.png"} {
+		code, jsonData = uploadPhoto(router, "POST", "/photos", token, name)
+		assert.Equal(t, 200, code)
+		assert.Len(t, jsonData["data"], i+1)
+	}
+	galleries, _ := server.Stores.Photos.ListPhotos([]string{"MrFirst"})
+	photos := jsonData["data"].([]interface{})
+	assert.Equal(t, true, photos[0].(map[string]interface{})["primary"])
+	assert.Equal(t, false, photos[1].(map[string]interface{})["primary"])
+	userInfo, _ := server.Stores.Users.FindUserInfo("MrFirst", "")
+	assert.Equal(t, galleries["MrFirst"][0].Path, userInfo.Photo)
+
+	ids := photoIDsOf(jsonData)
+	code, _ = serve(router, "PUT", "/photos/order?ids="+ids[2]+","+ids[0], token)
+	assert.Equal(t, 400, code)
+	code, jsonData = serve(router, "PUT", "/photos/order?ids="+ids[2]+","+ids[0]+","+ids[1], token)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{ids[2], ids[0], ids[1]}, photoIDsOf(jsonData))
+
+	code, _ = serve(router, "POST", "/photos/"+ids[1]+"/primary", token)
+	assert.Equal(t, 200, code)
+	userInfo, _ = server.Stores.Users.FindUserInfo("MrFirst", "")
+	assert.Equal(t, galleries["MrFirst"][1].Path, userInfo.Photo)
+	code, _ = serve(router, "POST", "/photos/unknown/primary", token)
+	assert.Equal(t, 404, code)
+
+	//删除主照片后由排在最前的照片作为主照片
+	code, jsonData = serve(router, "DELETE", "/photos/"+ids[1], token)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{ids[2], ids[0]}, photoIDsOf(jsonData))
+	assert.Equal(t, true, jsonData["data"].([]interface{})[0].(map[string]interface{})["primary"])
+	userInfo, _ = server.Stores.Users.FindUserInfo("MrFirst", "")
+	assert.Equal(t, galleries["MrFirst"][2].Path, userInfo.Photo)
+	code, _ = serve(router, "DELETE", "/photos/"+ids[1], token)
+	assert.Equal(t, 404, code)
+
+	secondToken := loginAs(t, router, "MrSecond")
+	_, jsonData = serve(router, "GET", "/users/MrFirst", secondToken)
+	assert.Len(t, jsonData["data"].(map[string]interface{})["Photos"], 2)
+}
+
+func TestConcurrentUploadsRespectGalleryLimit(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	codes := make([]int, maxGalleryPhotos+4)
+	var wait sync.WaitGroup
+	for i := range codes {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			codes[i], _ = uploadPhoto(router, "POST", "/photos", token, "concurrent_"+strconv.Itoa(i)+".png")
+		}(i)
+	}
+	wait.Wait()
+	accepted := 0
+	for _, code := range codes {
+		if code == 200 {
+			accepted++
+		} else {
+			assert.Equal(t, 400, code)
+		}
+	}
+	assert.Equal(t, maxGalleryPhotos, accepted)
+	galleries, _ := server.Stores.Photos.ListPhotos([]string{"MrFirst"})
+	assert.Len(t, galleries["MrFirst"], maxGalleryPhotos)
+	for i, photo := range galleries["MrFirst"] {
+		assert.Equal(t, i, photo.Position)
+	}
+}
+
+func TestUploadBodyIsLimited(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	server.Images.MaxBytes = 1024
+	code, jsonData := uploadFile(router, "POST", "/photos", token, "large.png", func(part io.Writer) {
+		_, _ = part.Write(make([]byte, uploadFormOverhead+2048))
+	})
+	assert.Equal(t, 413, code)
+	assert.Equal(t, errUploadTooLarge.Error(), jsonData["message"])
+	code, _ = uploadFile(router, "PATCH", "/profile", token, "large.png", func(part io.Writer) {
+		_, _ = part.Write(make([]byte, uploadFormOverhead+2048))
+	})
+	assert.Equal(t, 413, code)
+	_, jsonData = serve(router, "GET", "/photos", token)
+	assert.Equal(t, []interface{}{}, jsonData["data"])
+}
+
+func TestUploadInvalidPhoto(t *testing.T) {
+	_, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	code, jsonData := uploadFile(router, "POST", "/photos", token, "avatar.png", func(part io.Writer) {
+		_, _ = part.Write([]byte("<html>not an image</html>"))
+	})
+	assert.Equal(t, 400, code)
+	assert.Equal(t, imagePipeline.ErrUnsupportedFormat.Error(), jsonData["message"])
+	_, jsonData = serve(router, "GET", "/photos", token)
+	assert.Equal(t, []interface{}{}, jsonData["data"])
+}
+
+func TestPhotoBlobsAreSharedAndReleased(t *testing.T) {
+	server, router := newMemoryServer()
+	//两个用户上传相同的图片只保存一份文件
+	for _, name := range []string{"MrFirst", "MrSecond"} {
+		code, _ := uploadPhoto(router, "POST", "/photos", loginAs(t, router, name), "avatar.png")
+		assert.Equal(t, 200, code)
+	}
+	galleries, _ := server.Stores.Photos.ListPhotos([]string{"MrFirst", "MrSecond"})
+	first, second := galleries["MrFirst"][0], galleries["MrSecond"][0]
+	assert.NotEqual(t, first.Path, second.Path)
+	images, _ := server.Stores.Images.FindImages([]string{first.Path, second.Path})
+	original := images[first.Path].Variants[dataBase.OriginalVariant].Blob
+	assert.Equal(t, original, images[second.Path].Variants[dataBase.OriginalVariant].Blob)
+	//图片小于缩略图的尺寸, 原图和缩略图也是同一个Blob
+	assert.Equal(t, 2*(len(server.Images.Thumbnails)+1), original.RefCount)
+	blobs := server.Blobs.(*blobStorage.MemoryStore)
+	assert.Equal(t, []string{blobStorage.BlobKey(original)}, blobs.Keys())
+
+	code, _ := serve(router, "DELETE", "/photos/"+first.ID, loginAs(t, router, "MrFirst"))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{blobStorage.BlobKey(original)}, blobs.Keys())
+	code, _ = serve(router, "DELETE", "/photos/"+second.ID, loginAs(t, router, "MrSecond"))
+	assert.Equal(t, 200, code)
+	referenced, _ := server.Stores.Images.ListBlobs()
+	assert.Empty(t, referenced)
+	//文件不会被同步删除, 由清理任务在宽限期后移入隔离区
+	assert.Equal(t, []string{blobStorage.BlobKey(original)}, blobs.Keys())
+	report, err := server.Sweeper.Sweep(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.QuarantinedFiles)
+	report, err = server.Sweeper.Sweep(time.Now().Add(server.Sweeper.Config.Grace + time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.QuarantinedFiles)
+	assert.Equal(t, []string{blobStorage.QuarantineDir + "/" + blobStorage.BlobKey(original)}, blobs.Keys())
+}
@@ -0,0 +1,179 @@
+// @Title  presence.go
+// @Description  To push the online and offline events of users to their friends connected to the socket server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// presenceCheckInterval 复查只依靠http心跳在线的用户的间隔, 与心跳键的过期时间一致
+var presenceCheckInterval = 60 * time.Second
+
+// offlineCheck 一次等待中的在线状态复查
+type offlineCheck struct {
+	timer *time.Timer
+}
+
+// offlineChecks 等待复查在线状态的用户及其复查
+var offlineChecks = struct {
+	sync.Mutex
+	pending map[string]*offlineCheck
+}{pending: make(map[string]*offlineCheck)}
+
+// @title    notifyPresence
+// @description   向与Socket服务器保持连接的好友推送用户上线或下线的通知
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string, string	  存储, 用户名, utils.PresenceOnline或utils.PresenceOffline
+// @return    void
+func notifyPresence(stores *repository.Stores, username string, presence string) {
+	friendList, err := stores.Friends.GetFriendList(username)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "notifyPresence", "get friend list of "+username, err.Error())
+		return
+	}
+	for _, friend := range friendList {
+		if utils.IsUserConnected(friend) {
+			notifyUser(friend, utils.NotifyPresence, username, presence)
+		}
+	}
+}
+
+// @title    sendPresenceSnapshot
+// @description   向刚连接到Socket服务器的用户推送当前在线的好友列表
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string	  存储, 用户名
+// @return    void
+func sendPresenceSnapshot(stores *repository.Stores, username string) {
+	friendList, err := stores.Friends.GetFriendList(username)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "sendPresenceSnapshot", "get friend list of "+username, err.Error())
+		return
+	}
+	online := []string{}
+	for _, friend := range friendList {
+		if utils.IsUserConnected(friend) || stores.Presence.IsOnline(friend) {
+			online = append(online, friend)
+		}
+	}
+	buf, _ := json.Marshal(online)
+	notifyUser(username, utils.NotifyPresenceSnapshot, "", string(buf))
+}
+
+// @title    userCameOnline
+// @description   根据http心跳刷新用户的在线状态, 用户由离线变为在线且没有保持socket连接(连接时已通知)时通知其在线的好友
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string	  存储, 用户名
+// @return    void
+func userCameOnline(stores *repository.Stores, username string) {
+	online, err := stores.Presence.UpdateStatus(username, true)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "userCameOnline", "update status of "+username, err.Error())
+		return
+	}
+	if online && !utils.IsUserConnected(username) {
+		notifyPresence(stores, username, utils.PresenceOnline)
+		scheduleOfflineCheck(stores, username)
+	}
+}
+
+// @title    userDisconnected
+// @description   用户的socket连接断开后, 仍在发送http心跳时保持其在线状态并在心跳过期后复查, 否则将其标记为离线并通知其在线的好友
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string	  存储, 用户名
+// @return    void
+func userDisconnected(stores *repository.Stores, username string) {
+	if stores.Presence.HasHeartbeat(username) {
+		logger.SetToLogger(logrus.InfoLevel, "userDisconnected", username+" is still online by heartbeat", "")
+		scheduleOfflineCheck(stores, username)
+		return
+	}
+	userWentOffline(stores, username)
+}
+
+// @title    userWentOffline
+// @description   立即将用户标记为离线并通知其在线的好友
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string	  存储, 用户名
+// @return    void
+func userWentOffline(stores *repository.Stores, username string) {
+	cancelOfflineCheck(username)
+	if err := stores.Presence.SetOffline(username); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "userWentOffline", "set "+username+" offline", err.Error())
+	}
+	notifyPresence(stores, username, utils.PresenceOffline)
+}
+
+// @title    scheduleOfflineCheck
+// @description   在心跳过期后复查只依靠http心跳在线的用户, 同一用户只保留一个定时器
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string	  存储, 用户名
+// @return    void
+func scheduleOfflineCheck(stores *repository.Stores, username string) {
+	offlineChecks.Lock()
+	defer offlineChecks.Unlock()
+	if _, ok := offlineChecks.pending[username]; ok {
+		return
+	}
+	startOfflineCheck(stores, username)
+}
+
+// @title    startOfflineCheck
+// @description   创建复查定时器, 调用者需持有offlineChecks的锁
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string	  存储, 用户名
+// @return    void
+func startOfflineCheck(stores *repository.Stores, username string) {
+	check := &offlineCheck{}
+	check.timer = time.AfterFunc(presenceCheckInterval, func() {
+		checkOffline(stores, username, check)
+	})
+	offlineChecks.pending[username] = check
+}
+
+// @title    checkOffline
+// @description   复查用户的在线状态: 重新连接socket时停止复查, 心跳未过期时继续复查, 否则通知其在线的好友用户已离线
+// @auth      郑康             2026.10.19
+// @param     *repository.Stores, string, *offlineCheck	  存储, 用户名, 本次复查
+// @return    void
+func checkOffline(stores *repository.Stores, username string, check *offlineCheck) {
+	offlineChecks.Lock()
+	if offlineChecks.pending[username] != check {
+		offlineChecks.Unlock()
+		return
+	}
+	delete(offlineChecks.pending, username)
+	if utils.IsUserConnected(username) {
+		offlineChecks.Unlock()
+		return
+	}
+	if stores.Presence.IsOnline(username) {
+		startOfflineCheck(stores, username)
+		offlineChecks.Unlock()
+		return
+	}
+	offlineChecks.Unlock()
+	logger.SetToLogger(logrus.InfoLevel, "checkOffline", "heartbeat of "+username+" expired", "")
+	notifyPresence(stores, username, utils.PresenceOffline)
+}
+
+// @title    cancelOfflineCheck
+// @description   取消用户等待中的在线状态复查
+// @auth      郑康             2026.10.19
+// @param     string	  用户名
+// @return    void
+func cancelOfflineCheck(username string) {
+	offlineChecks.Lock()
+	defer offlineChecks.Unlock()
+	if check, ok := offlineChecks.pending[username]; ok {
+		check.timer.Stop()
+		delete(offlineChecks.pending, username)
+	}
+}
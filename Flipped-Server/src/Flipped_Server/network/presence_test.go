@@ -0,0 +1,171 @@
+package network
+
+import (
+	"Flipped_Server/utils"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+// readMessages持续解析客户端从连接中收到的json消息
+func readMessages(client net.Conn) chan map[string]interface{} {
+	messages := make(chan map[string]interface{}, 8)
+	go func() {
+		decoder := json.NewDecoder(client)
+		for {
+			msg := make(map[string]interface{})
+			if err := decoder.Decode(&msg); err != nil {
+				close(messages)
+				return
+			}
+			messages <- msg
+		}
+	}()
+	return messages
+}
+
+func TestPresenceEvents(t *testing.T) {
+	defer cancelOfflineCheck("MrFirst")
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	_ = server.Stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+	ss := &SocketServer{Stores: server.Stores}
+	utils.RWLock.Lock()
+	utils.UserConnectionMap = make(map[string]net.Conn)
+	utils.RWLock.Unlock()
+	defer func() {
+		utils.RWLock.Lock()
+		utils.UserConnectionMap = make(map[string]net.Conn)
+		utils.RWLock.Unlock()
+	}()
+
+	//MrSecond已经在线并保持着socket连接
+	secondClient, secondConn := net.Pipe()
+	defer secondClient.Close()
+	assert.True(t, utils.RegisterConnection("MrSecond", secondConn))
+	assert.False(t, utils.RegisterConnection("MrSecond", secondConn))
+	_, _ = server.Stores.Presence.UpdateStatus("MrSecond", true)
+	secondMessages := readMessages(secondClient)
+
+	firstClient, firstConn := net.Pipe()
+	go ss.connectionHandler(firstConn)
+	firstMessages := readMessages(firstClient)
+	buf, _ := json.Marshal(utils.FromClientMsg{MsgType: 2, MsgFrom: first})
+	_, _ = firstClient.Write(buf)
+
+	reply := <-firstMessages
+	assert.Equal(t, float64(200), reply["ResultCode"])
+	snapshot := <-firstMessages
+	assert.Equal(t, utils.NotifyPresenceSnapshot, snapshot["Notification"])
+	assert.Equal(t, `["MrSecond"]`, snapshot["MsgContent"])
+	event := <-secondMessages
+	assert.Equal(t, utils.NotifyPresence, event["Notification"])
+	assert.Equal(t, "MrFirst", event["MsgFrom"])
+	assert.Equal(t, utils.PresenceOnline, event["MsgContent"])
+	assert.True(t, server.Stores.Presence.IsOnline("MrFirst"))
+
+	//断开连接后好友收到下线通知
+	_ = firstClient.Close()
+	event = <-secondMessages
+	assert.Equal(t, "MrFirst", event["MsgFrom"])
+	assert.Equal(t, utils.PresenceOffline, event["MsgContent"])
+	assert.False(t, utils.IsUserConnected("MrFirst"))
+	assert.False(t, server.Stores.Presence.IsOnline("MrFirst"))
+
+	//心跳使离线用户重新上线时同样通知好友
+	userCameOnline(server.Stores, "MrFirst")
+	event = <-secondMessages
+	assert.Equal(t, utils.PresenceOnline, event["MsgContent"])
+	userCameOnline(server.Stores, "MrFirst")
+	select {
+	case event = <-secondMessages:
+		t.Errorf("unexpected presence event %v", event)
+	default:
+	}
+}
+
+func TestSocketDoesNotOverrideHeartbeatPresence(t *testing.T) {
+	interval := presenceCheckInterval
+	presenceCheckInterval = 20 * time.Millisecond
+	defer func() { presenceCheckInterval = interval }()
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	_ = server.Stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+	ss := &SocketServer{Stores: server.Stores}
+	utils.RWLock.Lock()
+	utils.UserConnectionMap = make(map[string]net.Conn)
+	utils.RWLock.Unlock()
+	defer func() {
+		utils.RWLock.Lock()
+		utils.UserConnectionMap = make(map[string]net.Conn)
+		utils.RWLock.Unlock()
+	}()
+
+	secondClient, secondConn := net.Pipe()
+	defer secondClient.Close()
+	utils.RegisterConnection("MrSecond", secondConn)
+	secondMessages := readMessages(secondClient)
+
+	//MrFirst已经通过http心跳在线, 好友收到过上线通知
+	userCameOnline(server.Stores, "MrFirst")
+	event := <-secondMessages
+	assert.Equal(t, utils.PresenceOnline, event["MsgContent"])
+
+	//建立和断开socket连接都不会再次通知好友, 心跳维持的在线状态保持不变
+	firstClient, firstConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		ss.connectionHandler(firstConn)
+		close(done)
+	}()
+	firstMessages := readMessages(firstClient)
+	buf, _ := json.Marshal(utils.FromClientMsg{MsgType: 2, MsgFrom: first})
+	_, _ = firstClient.Write(buf)
+	reply := <-firstMessages
+	assert.Equal(t, float64(200), reply["ResultCode"])
+	<-firstMessages
+	_ = firstClient.Close()
+	<-done
+	assert.True(t, server.Stores.Presence.IsOnline("MrFirst"))
+	select {
+	case event = <-secondMessages:
+		t.Errorf("unexpected presence event %v", event)
+	default:
+	}
+
+	//心跳过期后复查时通知好友下线
+	_ = server.Stores.Presence.SetOffline("MrFirst")
+	select {
+	case event = <-secondMessages:
+		assert.Equal(t, "MrFirst", event["MsgFrom"])
+		assert.Equal(t, utils.PresenceOffline, event["MsgContent"])
+	case <-time.After(time.Second):
+		t.Error("no offline event after the heartbeat expired")
+	}
+}
+
+func TestNotifyUserTimesOut(t *testing.T) {
+	timeout := utils.WriteTimeout
+	utils.WriteTimeout = 50 * time.Millisecond
+	defer func() { utils.WriteTimeout = timeout }()
+	utils.RWLock.Lock()
+	utils.UserConnectionMap = make(map[string]net.Conn)
+	utils.RWLock.Unlock()
+	defer func() {
+		utils.RWLock.Lock()
+		utils.UserConnectionMap = make(map[string]net.Conn)
+		utils.RWLock.Unlock()
+	}()
+
+	//客户端不读取数据时推送在超时后失败并关闭连接
+	client, conn := net.Pipe()
+	utils.RegisterConnection("MrFirst", conn)
+	start := time.Now()
+	assert.False(t, notifyUser("MrFirst", utils.NotifyWarning, "", "hello"))
+	assert.True(t, time.Since(start) < time.Second)
+	data, _ := ioutil.ReadAll(client)
+	assert.Empty(t, data)
+}
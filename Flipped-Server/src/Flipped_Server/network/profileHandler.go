@@ -0,0 +1,331 @@
+// @Title  profileHandler.go
+// @Description  To provide the http handlers of editing the own profile and looking up public profiles
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/imagePipeline"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 可编辑的文本资料字段及其最大长度(按字符计)，与userinfo表的列宽保持一致
+var profileTextFields = map[string]int{
+	"realName":   32,
+	"profession": 32,
+	"region":     64,
+	"hobby":      255,
+	"email":      64,
+}
+
+// 允许填写的年龄范围
+const (
+	minProfileAge = 18
+	maxProfileAge = 120
+)
+
+// 上传表单中除图片外的其他字段最多占用的字节数, 以及解析表单时保存在内存中的字节数(超出的部分写入临时文件)
+const (
+	uploadFormOverhead = 1 << 20
+	uploadFormMemory   = 32 << 20
+)
+
+// 上传的请求体超过限制时返回的错误
+var errUploadTooLarge = errors.New("request body is too large")
+
+// @title    profileHandler
+// @description   获取当前用户的完整资料(不包含密码)
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) profileHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "profileHandler")
+	if !ok {
+		return
+	}
+	userInfo, err := server.Stores.Users.FindUserInfo(username, "")
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "profileHandler", "find user info of "+username, err.Error())
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "account does't exist",
+			"data":    err.Error(),
+		})
+		return
+	}
+	profile := profileOf(userInfo)
+	profile["Tags"] = server.userTagsOf([]string{username})[username]
+	profile["Photos"] = server.galleriesOf([]string{username})[username]
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find profile",
+		"data":    profile,
+	})
+}
+
+// @title    userProfileHandler
+// @description   按用户名查看他人的公开资料、相册、兴趣标签及与当前用户的近似距离, 与当前用户存在拉黑关系时视为用户不存在, /users/search转交给searchUsersHandler
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) userProfileHandler(context *gin.Context) {
+	if context.Param("name") == searchUserName {
+		server.searchUsersHandler(context)
+		return
+	}
+	username, ok := server.authenticate(context, "userProfileHandler")
+	if !ok {
+		return
+	}
+	targetUser := context.Param("name")
+	blocked, err := repository.IsBlockedBetween(server.Stores.Blocks, username, targetUser)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "userProfileHandler", "check block list", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    "",
+		})
+		return
+	}
+	userInfo, err := server.Stores.Users.FindUserInfo(targetUser, "")
+	if err != nil || blocked {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "target user doesn't exist",
+			"data":    "",
+		})
+		return
+	}
+	profile := publicProfileOf(userInfo)
+	if distance, ok := server.approximateDistances(username, []string{targetUser})[targetUser]; ok {
+		profile["Distance"] = distance
+	}
+	tags := server.userTagsOf([]string{username, targetUser})
+	profile["Tags"] = tags[targetUser]
+	profile["CommonTags"] = commonTags(tags[username], tags[targetUser])
+	profile["Photos"] = server.galleriesOf([]string{targetUser})[targetUser]
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find profile",
+		"data":    profile,
+	})
+}
+
+// @title    updateProfileHandler
+// @description   部分更新当前用户的资料, 只修改请求中出现的字段, 文本字段需通过内容审核, 上传photo时加入相册并设为头像
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) updateProfileHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "updateProfileHandler")
+	if !ok {
+		return
+	}
+	if err := server.parseUploadForm(context); err != nil {
+		respondUploadError(context, "updateProfileHandler", err)
+		return
+	}
+	fields, err := profileFieldsOf(context)
+	if err == nil {
+		err = server.moderateProfile(username, fields)
+	}
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return
+	}
+	photo, err := uploadedPhotoOf(context)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "updateProfileHandler", "read uploaded photo", err.Error())
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "upload file is unacceptable",
+			"data":    err.Error(),
+		})
+		return
+	}
+	if len(fields) == 0 && photo == nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "nothing to update",
+			"data":    "",
+		})
+		return
+	}
+	if photo != nil {
+		//上传的头像加入相册并设为主照片
+		if _, err = server.addPhoto(username, photo, true); err == errGalleryFull || imagePipeline.IsRejected(err) {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+				"data":    "",
+			})
+			return
+		}
+	}
+	if err == nil && len(fields) > 0 {
+		err = server.Stores.Users.UpdateUser(username, fields)
+	}
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "updateProfileHandler", "update user info of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	server.invalidateRecommendQueue(username)
+	server.profileHandler(context)
+}
+
+// @title    profileFieldsOf
+// @description   从请求的query或表单中读取并校验需要更新的资料字段
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    map[string]string, error	  列名与新值, 校验错误
+func profileFieldsOf(context *gin.Context) (map[string]string, error) {
+	fields := make(map[string]string)
+	for key, maxLen := range profileTextFields {
+		value, ok := requestValue(context, key)
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if utf8.RuneCountInString(value) > maxLen {
+			return nil, errors.New("'" + key + "' is too long, please make its length less than " + strconv.Itoa(maxLen))
+		}
+		if key == "email" && !utils.VerifyEmail(value) {
+			return nil, errors.New("email is illegal")
+		}
+		fields[key] = value
+	}
+	if value, ok := requestValue(context, "age"); ok {
+		age, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || age < minProfileAge || age > maxProfileAge {
+			return nil, errors.New("'age' should be an integer between " + strconv.Itoa(minProfileAge) + " and " + strconv.Itoa(maxProfileAge))
+		}
+		fields["age"] = strconv.Itoa(age)
+	}
+	return fields, nil
+}
+
+// @title    requestValue
+// @description   依次从query和表单中读取参数
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string	  gin的上下文指针, 参数名
+// @return    string, bool	  参数值, 是否存在
+func requestValue(context *gin.Context, key string) (string, bool) {
+	if value, ok := context.GetQuery(key); ok {
+		return value, true
+	}
+	return context.GetPostForm(key)
+}
+
+// @title    parseUploadForm
+// @description   限制请求体的字节数(图片的最大字节数加上其他字段占用的字节数)后解析multipart表单, 必须在读取任何表单字段之前调用, 不是multipart表单时不报错
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    error	  错误信息(请求体超过限制时为errUploadTooLarge)
+func (server *HttpServer) parseUploadForm(context *gin.Context) error {
+	body := &countingBody{ReadCloser: context.Request.Body}
+	limit := server.Images.MaxBytes + uploadFormOverhead
+	if server.Images.MaxBytes > 0 {
+		context.Request.Body = http.MaxBytesReader(context.Writer, body, limit)
+	}
+	err := context.Request.ParseMultipartForm(uploadFormMemory)
+	if err == http.ErrNotMultipart {
+		return nil
+	} else if err != nil && server.Images.MaxBytes > 0 && body.count > limit {
+		//MaxBytesReader最多多读取一个字节, 读取的字节数超过限制说明是请求体过大导致的错误
+		return errUploadTooLarge
+	}
+	return err
+}
+
+// countingBody记录从请求体中读取的字节数
+type countingBody struct {
+	io.ReadCloser
+	count int64
+}
+
+func (body *countingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.count += int64(n)
+	return n, err
+}
+
+// @title    respondUploadError
+// @description   解析上传表单失败时的统一响应: 请求体过大时返回413, 其他情况返回400
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string, error	  gin的上下文指针, 处理函数名, 解析表单的错误
+// @return    void
+func respondUploadError(context *gin.Context, function string, err error) {
+	logger.SetToLogger(logrus.InfoLevel, function, "parse the uploaded form", err.Error())
+	if err == errUploadTooLarge {
+		context.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return
+	}
+	context.JSON(http.StatusBadRequest, gin.H{
+		"message": "upload file is unacceptable",
+		"data":    err.Error(),
+	})
+}
+
+// @title    uploadedPhotoOf
+// @description   读取multipart表单中名为photo的文件, 未上传时返回nil, 文件名不会被使用, 请求体的大小应先由parseUploadForm限制
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    []byte, error	  文件内容, 错误信息
+func uploadedPhotoOf(context *gin.Context) ([]byte, error) {
+	fileHeader, err := context.FormFile("photo")
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// @title    profileOf
+// @description   生成用户本人可见的资料, 在公开资料的基础上增加邮箱, 不包含密码
+// @auth      郑康             2026.10.19
+// @param     *dataBase.UserInfoTable	  用户信息
+// @return    gin.H	  资料
+func profileOf(userInfo *dataBase.UserInfoTable) gin.H {
+	profile := publicProfileOf(userInfo)
+	profile["Email"] = userInfo.Email
+	return profile
+}
+
+// @title    publicProfileOf
+// @description   生成其他用户可见的公开资料, 不包含密码和邮箱
+// @auth      郑康             2026.10.19
+// @param     *dataBase.UserInfoTable	  用户信息
+// @return    gin.H	  公开资料
+func publicProfileOf(userInfo *dataBase.UserInfoTable) gin.H {
+	return gin.H{
+		"Username":   userInfo.Username,
+		"UserType":   userInfo.UserType,
+		"Photo":      imageURL(userInfo.Photo),
+		"RealName":   userInfo.RealName,
+		"Profession": userInfo.Profession,
+		"Age":        userInfo.Age,
+		"Region":     userInfo.Region,
+		"Hobby":      userInfo.Hobby,
+	}
+}
@@ -0,0 +1,112 @@
+package network
+
+import (
+	"Flipped_Server/moderation"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+
+	code, jsonData := serve(router, "GET", "/profile", token)
+	assert.Equal(t, 200, code)
+	profile := jsonData["data"].(map[string]interface{})
+	assert.Equal(t, "MrFirst", profile["Username"])
+	assert.NotContains(t, profile, "Password")
+
+	code, _ = serve(router, "PATCH", "/profile", token)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "PATCH", "/profile?age=12", token)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "PATCH", "/profile?email=wrong", token)
+	assert.Equal(t, 400, code)
+
+	code, jsonData = serve(router, "PATCH", "/profile?age=21&region=Wuhan&hobby=reading,hiking", token)
+	assert.Equal(t, 200, code)
+	profile = jsonData["data"].(map[string]interface{})
+	assert.Equal(t, float64(21), profile["Age"])
+	assert.Equal(t, "Wuhan", profile["Region"])
+
+	userInfo, _ := server.Stores.Users.FindUserInfo("MrFirst", "")
+	assert.Equal(t, "reading,hiking", userInfo.Hobby)
+	assert.Equal(t, "MrFirst@qq.com", userInfo.Email)
+}
+
+func TestPublicProfileAndFriendProfiles(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+
+	code, jsonData := serve(router, "GET", "/users/MrSecond", token)
+	assert.Equal(t, 200, code)
+	profile := jsonData["data"].(map[string]interface{})
+	assert.Equal(t, "MrSecond", profile["Username"])
+	assert.NotContains(t, profile, "Password")
+	assert.NotContains(t, profile, "Email")
+	code, _ = serve(router, "GET", "/users/Nobody", token)
+	assert.Equal(t, 404, code)
+
+	_ = server.Stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+	code, jsonData = serve(router, "GET", "/friendList?withProfile=true", token)
+	assert.Equal(t, 200, code)
+	friends := jsonData["data"].([]interface{})
+	assert.Len(t, friends, 1)
+	assert.Equal(t, "MrSecond", friends[0].(map[string]interface{})["Username"])
+
+	_ = server.Stores.Blocks.Block("MrSecond", "MrFirst")
+	code, _ = serve(router, "GET", "/users/MrSecond", token)
+	assert.Equal(t, 404, code)
+}
+
+func TestUpdateProfileModeration(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+
+	code, jsonData := serve(router, "PATCH", "/profile?hobby=f.u.c.k%20off&realName=13912345678", token)
+	assert.Equal(t, 200, code)
+	profile := jsonData["data"].(map[string]interface{})
+	assert.Equal(t, "******* off", profile["Hobby"])
+	assert.Equal(t, "13912345678", profile["RealName"])
+	//只有命中flag规则的字段被记录
+	flagged, _ := server.Stores.Moderation.ListFlagged(10)
+	assert.Len(t, flagged, 1)
+	assert.Equal(t, "realName", flagged[0].Target)
+	assert.Equal(t, []string{"phone"}, flagged[0].Rules)
+
+	server.Moderator, _ = moderation.NewModerator([]moderation.RuleConfig{{Name: "scam", Type: moderation.TypeWords, Action: moderation.ActionReject, Words: []string{"bitcoin"}}})
+	code, jsonData = serve(router, "PATCH", "/profile?profession=Bitcoin%20trader&age=30", token)
+	assert.Equal(t, 400, code)
+	assert.Contains(t, jsonData["message"], "profession")
+	userInfo, _ := server.Stores.Users.FindUserInfo("MrFirst", "")
+	assert.Equal(t, 0, userInfo.Age)
+	flagged, _ = server.Stores.Moderation.ListFlagged(10)
+	assert.True(t, flagged[0].Rejected)
+	assert.Equal(t, "Bitcoin trader", flagged[0].Content)
+}
+
+func TestReplaceProfilePhotoReleasesOldImage(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	//旧版本的头像不在相册中, 被替换后应释放
+	_, jsonData := uploadPhoto(router, "POST", "/photos", token, "legacy.png")
+	legacy := photoIDsOf(jsonData)[0]
+	galleries, _ := server.Stores.Photos.ListPhotos([]string{"MrFirst"})
+	legacyImage := galleries["MrFirst"][0].Path
+	_ = server.Stores.Photos.DeletePhoto("MrFirst", legacy)
+
+	code, _ := uploadPhoto(router, "PATCH", "/profile", token, "first.png")
+	assert.Equal(t, 200, code)
+	images, _ := server.Stores.Images.FindImages([]string{legacyImage})
+	assert.Empty(t, images)
+
+	//相册中的头像被替换后仍保留在相册中
+	galleries, _ = server.Stores.Photos.ListPhotos([]string{"MrFirst"})
+	firstImage := galleries["MrFirst"][0].Path
+	code, _ = uploadPhoto(router, "PATCH", "/profile", token, "second.png")
+	assert.Equal(t, 200, code)
+	images, _ = server.Stores.Images.FindImages([]string{firstImage})
+	assert.Contains(t, images, firstImage)
+	userInfo, _ := server.Stores.Users.FindUserInfo("MrFirst", "")
+	assert.NotEqual(t, firstImage, userInfo.Photo)
+}
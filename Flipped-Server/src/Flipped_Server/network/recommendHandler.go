@@ -0,0 +1,111 @@
+// @Title  recommendHandler.go
+// @Description  To provide the http handler of ranked batches of recommended users
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/logger"
+	"Flipped_Server/recommend"
+	"Flipped_Server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// @title    recommendationsHandler
+// @description   返回一批推荐用户(count个), 默认按相似度排列, sort=distance时按距离由近到远, maxDistance限制最大距离(千米), cursor为上一批的nextCursor, explain=true时附带得分明细
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) recommendationsHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "recommendationsHandler")
+	if !ok {
+		return
+	}
+	config := server.Recommender.Config
+	count, err := strconv.Atoi(context.DefaultQuery("count", strconv.Itoa(config.DefaultBatchSize)))
+	if err != nil || count < 1 || count > config.MaxBatchSize {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'count' should be an integer between 1 and " + strconv.Itoa(config.MaxBatchSize),
+			"data":    "",
+		})
+		return
+	}
+	maxDistance, err := strconv.ParseFloat(context.DefaultQuery("maxDistance", "0"), 64)
+	if err != nil || maxDistance < 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'maxDistance' should be a non-negative number of kilometers",
+			"data":    "",
+		})
+		return
+	}
+	sortBy := context.DefaultQuery("sort", recommend.SortByScore)
+	if sortBy != recommend.SortByScore && sortBy != recommend.SortByDistance {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "value of 'sort' should be 'score' or 'distance'",
+			"data":    "",
+		})
+		return
+	}
+	candidates, nextCursor, err := server.Recommender.Recommend(username, &recommend.Query{
+		Cursor:      context.DefaultQuery("cursor", ""),
+		Count:       count,
+		MaxDistance: maxDistance,
+		SortBy:      sortBy,
+	})
+	if err == recommend.ErrInvalidCursor || err == recommend.ErrNoLocation {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "recommendationsHandler", "recommend users for "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	explain := context.DefaultQuery("explain", "false") == "true"
+	usernames := []string{username}
+	for i := range candidates {
+		usernames = append(usernames, candidates[i].User.Username)
+	}
+	tags := server.userTagsOf(usernames)
+	galleries := server.galleriesOf(usernames[1:])
+	users := make([]gin.H, len(candidates))
+	for i := range candidates {
+		users[i] = publicProfileOf(candidates[i].User)
+		users[i]["Score"] = candidates[i].Score
+		users[i]["Tags"] = tags[candidates[i].User.Username]
+		users[i]["CommonTags"] = commonTags(tags[username], tags[candidates[i].User.Username])
+		users[i]["Photos"] = galleries[candidates[i].User.Username]
+		if candidates[i].Distance != recommend.UnknownDistance {
+			users[i]["Distance"] = utils.ApproximateKm(candidates[i].Distance)
+		}
+		if explain {
+			users[i]["ScoreBreakdown"] = candidates[i].Breakdown
+		}
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to recommend users",
+		"data": gin.H{
+			"users":      users,
+			"nextCursor": nextCursor,
+		},
+	})
+}
+
+// @title    invalidateRecommendQueue
+// @description   用户资料或位置变化后清空其预先计算的推荐队列, 由后台重新计算
+// @auth      郑康             2026.10.19
+// @param     string	  用户名
+// @return    void
+func (server *HttpServer) invalidateRecommendQueue(username string) {
+	if err := server.Recommender.Queue.Invalidate(username); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "invalidateRecommendQueue", "invalidate the recommend queue of "+username, err.Error())
+	}
+}
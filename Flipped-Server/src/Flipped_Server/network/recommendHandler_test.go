@@ -0,0 +1,58 @@
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecommendations(t *testing.T) {
+	server, router := newMemoryServer()
+	for _, name := range []string{"MrThird", "MrFourth"} {
+		_ = server.Stores.Users.InsertUser(&dataBase.UserInfoTable{Username: name, Email: name + "@qq.com"})
+	}
+	token := loginAs(t, router, "MrFirst")
+
+	code, jsonData := serve(router, "GET", "/recommendations?count=2", token)
+	assert.Equal(t, 200, code)
+	data := jsonData["data"].(map[string]interface{})
+	assert.Len(t, data["users"], 2)
+	cursor := data["nextCursor"].(string)
+	assert.NotEqual(t, "", cursor)
+
+	_, jsonData = serve(router, "GET", "/recommendations?count=2&cursor="+cursor, token)
+	data = jsonData["data"].(map[string]interface{})
+	assert.Len(t, data["users"], 1)
+	assert.Equal(t, "", data["nextCursor"])
+	assert.NotContains(t, data["users"].([]interface{})[0], "ScoreBreakdown")
+
+	_, jsonData = serve(router, "GET", "/recommendations?count=1&explain=true", token)
+	user := jsonData["data"].(map[string]interface{})["users"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, user["ScoreBreakdown"], "hobby")
+
+	serve(router, "POST", "/swipe?username=MrThird&action=pass", token)
+	_, jsonData = serve(router, "GET", "/recommendations", token)
+	users := jsonData["data"].(map[string]interface{})["users"].([]interface{})
+	assert.Len(t, users, 2)
+	for _, user := range users {
+		assert.NotEqual(t, "MrThird", user.(map[string]interface{})["Username"])
+	}
+
+	code, _ = serve(router, "GET", "/recommendations?count=0", token)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "GET", "/recommendations?cursor=abc", token)
+	assert.Equal(t, 400, code)
+}
+
+func TestRecommendUserHidesPrivateFields(t *testing.T) {
+	_, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	code, jsonData := serve(router, "GET", "/recommendUser", token)
+	assert.Equal(t, 200, code)
+	user := jsonData["data"].(map[string]interface{})
+	assert.Equal(t, "MrSecond", user["Username"])
+	assert.NotContains(t, user, "Password")
+	assert.NotContains(t, user, "Email")
+	assert.Contains(t, user, "Photos")
+	assert.Contains(t, user, "Tags")
+}
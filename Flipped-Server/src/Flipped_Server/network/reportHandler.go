@@ -0,0 +1,448 @@
+// @Title  reportHandler.go
+// @Description  To provide the http handlers of reporting users and the admin queue of triaging and resolving the reports
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/initialSetting"
+	"Flipped_Server/logger"
+	"Flipped_Server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 允许的举报原因
+var reportReasons = []string{"spam", "harassment", "inappropriate", "fake", "scam", "underage", "other"}
+
+// 举报说明和处理备注的最大长度(按字符计)，与report表的列宽保持一致
+const maxReportTextLength = 512
+
+// 管理员查看举报列表时的默认和最大条数
+const (
+	defaultReportLimit = 50
+	maxReportLimit     = 200
+)
+
+// 停用账号的最长时间(小时)，更长的停用应使用ban
+const maxSuspensionHours = 24 * 365
+
+// @title    loadAdmins
+// @description   读取配置文件中admin项的usernames作为管理员列表, 没有该项时没有管理员
+// @auth      郑康             2026.10.19
+// @param     void
+// @return    []string	  管理员的用户名
+func loadAdmins() []string {
+	admins := []string{}
+	usernames, _ := initialSetting.AdminConfig["usernames"].([]interface{})
+	for _, username := range usernames {
+		if name, ok := username.(string); ok && name != "" {
+			admins = append(admins, name)
+		}
+	}
+	return admins
+}
+
+// @title    authenticateAdmin
+// @description   解析请求头中的token并确认当前用户是管理员, 否则直接向客户端返回401或403
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string	  gin的上下文指针, 调用方函数名(用于日志)
+// @return    string, bool	  管理员的用户名, 是否通过校验
+func (server *HttpServer) authenticateAdmin(context *gin.Context, function string) (string, bool) {
+	username, ok := server.authenticate(context, function)
+	if !ok {
+		return "", false
+	}
+	if !utils.Contains(server.Admins, username) {
+		logger.SetToLogger(logrus.InfoLevel, function, "refuse the admin request of "+username, "")
+		context.JSON(http.StatusForbidden, gin.H{
+			"message": "only admins can do this",
+			"data":    "",
+		})
+		return "", false
+	}
+	return username, true
+}
+
+// @title    reportHandler
+// @description   举报用户, 可以引用一条被举报用户发给自己的消息(messageAt为该消息的发送时间), 消息内容随举报一起保存
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) reportHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "reportHandler")
+	if !ok {
+		return
+	}
+	targetUser, _ := requestValue(context, "username")
+	reason, _ := requestValue(context, "reason")
+	details, _ := requestValue(context, "details")
+	details = strings.TrimSpace(details)
+	status := http.StatusOK
+	msg := "succeed to report the user"
+	if targetUser == "" || !utils.Contains(reportReasons, reason) {
+		status = http.StatusBadRequest
+		msg = "key 'username' is required and 'reason' should be one of " + strings.Join(reportReasons, ", ")
+	} else if utf8.RuneCountInString(details) > maxReportTextLength {
+		status = http.StatusBadRequest
+		msg = "'details' is too long, please make its length less than " + strconv.Itoa(maxReportTextLength)
+	} else if targetUser == username {
+		status = http.StatusBadRequest
+		msg = "can't report yourself"
+	} else if !server.Stores.Users.DoesUserExist(targetUser) {
+		status = http.StatusNotFound
+		msg = "target user doesn't exist"
+	}
+	if status != http.StatusOK {
+		context.JSON(status, gin.H{
+			"message": msg,
+			"data":    "",
+		})
+		return
+	}
+
+	now := time.Now().Unix()
+	report := &dataBase.Report{
+		ID:        utils.GeneratorUUID(),
+		Reporter:  username,
+		Target:    targetUser,
+		Reason:    reason,
+		Details:   details,
+		Status:    dataBase.ReportOpen,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if value, ok := requestValue(context, "messageAt"); ok {
+		messageAt, err := strconv.ParseInt(value, 10, 64)
+		var messages []dataBase.Message
+		if err == nil {
+			messages, err = server.Stores.Messages.MessagesSentAt(targetUser, username, messageAt)
+		}
+		if err != nil || len(messages) == 0 {
+			context.JSON(http.StatusNotFound, gin.H{
+				"message": "the referenced message doesn't exist",
+				"data":    "",
+			})
+			return
+		}
+		contents := make([]string, len(messages))
+		for i := range messages {
+			contents[i] = messages[i].Content
+		}
+		report.MessageAt = messageAt
+		report.Message = strings.Join(contents, "\n")
+	}
+	action := &dataBase.ReportAction{
+		ID:        utils.GeneratorUUID(),
+		ReportID:  report.ID,
+		Actor:     username,
+		Action:    dataBase.ReportActionCreated,
+		Status:    report.Status,
+		Note:      reason,
+		CreatedAt: now,
+	}
+	//快照中引用的图片是举报的证据, 在保存举报之前记录引用, 删除聊天记录后也不会被回收
+	err := recordImageReferences(server.Stores, dataBase.ReferenceReport, report.ID, report.Message)
+	if err == nil {
+		err = server.Stores.Reports.AddReport(report, action)
+	}
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "reportHandler", "add report of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": msg,
+		"data":    gin.H{"id": report.ID},
+	})
+}
+
+// @title    adminReportsHandler
+// @description   管理员按状态(默认全部)查看最近的举报
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) adminReportsHandler(context *gin.Context) {
+	if _, ok := server.authenticateAdmin(context, "adminReportsHandler"); !ok {
+		return
+	}
+	status := context.DefaultQuery("status", "")
+	limit, err := strconv.Atoi(context.DefaultQuery("limit", strconv.Itoa(defaultReportLimit)))
+	if err != nil || limit < 1 || limit > maxReportLimit ||
+		(status != "" && status != dataBase.ReportOpen && status != dataBase.ReportTriaged && status != dataBase.ReportResolved) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'status' should be open, triaged or resolved and 'limit' should be an integer between 1 and " + strconv.Itoa(maxReportLimit),
+			"data":    "",
+		})
+		return
+	}
+	reports, err := server.Stores.Reports.ListReports(status, limit)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "adminReportsHandler", "list reports", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find reports",
+		"data":    reports,
+	})
+}
+
+// @title    adminReportHandler
+// @description   管理员查看一条举报及其全部审计记录
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) adminReportHandler(context *gin.Context) {
+	if _, ok := server.authenticateAdmin(context, "adminReportHandler"); !ok {
+		return
+	}
+	report, ok := server.findReport(context, "adminReportHandler")
+	if !ok {
+		return
+	}
+	history, err := server.Stores.Reports.ListActions(report.ID)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "adminReportHandler", "list actions of report "+report.ID, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find the report",
+		"data":    gin.H{"report": report, "history": history},
+	})
+}
+
+// @title    triageReportHandler
+// @description   管理员接手一条未处理完毕的举报, assignee默认为当前管理员, 可以重新指派
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) triageReportHandler(context *gin.Context) {
+	admin, ok := server.authenticateAdmin(context, "triageReportHandler")
+	if !ok {
+		return
+	}
+	note, ok := reportNoteOf(context)
+	if !ok {
+		return
+	}
+	report, ok := server.findReport(context, "triageReportHandler")
+	if !ok {
+		return
+	}
+	assignee := context.DefaultQuery("assignee", admin)
+	if !utils.Contains(server.Admins, assignee) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'assignee' should be an admin",
+			"data":    "",
+		})
+		return
+	}
+	from := report.Status
+	report.Status = dataBase.ReportTriaged
+	report.Assignee = assignee
+	if note == "" {
+		note = "assigned to " + assignee
+	}
+	if !server.updateReport(context, "triageReportHandler", report, from, admin, dataBase.ReportActionTriaged, note) {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "the report has been " + dataBase.ReportActionTriaged,
+		"data":    report,
+	})
+}
+
+// @title    resolveReportHandler
+// @description   管理员处理一条举报: dismissed(不成立)、warning(推送警告)、suspension(停用hours小时)或ban(永久停用), 停用立即生效, 处理结果及停用期限记录在举报和审计记录中
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) resolveReportHandler(context *gin.Context) {
+	admin, ok := server.authenticateAdmin(context, "resolveReportHandler")
+	if !ok {
+		return
+	}
+	note, ok := reportNoteOf(context)
+	if !ok {
+		return
+	}
+	resolution := context.DefaultQuery("resolution", "")
+	var suspendedUntil int64
+	switch resolution {
+	case dataBase.ResolutionDismissed, dataBase.ResolutionWarning, dataBase.ResolutionBan:
+	case dataBase.ResolutionSuspension:
+		hours, err := strconv.Atoi(context.DefaultQuery("hours", ""))
+		if err != nil || hours < 1 || hours > maxSuspensionHours {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "'hours' should be an integer between 1 and " + strconv.Itoa(maxSuspensionHours),
+				"data":    "",
+			})
+			return
+		}
+		suspendedUntil = time.Now().Add(time.Duration(hours) * time.Hour).Unix()
+	default:
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'resolution' should be dismissed, warning, suspension or ban",
+			"data":    "",
+		})
+		return
+	}
+	report, ok := server.findReport(context, "resolveReportHandler")
+	if !ok {
+		return
+	}
+	from := report.Status
+	report.Status = dataBase.ReportResolved
+	report.Resolution = resolution
+	report.SuspendedUntil = suspendedUntil
+	if report.Assignee == "" {
+		report.Assignee = admin
+	}
+	restrict := resolution == dataBase.ResolutionSuspension || resolution == dataBase.ResolutionBan
+	if restrict && report.Target == admin {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "can't restrict your own account",
+			"data":    "",
+		})
+		return
+	}
+	//先保存处理结果再停用账号, 举报已被其他管理员处理时不会停用账号
+	if !server.updateReport(context, "resolveReportHandler", report, from, admin, dataBase.ReportActionResolved, note) {
+		return
+	}
+	if restrict {
+		account := &dataBase.AccountStatus{Username: report.Target, Status: dataBase.AccountBanned, Reason: note}
+		if resolution == dataBase.ResolutionSuspension {
+			account.Status = dataBase.AccountSuspended
+			account.Until = suspendedUntil
+		}
+		if account.Reason == "" {
+			account.Reason = "reported for " + report.Reason
+		}
+		if err := server.restrictAccount(admin, account); err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "resolveReportHandler", "restrict account of "+report.Target, err.Error())
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "the report has been resolved but failed to restrict the account, Please change the status of the account again",
+				"data":    err.Error(),
+			})
+			return
+		}
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "the report has been " + dataBase.ReportActionResolved,
+		"data":    report,
+	})
+	if resolution == dataBase.ResolutionWarning {
+		content := note
+		if content == "" {
+			content = "you have been reported for " + report.Reason + ", please follow the community rules"
+		}
+		notifyUser(report.Target, utils.NotifyWarning, "", content)
+	}
+}
+
+// @title    reportNoteOf
+// @description   读取并校验处理举报时的备注, 不合法时直接向客户端返回400
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    string, bool	  备注, 是否合法
+func reportNoteOf(context *gin.Context) (string, bool) {
+	note := strings.TrimSpace(context.DefaultQuery("note", ""))
+	if utf8.RuneCountInString(note) > maxReportTextLength {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "'note' is too long, please make its length less than " + strconv.Itoa(maxReportTextLength),
+			"data":    "",
+		})
+		return "", false
+	}
+	return note, true
+}
+
+// @title    findReport
+// @description   按路径中的id查询未处理完毕的举报, 举报不存在时返回404, 已处理完毕时(查看除外)返回409
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string	  gin的上下文指针, 调用方函数名(用于日志)
+// @return    *dataBase.Report, bool	  举报, 是否找到
+func (server *HttpServer) findReport(context *gin.Context, function string) (*dataBase.Report, bool) {
+	report, err := server.Stores.Reports.FindReport(context.Param("id"))
+	if err == dataBase.ErrReportNotFound {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return nil, false
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, function, "find report "+context.Param("id"), err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return nil, false
+	}
+	if context.Request.Method != http.MethodGet && report.Status == dataBase.ReportResolved {
+		context.JSON(http.StatusConflict, gin.H{
+			"message": "the report has been resolved",
+			"data":    report,
+		})
+		return nil, false
+	}
+	return report, true
+}
+
+// @title    updateReport
+// @description   保存举报的变更并追加一条审计记录, 失败时直接向客户端返回错误, 举报的状态已不是读取时的from(被其他管理员处理)时返回409
+// @auth      郑康             2026.10.19
+// @param     *gin.Context, string, *dataBase.Report, string, string, string, string	  gin的上下文指针, 调用方函数名, 更新后的举报, 读取时的状态, 操作者, 操作, 备注
+// @return    bool	  是否保存成功
+func (server *HttpServer) updateReport(context *gin.Context, function string, report *dataBase.Report, from string, actor string, action string, note string) bool {
+	now := time.Now().Unix()
+	report.UpdatedAt = now
+	err := server.Stores.Reports.UpdateReport(report, from, &dataBase.ReportAction{
+		ID:         utils.GeneratorUUID(),
+		ReportID:   report.ID,
+		Actor:      actor,
+		Action:     action,
+		Status:     report.Status,
+		Resolution: report.Resolution,
+		Note:       note,
+		CreatedAt:  now,
+	})
+	if err == dataBase.ErrReportNotFound {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return false
+	} else if err == dataBase.ErrReportChanged {
+		context.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return false
+	} else if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, function, "update report "+report.ID, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return false
+	}
+	return true
+}
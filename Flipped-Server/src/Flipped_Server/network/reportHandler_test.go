@@ -0,0 +1,130 @@
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/repository"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestReportAndResolve(t *testing.T) {
+	server, router := newMemoryServer()
+	server.Admins = []string{"MrFirst"}
+	admin := loginAs(t, router, "MrFirst")
+	second := loginAs(t, router, "MrSecond")
+	_ = server.Stores.Messages.SaveMessage("MrSecond", "MrFirst", "buy my coins")
+	messages, _ := server.Stores.Messages.ListMessages("MrFirst", "MrSecond", 10)
+	messageAt := strconv.FormatInt(messages[0].CreatedAt, 10)
+
+	code, _ := serve(router, "POST", "/reports?username=MrSecond&reason=rude", admin)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/reports?username=MrFirst&reason=spam", admin)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/reports?username=MrNobody&reason=spam", admin)
+	assert.Equal(t, 404, code)
+	code, _ = serve(router, "POST", "/reports?username=MrSecond&reason=spam&messageAt=1", admin)
+	assert.Equal(t, 404, code)
+	code, jsonData := serve(router, "POST", "/reports?username=MrSecond&reason=scam&details=coins&messageAt="+messageAt, admin)
+	assert.Equal(t, 200, code)
+	id := jsonData["data"].(map[string]interface{})["id"].(string)
+
+	//只有管理员可以处理举报
+	code, _ = serve(router, "GET", "/admin/reports", second)
+	assert.Equal(t, 403, code)
+	code, jsonData = serve(router, "GET", "/admin/reports?status=open", admin)
+	assert.Equal(t, 200, code)
+	reports := jsonData["data"].([]interface{})
+	assert.Len(t, reports, 1)
+	report := reports[0].(map[string]interface{})
+	assert.Equal(t, "buy my coins", report["message"])
+	assert.Equal(t, "MrFirst", report["reporter"])
+	code, _ = serve(router, "GET", "/admin/reports?status=closed", admin)
+	assert.Equal(t, 400, code)
+
+	code, _ = serve(router, "POST", "/admin/reports/"+id+"/triage?assignee=MrSecond", admin)
+	assert.Equal(t, 400, code)
+	code, jsonData = serve(router, "POST", "/admin/reports/"+id+"/triage", admin)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "triaged", jsonData["data"].(map[string]interface{})["status"])
+	assert.Equal(t, "MrFirst", jsonData["data"].(map[string]interface{})["assignee"])
+
+	code, _ = serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=suspension", admin)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/admin/reports/unknown/resolve?resolution=ban", admin)
+	assert.Equal(t, 404, code)
+	code, jsonData = serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=suspension&hours=24&note=scam", admin)
+	assert.Equal(t, 200, code)
+	resolved := jsonData["data"].(map[string]interface{})
+	assert.Equal(t, "resolved", resolved["status"])
+	assert.Equal(t, "suspension", resolved["resolution"])
+	assert.NotZero(t, resolved["suspendedUntil"])
+	code, _ = serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=ban", admin)
+	assert.Equal(t, 409, code)
+
+	code, jsonData = serve(router, "GET", "/admin/reports/"+id, admin)
+	assert.Equal(t, 200, code)
+	history := jsonData["data"].(map[string]interface{})["history"].([]interface{})
+	assert.Len(t, history, 3)
+	actions := []string{}
+	for _, entry := range history {
+		actions = append(actions, entry.(map[string]interface{})["action"].(string))
+	}
+	assert.Equal(t, []string{"created", "triaged", "resolved"}, actions)
+	assert.Equal(t, "scam", history[2].(map[string]interface{})["note"])
+}
+
+func TestReportAndMessagesKeepReferencedImages(t *testing.T) {
+	server, router := newMemoryServer()
+	first := loginAs(t, router, "MrFirst")
+	serve(router, "POST", "/swipe?username=MrSecond&action=like", first)
+	serve(router, "POST", "/swipe?username=MrFirst&action=like", loginAs(t, router, "MrSecond"))
+	//发送消息时记录的引用
+	conversation := dataBase.ConversationRef("MrSecond", "MrFirst")
+	_ = recordImageReferences(server.Stores, dataBase.ReferenceMessage, conversation, "<img src=\"/images/evidence/list\"> /images/evidence")
+	_ = recordImageReferences(server.Stores, dataBase.ReferenceMessage, conversation, "/images/other")
+	_ = server.Stores.Messages.SaveMessage("MrSecond", "MrFirst", "<img src=\"/images/evidence/list\">")
+	messages, _ := server.Stores.Messages.ListMessages("MrFirst", "MrSecond", 10)
+	code, _ := serve(router, "POST", "/reports?username=MrSecond&reason=scam&messageAt="+strconv.FormatInt(messages[0].CreatedAt, 10), first)
+	assert.Equal(t, 200, code)
+
+	//解除配对后聊天记录仍然保留, 其中引用的图片同样不会被回收
+	code, _ = serve(router, "POST", "/unmatch?username=MrSecond", first)
+	assert.Equal(t, 200, code)
+	names, _ := server.Stores.Images.ReferencedNames()
+	assert.Equal(t, []string{"evidence", "other"}, names)
+}
+
+// staleReportStore模拟另一个管理员在本次请求读取举报之后、保存之前已经处理了该举报
+type staleReportStore struct {
+	repository.ReportStore
+}
+
+func (store *staleReportStore) FindReport(reportID string) (*dataBase.Report, error) {
+	report, err := store.ReportStore.FindReport(reportID)
+	if err == nil {
+		report.Status = dataBase.ReportOpen
+	}
+	return report, err
+}
+
+func TestConcurrentResolveConflicts(t *testing.T) {
+	server, router := newMemoryServer()
+	server.Admins = []string{"MrFirst"}
+	admin := loginAs(t, router, "MrFirst")
+	code, jsonData := serve(router, "POST", "/reports?username=MrSecond&reason=spam", admin)
+	assert.Equal(t, 200, code)
+	id := jsonData["data"].(map[string]interface{})["id"].(string)
+	code, _ = serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=dismissed", admin)
+	assert.Equal(t, 200, code)
+
+	server.Stores.Reports = &staleReportStore{ReportStore: server.Stores.Reports}
+	code, _ = serve(router, "POST", "/admin/reports/"+id+"/resolve?resolution=warning", admin)
+	assert.Equal(t, 409, code)
+	code, _ = serve(router, "POST", "/admin/reports/"+id+"/triage", admin)
+	assert.Equal(t, 409, code)
+	actions, _ := server.Stores.Reports.ListActions(id)
+	assert.Len(t, actions, 2)
+	report, _ := server.Stores.Reports.(*staleReportStore).ReportStore.FindReport(id)
+	assert.Equal(t, dataBase.ResolutionDismissed, report.Resolution)
+}
@@ -0,0 +1,141 @@
+// @Title  searchHandler.go
+// @Description  To provide the http handler of searching users
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 每页搜索结果的默认数量和最大数量
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 50
+)
+
+// 搜索路由GET /users/search与GET /users/:name共用前缀, gin不允许二者同时注册, 因此由userProfileHandler转交, 该名字不能用于注册
+const searchUserName = "search"
+
+// @title    searchUsersHandler
+// @description   按用户名前缀和地区、职业、年龄范围、用户类型、爱好、兴趣标签(拥有其中任一标签)等条件分页搜索用户, 结果排除自己和存在拉黑关系的用户
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) searchUsersHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "searchUsersHandler")
+	if !ok {
+		return
+	}
+	query, page, pageSize, err := userQueryOf(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    "",
+		})
+		return
+	}
+	blockedUsers, err := repository.BlockedUsersOf(server.Stores.Blocks, username)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "searchUsersHandler", "error to get blocked users of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	query.Excluded = append(blockedUsers, username)
+	query.Tags = tagNamesOf(context.DefaultQuery("tags", ""))
+	//多查询一条用于判断是否还有下一页
+	query.Offset = (page - 1) * pageSize
+	query.Limit = pageSize + 1
+	users, err := server.Stores.Users.SearchUsers(query)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "searchUsersHandler", "search users", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	hasMore := len(users) > pageSize
+	if hasMore {
+		users = users[:pageSize]
+	}
+	usernames := make([]string, len(users))
+	for i := range users {
+		usernames[i] = users[i].Username
+	}
+	distances := server.approximateDistances(username, usernames)
+	tags := server.userTagsOf(append(usernames, username))
+	profiles := make([]gin.H, len(users))
+	for i := range users {
+		profiles[i] = publicProfileOf(users[i])
+		if distance, ok := distances[users[i].Username]; ok {
+			profiles[i]["Distance"] = distance
+		}
+		profiles[i]["Tags"] = tags[users[i].Username]
+		profiles[i]["CommonTags"] = commonTags(tags[username], tags[users[i].Username])
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to search users",
+		"data": gin.H{
+			"users":    profiles,
+			"page":     page,
+			"pageSize": pageSize,
+			"hasMore":  hasMore,
+		},
+	})
+}
+
+// @title    userQueryOf
+// @description   从请求参数中解析搜索条件和分页参数
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    *dataBase.UserQuery, int, int, error	  搜索条件, 页码, 每页数量, 参数错误
+func userQueryOf(context *gin.Context) (*dataBase.UserQuery, int, int, error) {
+	query := &dataBase.UserQuery{
+		NamePrefix: strings.TrimSpace(context.DefaultQuery("name", "")),
+		Region:     strings.TrimSpace(context.DefaultQuery("region", "")),
+		Profession: strings.TrimSpace(context.DefaultQuery("profession", "")),
+		Hobby:      strings.TrimSpace(context.DefaultQuery("hobby", "")),
+	}
+	var err error
+	integers := []struct {
+		key          string
+		defaultValue string
+		target       *int
+		min          int
+		max          int
+	}{
+		{"minAge", "0", &query.MinAge, 0, maxProfileAge},
+		{"maxAge", "0", &query.MaxAge, 0, maxProfileAge},
+		{"user_type", "-1", &query.UserType, -1, 1 << 30},
+	}
+	for _, item := range integers {
+		*item.target, err = strconv.Atoi(context.DefaultQuery(item.key, item.defaultValue))
+		if err != nil || *item.target < item.min || *item.target > item.max {
+			return nil, 0, 0, errors.New("wrong value of '" + item.key + "'")
+		}
+	}
+	if query.MaxAge > 0 && query.MinAge > query.MaxAge {
+		return nil, 0, 0, errors.New("'minAge' should not be greater than 'maxAge'")
+	}
+	page, err := strconv.Atoi(context.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return nil, 0, 0, errors.New("'page' should be a positive integer")
+	}
+	pageSize, err := strconv.Atoi(context.DefaultQuery("pageSize", strconv.Itoa(defaultSearchPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxSearchPageSize {
+		return nil, 0, 0, errors.New("'pageSize' should be an integer between 1 and " + strconv.Itoa(maxSearchPageSize))
+	}
+	return query, page, pageSize, nil
+}
@@ -0,0 +1,85 @@
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/repository"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// failingSearchStore模拟搜索时数据库出错的用户存储
+type failingSearchStore struct {
+	repository.UserStore
+}
+
+func (store *failingSearchStore) SearchUsers(query *dataBase.UserQuery) ([]*dataBase.UserInfoTable, error) {
+	return nil, errors.New("mysql is unavailable")
+}
+
+func TestSearchUsers(t *testing.T) {
+	server, router := newMemoryServer()
+	for _, user := range []*dataBase.UserInfoTable{
+		{Username: "Mr", Age: 30, Region: "Wuhan", UserType: 1},
+		{Username: "MrThird", Age: 25, Region: "Wuhan", Hobby: "reading,hiking"},
+		{Username: "MrFourth", Age: 40, Region: "Beijing"},
+		{Username: "MissFifth", Age: 22, Region: "Wuhan"},
+	} {
+		_ = server.Stores.Users.InsertUser(user)
+	}
+	token := loginAs(t, router, "MrFirst")
+
+	code, jsonData := serve(router, "GET", "/users/search?name=mr", token)
+	assert.Equal(t, 200, code)
+	data := jsonData["data"].(map[string]interface{})
+	users := data["users"].([]interface{})
+	assert.Len(t, users, 4)
+	assert.Equal(t, "Mr", users[0].(map[string]interface{})["Username"])
+	assert.NotContains(t, users[0], "Email")
+	assert.Equal(t, false, data["hasMore"])
+
+	code, jsonData = serve(router, "GET", "/users/search?name=mr&region=Wuhan&minAge=26", token)
+	assert.Equal(t, 200, code)
+	users = jsonData["data"].(map[string]interface{})["users"].([]interface{})
+	assert.Len(t, users, 1)
+	assert.Equal(t, "Mr", users[0].(map[string]interface{})["Username"])
+
+	_, jsonData = serve(router, "GET", "/users/search?hobby=HIKING", token)
+	users = jsonData["data"].(map[string]interface{})["users"].([]interface{})
+	assert.Len(t, users, 1)
+	assert.Equal(t, "MrThird", users[0].(map[string]interface{})["Username"])
+
+	code, jsonData = serve(router, "GET", "/users/search?name=mr&pageSize=2&page=2", token)
+	assert.Equal(t, 200, code)
+	data = jsonData["data"].(map[string]interface{})
+	assert.Len(t, data["users"], 2)
+	assert.Equal(t, false, data["hasMore"])
+	_, jsonData = serve(router, "GET", "/users/search?name=mr&pageSize=2", token)
+	assert.Equal(t, true, jsonData["data"].(map[string]interface{})["hasMore"])
+
+	_ = server.Stores.Blocks.Block("MrSecond", "MrFirst")
+	_, jsonData = serve(router, "GET", "/users/search?name=mrs", token)
+	assert.Len(t, jsonData["data"].(map[string]interface{})["users"], 0)
+
+	code, _ = serve(router, "GET", "/users/search?pageSize=100", token)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "GET", "/users/search?minAge=40&maxAge=20", token)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "GET", "/users/search", "")
+	assert.Equal(t, 401, code)
+}
+
+func TestSearchUsersErrorAndRoute(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	//搜索路由与查看资料的路由共用前缀
+	code, jsonData := serve(router, "GET", "/users/search?name=MrS", token)
+	assert.Equal(t, 200, code)
+	assert.Len(t, jsonData["data"].(map[string]interface{})["users"], 1)
+	code, _ = serve(router, "GET", "/users/MrSecond", token)
+	assert.Equal(t, 200, code)
+
+	server.Stores.Users = &failingSearchStore{UserStore: server.Stores.Users}
+	code, _ = serve(router, "GET", "/users/search?name=mr", token)
+	assert.Equal(t, 500, code)
+}
@@ -1,22 +1,38 @@
 package network
 
 import (
+	"Flipped_Server/dataBase"
 	"Flipped_Server/logger"
 	"Flipped_Server/messageQueue"
+	"Flipped_Server/moderation"
+	"Flipped_Server/repository"
 	"Flipped_Server/utils"
 	"encoding/json"
 	"github.com/sirupsen/logrus"
 	"net"
 	"strconv"
-	"sync"
 )
 
+// SocketServer结构体包含了Socket服务器绑定的IP地址和端口号, 以及处理消息时使用的存储和内容审核器
 type SocketServer struct {
-	IPAddr string
-	Port   int
+	IPAddr    string
+	Port      int
+	Stores    *repository.Stores
+	Moderator *moderation.Moderator
 }
 
 func (ss *SocketServer) Run() {
+	if ss.Stores == nil {
+		ss.Stores = repository.NewBackendStores()
+	}
+	if ss.Moderator == nil {
+		moderator, err := moderation.LoadModerator()
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "Run", "error to create the moderator", err.Error())
+			return
+		}
+		ss.Moderator = moderator
+	}
 	server, err := net.Listen("tcp", ss.IPAddr+":"+strconv.Itoa(ss.Port))
 	utils.UserConnectionMap = make(map[string]net.Conn)
 
@@ -33,114 +49,107 @@ func (ss *SocketServer) Run() {
 			logger.SetToLogger(logrus.ErrorLevel, "Run", "error to Accept socket", err.Error())
 		} else {
 			logger.SetToLogger(logrus.InfoLevel, "Run", "succeed to Accept socket", "")
-			go connectionHandler(conn)
+			go ss.connectionHandler(conn)
 		}
 	}
 }
 
-func connectionHandler(conn net.Conn) {
-	var bytesFlag chan []byte
-	var countFlag chan int
-	var exitFlag chan bool
-	bytesFlag = make(chan []byte)
-	countFlag = make(chan int)
-	exitFlag = make(chan bool)
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go func() {
-		for {
-			select {
-			case <-exitFlag:
-				{
-					logger.SetToLogger(logrus.ErrorLevel, "Anonymous function one in connectionHandler", "receive a exit signal", "")
-					wg.Done()
-					return
-				}
-			default:
-				{
-					buf := make([]byte, 1024*1024)
-					count, err := conn.Read(buf)
-					if err != nil {
-						logger.SetToLogger(logrus.ErrorLevel, "Anonymous function one in connectionHandler", "error to read from connection", err.Error())
-						exitFlag <- true
-						wg.Done()
-						return
-					} else {
-						logger.SetToLogger(logrus.InfoLevel, "Anonymous function one in connectionHandler", "succeed to read from connection", "bytes number:"+strconv.Itoa(count))
-						bytesFlag <- buf
-						countFlag <- count
-						logger.SetToLogger(logrus.InfoLevel, "Anonymous function one in connectionHandler", "succeed to send bytes to bytesFlag channel", "bytes number:"+strconv.Itoa(count))
-					}
-				}
-			}
+// @title    connectionHandler
+// @description   依次读取并处理客户端发送的消息, 连接断开或消息格式错误时移除该连接并通知用户的好友其已下线
+// @auth      郑康             2026.10.19
+// @param     net.Conn	  与客户端的连接
+// @return    void
+func (ss *SocketServer) connectionHandler(conn net.Conn) {
+	for {
+		buf := make([]byte, 1024*1024)
+		count, err := conn.Read(buf)
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "connectionHandler", "error to read from connection", err.Error())
+			break
+		}
+		logger.SetToLogger(logrus.InfoLevel, "connectionHandler", "succeed to read from connection", "bytes number:"+strconv.Itoa(count))
+		msg := utils.FromClientMsg{}
+		err = json.Unmarshal(buf[:count], &msg)
+		if err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "connectionHandler", "error to decode message sent from client", err.Error())
+			break
 		}
-	}()
-	go func() {
-		for {
-			select {
-			case <-exitFlag:
-				{
-					logger.SetToLogger(logrus.ErrorLevel, "Anonymous function one in connectionHandler", "receive a exit sigal", "")
-					wg.Done()
-					return
-				}
-			default:
-				{
-					logger.SetToLogger(logrus.InfoLevel, "Anonymous function two in connectionHandler", "go into default branch", "")
-					bytes := <-bytesFlag
-					count := <-countFlag
-					msg := utils.FromClientMsg{}
-					err := json.Unmarshal(bytes[:count], &msg)
-					if err != nil {
-						logger.SetToLogger(logrus.ErrorLevel, "Anonymous function two in connectionHandler", "error to decode message sent from client", err.Error())
-						exitFlag <- true
-						wg.Done()
-						return
-					}
-					switch msg.MsgType {
-					case 1:
-						err = communicationRequestHandler(&msg, conn)
-						if err != nil {
-							reply := utils.ReplyMsg{
-								ResultCode: 500,
-								MsgContent: "Some error occur in the server, please try it latter",
-							}
-							buf, _ := json.Marshal(reply)
-							_, _ = conn.Write(buf)
-						}
-					case 2:
-						err = connectionRequestHandler(&msg, conn)
-						if err != nil {
-							replyToClient(conn, 500, "Some error occur in the server, please try it latter")
-						}
-					default:
-						logger.SetToLogger(logrus.ErrorLevel, "connectionHandler", "error type in communicationMsg struct", "")
-					}
-				}
+		switch msg.MsgType {
+		case 1:
+			err = ss.communicationRequestHandler(&msg, conn)
+			if err != nil {
+				replyToClient(conn, 500, "Some error occur in the server, please try it latter")
 			}
+		case 2:
+			err = ss.connectionRequestHandler(&msg, conn)
+			if err != nil {
+				replyToClient(conn, 500, "Some error occur in the server, please try it latter")
+			}
+		default:
+			logger.SetToLogger(logrus.ErrorLevel, "connectionHandler", "error type in communicationMsg struct", "")
 		}
-	}()
-	wg.Wait()
+	}
 	logger.SetToLogger(logrus.InfoLevel, "connectionHandler", "one connection to client is disconnected", "")
-	utils.DeleteConnection(conn)
+	for _, username := range utils.DeleteConnection(conn) {
+		userDisconnected(ss.Stores, username)
+	}
+	_ = conn.Close()
 	logger.SetToLogger(logrus.InfoLevel, "connectionHandler", "delete the connection with client", "")
 }
 
 //交流请求
-func communicationRequestHandler(msg *utils.FromClientMsg, conn net.Conn) error {
+func (ss *SocketServer) communicationRequestHandler(msg *utils.FromClientMsg, conn net.Conn) error {
 	sourceUserToken := msg.MsgFrom
-	sourceUser, err := ParseToken(sourceUserToken)
-	if err != nil {
+	sourceUser, err := ParseToken(ss.Stores.Sessions, ss.Stores.Users, sourceUserToken)
+	if restricted, ok := err.(*AccountRestrictedError); ok {
+		replyToClient(conn, StatusAccountRestricted, restricted.Error())
+		return nil
+	} else if _, ok := err.(*AccountStatusUnavailableError); ok {
+		replyToClient(conn, 503, err.Error())
+		return nil
+	} else if err != nil {
 		logger.SetToLogger(logrus.ErrorLevel, "communicationRequestHandler", "error to parse token from client", err.Error())
 		return err
 	}
 
-	utils.RWLock.Lock()
-	utils.UserConnectionMap[sourceUser] = conn
-	utils.RWLock.Unlock()
+	if utils.RegisterConnection(sourceUser, conn) {
+		ss.userConnected(sourceUser)
+	}
 
 	targetUser := msg.MsgTo
 	msgContent := msg.MsgContent
+	//任意一方拉黑对方或双方已解除配对时不转发消息, 无法读取黑名单或解除配对的记录时同样拒绝
+	if blocked, err := repository.IsBlockedBetween(ss.Stores.Blocks, sourceUser, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "communicationRequestHandler", "check block list", err.Error())
+		replyToClient(conn, 500, "some error occur in the server, Please try again")
+		return nil
+	} else if unmatchedUsers, err := ss.Stores.Matches.ListUnmatched(sourceUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "communicationRequestHandler", "list unmatched users", err.Error())
+		replyToClient(conn, 500, "some error occur in the server, Please try again")
+		return nil
+	} else if blocked || utils.Contains(unmatchedUsers, targetUser) {
+		logger.SetToLogger(logrus.InfoLevel, "communicationRequestHandler", "refuse to route message between blocked or unmatched users", sourceUser+" -> "+targetUser)
+		replyToClient(conn, 403, "you can't send message to the user")
+		return nil
+	}
+	//被拒绝的消息不保存也不转发, 命中mask规则的内容替换后再保存和转发
+	verdict := moderateText(ss.Moderator, ss.Stores, sourceUser, dataBase.SourceMessage, targetUser, msgContent)
+	if verdict.Rejected() {
+		logger.SetToLogger(logrus.InfoLevel, "communicationRequestHandler", "refuse to route message rejected by moderation", sourceUser+" -> "+targetUser)
+		replyToClient(conn, 400, "your message contains content that isn't allowed")
+		return nil
+	}
+	msgContent = verdict.Text
+	//先记录消息中引用的图片再保存和转发, 记录失败时不发送, 避免图片被清理任务回收
+	if err := recordImageReferences(ss.Stores, dataBase.ReferenceMessage, dataBase.ConversationRef(sourceUser, targetUser), msgContent); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "communicationRequestHandler", "record image references in message", err.Error())
+		replyToClient(conn, 500, "some error occur in the server, Please try again")
+		return nil
+	}
+	//保存聊天记录, 保存失败不影响消息的转发
+	if err := ss.Stores.Messages.SaveMessage(sourceUser, targetUser, msgContent); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "communicationRequestHandler", "error to save message", err.Error())
+	}
 	//如果目标用户处于与服务器TCP连接状态,并且能够正常获得目标用户的连接
 	if targetConn := utils.GetUserConnection(targetUser); utils.IsUserConnected(targetUser) && targetConn != nil {
 		resultCode := 200
@@ -158,7 +167,7 @@ func communicationRequestHandler(msg *utils.FromClientMsg, conn net.Conn) error
 			replyToClient(conn, resultCode, replyContent)
 			return err
 		}
-		count, err := targetConn.Write(buf)
+		count, err := utils.WriteToConnection(targetConn, buf)
 		if err != nil {
 			logger.SetToLogger(logrus.ErrorLevel, "communicationRequestHandler", "error to send to client with bytes", err.Error())
 			resultCode = 500
@@ -192,27 +201,49 @@ func communicationRequestHandler(msg *utils.FromClientMsg, conn net.Conn) error
 }
 
 //连接请求
-func connectionRequestHandler(msg *utils.FromClientMsg, conn net.Conn) error {
+func (ss *SocketServer) connectionRequestHandler(msg *utils.FromClientMsg, conn net.Conn) error {
 	realMsg := *msg
 	sourceUserToken := realMsg.MsgFrom
-	sourceUser, err := ParseToken(sourceUserToken)
-	if err != nil {
+	sourceUser, err := ParseToken(ss.Stores.Sessions, ss.Stores.Users, sourceUserToken)
+	if restricted, ok := err.(*AccountRestrictedError); ok {
+		replyToClient(conn, StatusAccountRestricted, restricted.Error())
+		return nil
+	} else if _, ok := err.(*AccountStatusUnavailableError); ok {
+		replyToClient(conn, 503, err.Error())
+		return nil
+	} else if err != nil {
 		logger.SetToLogger(logrus.ErrorLevel, "communicationRequestHandler", "error to parse token from client", err.Error())
 		return err
 	}
 
-	utils.RWLock.Lock()
-	utils.UserConnectionMap[sourceUser] = conn
-	utils.RWLock.Unlock()
+	isNew := utils.RegisterConnection(sourceUser, conn)
 	replyToClient(conn, 200, "succeed to connect to socketServer")
+	if isNew {
+		ss.userConnected(sourceUser)
+	}
 	return nil
 }
 
+// @title    userConnected
+// @description   用户建立socket连接后刷新其在线状态, 由离线变为在线时通知其在线的好友, 并向其推送在线好友列表
+// @auth      郑康             2026.10.19
+// @param     string	  用户名
+// @return    void
+func (ss *SocketServer) userConnected(username string) {
+	//用户已经通过http心跳在线时好友已经收到过上线通知
+	if online, err := ss.Stores.Presence.UpdateStatus(username, false); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "userConnected", "update status of "+username, err.Error())
+	} else if online {
+		notifyPresence(ss.Stores, username, utils.PresenceOnline)
+	}
+	sendPresenceSnapshot(ss.Stores, username)
+}
+
 func replyToClient(conn net.Conn, resultCode int, msgContent string) {
 	reply := utils.ReplyMsg{
 		ResultCode: resultCode,
 		MsgContent: msgContent,
 	}
 	buf, _ := json.Marshal(reply)
-	_, _ = conn.Write(buf)
+	_, _ = utils.WriteToConnection(conn, buf)
 }
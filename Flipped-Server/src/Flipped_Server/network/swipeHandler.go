@@ -0,0 +1,103 @@
+// @Title  swipeHandler.go
+// @Description  To provide the http handler of liking or passing users and matching users who like each other
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// 滑动操作的取值
+const (
+	swipeActionLike = "like"
+	swipeActionPass = "pass"
+)
+
+// @title    swipeHandler
+// @description   记录当前用户对目标用户的喜欢(action=like)或跳过(action=pass), 双方互相喜欢时配对成功并互加好友
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) swipeHandler(context *gin.Context) {
+	sourceUser, ok := server.authenticate(context, "swipeHandler")
+	if !ok {
+		return
+	}
+	targetUser := context.DefaultQuery("username", "")
+	action := context.DefaultQuery("action", "")
+	status := http.StatusOK
+	msg := "succeed to record the swipe"
+	matched := false
+	if targetUser == "" {
+		status = http.StatusBadRequest
+		msg = "key 'username' is required"
+	} else if action != swipeActionLike && action != swipeActionPass {
+		status = http.StatusBadRequest
+		msg = "value of 'action' should be 'like' or 'pass'"
+	} else if targetUser == sourceUser {
+		status = http.StatusBadRequest
+		msg = "can't swipe yourself"
+	} else if !server.Stores.Users.DoesUserExist(targetUser) {
+		status = http.StatusNotFound
+		msg = "target user doesn't exist"
+	} else if blocked, err := repository.IsBlockedBetween(server.Stores.Blocks, sourceUser, targetUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "swipeHandler", "check block list", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if blocked {
+		status = http.StatusForbidden
+		msg = "you can't swipe the user"
+	} else if server.Stores.Matches.IsMatched(sourceUser, targetUser) {
+		status = http.StatusConflict
+		msg = "you have already matched with the user"
+	} else if unmatchedUsers, err := server.Stores.Matches.ListUnmatched(sourceUser); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "swipeHandler", "list unmatched users", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if utils.Contains(unmatchedUsers, targetUser) {
+		status = http.StatusForbidden
+		msg = "you have unmatched with the user"
+	} else if err := server.Stores.Swipes.RecordSwipe(sourceUser, targetUser, action == swipeActionLike); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "swipeHandler", "record swipe", err.Error())
+		status = http.StatusInternalServerError
+		msg = "some error occur in the server, Please try again"
+	} else if swipe, ok := server.Stores.Swipes.GetSwipe(targetUser, sourceUser); action == swipeActionLike && ok && swipe.Liked {
+		if err := server.matchUsers(sourceUser, targetUser); err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "swipeHandler", "match "+sourceUser+" with "+targetUser, err.Error())
+			status = http.StatusInternalServerError
+			msg = "there is something wrong when matching, please try it latter"
+		} else {
+			matched = true
+			msg = "it's a match"
+		}
+	}
+	context.JSON(status, gin.H{
+		"message": msg,
+		"data":    gin.H{"matched": matched},
+	})
+}
+
+// @title    matchUsers
+// @description   保存配对记录, 双方互加好友并删除双方之间待处理的好友请求, 然后向双方推送配对成功的通知
+// @auth      郑康             2026.10.19
+// @param     string, string	  后喜欢的一方, 先喜欢的一方
+// @return    error	  错误信息
+func (server *HttpServer) matchUsers(userA string, userB string) error {
+	if err := server.Stores.Friends.AddMutualFriends(userA, userB); err != nil {
+		return err
+	}
+	if err := server.Stores.Matches.CreateMatch(userA, userB); err != nil {
+		return err
+	}
+	_ = server.Stores.FriendRequests.RemoveRequest(userA, userB)
+	_ = server.Stores.FriendRequests.RemoveRequest(userB, userA)
+	notifyUser(userA, utils.NotifyNewMatch, userB, "you and "+userB+" like each other")
+	notifyUser(userB, utils.NotifyNewMatch, userA, "you and "+userA+" like each other")
+	return nil
+}
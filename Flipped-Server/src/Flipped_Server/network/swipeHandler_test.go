@@ -0,0 +1,51 @@
+package network
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSwipeAndMatch(t *testing.T) {
+	server, router := newMemoryServer()
+	firstToken := loginAs(t, router, "MrFirst")
+	secondToken := loginAs(t, router, "MrSecond")
+
+	code, _ := serve(router, "POST", "/swipe?username=MrSecond&action=maybe", firstToken)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/swipe?username=MrFirst&action=like", firstToken)
+	assert.Equal(t, 400, code)
+	code, _ = serve(router, "POST", "/swipe?username=Nobody&action=like", firstToken)
+	assert.Equal(t, 404, code)
+
+	code, jsonData := serve(router, "POST", "/swipe?username=MrSecond&action=like", firstToken)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, false, jsonData["data"].(map[string]interface{})["matched"])
+	friendList, _ := server.Stores.Friends.GetFriendList("MrFirst")
+	assert.Empty(t, friendList)
+
+	code, jsonData = serve(router, "POST", "/swipe?username=MrFirst&action=pass", secondToken)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, false, jsonData["data"].(map[string]interface{})["matched"])
+
+	code, jsonData = serve(router, "POST", "/swipe?username=MrFirst&action=like", secondToken)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, true, jsonData["data"].(map[string]interface{})["matched"])
+	assert.True(t, server.Stores.Matches.IsMatched("MrFirst", "MrSecond"))
+	friendList, _ = server.Stores.Friends.GetFriendList("MrFirst")
+	assert.Equal(t, []string{"MrSecond"}, friendList)
+	friendList, _ = server.Stores.Friends.GetFriendList("MrSecond")
+	assert.Equal(t, []string{"MrFirst"}, friendList)
+
+	code, _ = serve(router, "POST", "/swipe?username=MrSecond&action=like", firstToken)
+	assert.Equal(t, 409, code)
+}
+
+func TestSwipeBlockedUser(t *testing.T) {
+	server, router := newMemoryServer()
+	token := loginAs(t, router, "MrFirst")
+	_ = server.Stores.Blocks.Block("MrSecond", "MrFirst")
+	code, _ := serve(router, "POST", "/swipe?username=MrSecond&action=like", token)
+	assert.Equal(t, 403, code)
+	_, ok := server.Stores.Swipes.GetSwipe("MrFirst", "MrSecond")
+	assert.False(t, ok)
+}
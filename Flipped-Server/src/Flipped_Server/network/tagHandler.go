@@ -0,0 +1,177 @@
+// @Title  tagHandler.go
+// @Description  To provide the http handlers of the interest tag catalogue and the tags of the current user
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package network
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 每个用户最多可以设置的兴趣标签数
+const maxUserTags = 10
+
+// 按前缀自动补全时默认返回的数量和最多返回的数量
+const (
+	defaultTagSuggestions = 10
+	maxTagSuggestions     = 50
+)
+
+// @title    tagsHandler
+// @description   获取兴趣标签目录, 指定prefix时按前缀自动补全, 最多返回limit个
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) tagsHandler(context *gin.Context) {
+	if _, ok := server.authenticate(context, "tagsHandler"); !ok {
+		return
+	}
+	prefix := dataBase.NormalizeTag(context.DefaultQuery("prefix", ""))
+	limit := 0
+	if prefix != "" {
+		var err error
+		limit, err = strconv.Atoi(context.DefaultQuery("limit", strconv.Itoa(defaultTagSuggestions)))
+		if err != nil || limit < 1 || limit > maxTagSuggestions {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "'limit' should be an integer between 1 and " + strconv.Itoa(maxTagSuggestions),
+				"data":    "",
+			})
+			return
+		}
+	}
+	tags, err := server.Stores.Tags.SearchTags(prefix, limit)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "tagsHandler", "search tags with prefix "+prefix, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to find tags",
+		"data":    tags,
+	})
+}
+
+// @title    setTagsHandler
+// @description   用tags(以逗号分隔)替换当前用户的兴趣标签, 标签必须存在于目录中, tags为空时清空
+// @auth      郑康             2026.10.19
+// @param     *gin.Context	  gin的上下文指针
+// @return    void
+func (server *HttpServer) setTagsHandler(context *gin.Context) {
+	username, ok := server.authenticate(context, "setTagsHandler")
+	if !ok {
+		return
+	}
+	names := tagNamesOf(context.DefaultQuery("tags", ""))
+	if len(names) > maxUserTags {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "at most " + strconv.Itoa(maxUserTags) + " tags are allowed",
+			"data":    "",
+		})
+		return
+	}
+	tags, err := server.Stores.Tags.FindTags(names)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "setTagsHandler", "find tags", err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	if len(tags) != len(names) {
+		known := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			known[tag.Name] = true
+		}
+		unknown := []string{}
+		for _, name := range names {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "some tags don't exist in the catalogue",
+			"data":    unknown,
+		})
+		return
+	}
+	tagIDs := make([]int, len(tags))
+	for i := range tags {
+		tagIDs[i] = tags[i].ID
+	}
+	if err := server.Stores.Tags.SetUserTags(username, tagIDs); err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "setTagsHandler", "set tags of "+username, err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "some error occur in the server, Please try again",
+			"data":    err.Error(),
+		})
+		return
+	}
+	server.invalidateRecommendQueue(username)
+	context.JSON(http.StatusOK, gin.H{
+		"message": "succeed to update tags",
+		"data":    server.userTagsOf([]string{username})[username],
+	})
+}
+
+// @title    tagNamesOf
+// @description   将以逗号分隔的标签拆分并规范化, 去掉空的、过长的和重复的标签
+// @auth      郑康             2026.10.19
+// @param     string	  以逗号分隔的标签
+// @return    []string	  规范化后的标签名
+func tagNamesOf(str string) []string {
+	res := []string{}
+	seen := make(map[string]bool)
+	for _, item := range strings.FieldsFunc(str, func(r rune) bool { return r == ',' || r == '，' }) {
+		name := dataBase.NormalizeTag(item)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// @title    userTagsOf
+// @description   批量获取用户的兴趣标签, 查询失败时只记录日志, 没有标签的用户对应空列表
+// @auth      郑康             2026.10.19
+// @param     []string	  用户名列表
+// @return    map[string][]string	  用户名与标签名
+func (server *HttpServer) userTagsOf(usernames []string) map[string][]string {
+	tags, err := server.Stores.Tags.UserTags(usernames)
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "userTagsOf", "find tags of users", err.Error())
+		tags = make(map[string][]string)
+	}
+	for _, username := range usernames {
+		if tags[username] == nil {
+			tags[username] = []string{}
+		}
+	}
+	return tags
+}
+
+// @title    commonTags
+// @description   计算两组标签的交集
+// @auth      郑康             2026.10.19
+// @param     []string, []string	  标签A, 标签B
+// @return    []string	  共同的标签, 顺序与标签B一致
+func commonTags(a []string, b []string) []string {
+	res := []string{}
+	for _, tag := range b {
+		if utils.Contains(a, tag) {
+			res = append(res, tag)
+		}
+	}
+	return res
+}
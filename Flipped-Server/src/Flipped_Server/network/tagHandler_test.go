@@ -0,0 +1,48 @@
+package network
+
+import (
+	"Flipped_Server/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTagsAndSearchByTags(t *testing.T) {
+	server, router := newMemoryServer()
+	server.Stores.Tags.(*repository.MemoryTagStore).AddTags("reading", "running", "board-games", "music")
+	token := loginAs(t, router, "MrFirst")
+
+	code, jsonData := serve(router, "GET", "/tags?prefix=R", token)
+	assert.Equal(t, 200, code)
+	tags := jsonData["data"].([]interface{})
+	assert.Len(t, tags, 2)
+	assert.Equal(t, "reading", tags[0].(map[string]interface{})["name"])
+	code, _ = serve(router, "GET", "/tags?prefix=r&limit=0", token)
+	assert.Equal(t, 400, code)
+	_, jsonData = serve(router, "GET", "/tags", token)
+	assert.Len(t, jsonData["data"], 4)
+
+	code, jsonData = serve(router, "PUT", "/profile/tags?tags=Reading,%23Board%20Games,reading", token)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []interface{}{"board-games", "reading"}, jsonData["data"])
+	code, jsonData = serve(router, "PUT", "/profile/tags?tags=reading,knitting", token)
+	assert.Equal(t, 400, code)
+	assert.Equal(t, []interface{}{"knitting"}, jsonData["data"])
+
+	secondToken := loginAs(t, router, "MrSecond")
+	_, _ = serve(router, "PUT", "/profile/tags?tags=reading,music", secondToken)
+	_, jsonData = serve(router, "GET", "/users/MrSecond", token)
+	profile := jsonData["data"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"music", "reading"}, profile["Tags"])
+	assert.Equal(t, []interface{}{"reading"}, profile["CommonTags"])
+
+	_, jsonData = serve(router, "GET", "/users/search?tags=music", token)
+	users := jsonData["data"].(map[string]interface{})["users"].([]interface{})
+	assert.Len(t, users, 1)
+	_, jsonData = serve(router, "GET", "/users/search?tags=running", token)
+	assert.Len(t, jsonData["data"].(map[string]interface{})["users"], 0)
+
+	code, _ = serve(router, "PUT", "/profile/tags?tags=", token)
+	assert.Equal(t, 200, code)
+	_, jsonData = serve(router, "GET", "/profile", token)
+	assert.Equal(t, []interface{}{}, jsonData["data"].(map[string]interface{})["Tags"])
+}
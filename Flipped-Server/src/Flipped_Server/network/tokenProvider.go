@@ -5,8 +5,8 @@
 package network
 
 import (
-	"Flipped_Server/dataBase"
 	"Flipped_Server/logger"
+	"Flipped_Server/repository"
 	"errors"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/sirupsen/logrus"
@@ -26,11 +26,11 @@ const TokenExpireDuration = 3 * 3600
 var MySecret = []byte("First Blood")
 
 // @title    GenerateToken
-// @description   			通过用户名生成token字符串,并将token字符串与用户名组成键值对存入redis中
+// @description   			通过用户名生成token字符串,并将token字符串与用户名组成键值对存入会话存储中
 // @auth      郑康       	2020.5.25
-// @param     string		用户名
+// @param     repository.SessionStore, string		会话存储, 用户名
 // @return    string；error	token字符串；错误信息
-func GenerateToken(username string) (string, error) {
+func GenerateToken(sessions repository.SessionStore, username string) (string, error) {
 	//创建自定义声明
 	claim := MyClaims{
 		username,
@@ -49,7 +49,7 @@ func GenerateToken(username string) (string, error) {
 		}).Error(err.Error())
 		return "", err
 	}
-	err1 := dataBase.WriteToRedis(username, tokenStr)
+	err1 := sessions.SaveToken(username, tokenStr)
 
 	if err1 != nil {
 		logger.Logger.WithFields(logrus.Fields{
@@ -62,11 +62,11 @@ func GenerateToken(username string) (string, error) {
 }
 
 // @title    ParseToken
-// @description   			通过token字符串解析用户名, 同时判断token是否合法
-// @auth      郑康       	2020.5.25
-// @param     string		token字符串
-// @return    string；error	用户名字符串；错误信息
-func ParseToken(tokenStr string) (string, error) {
+// @description   			通过token字符串解析用户名, 同时判断token是否合法以及账号是否被停用
+// @auth      郑康       	2026.10.19
+// @param     repository.SessionStore, repository.UserStore, string		会话存储, 用户存储, token字符串
+// @return    string；error	用户名字符串；错误信息, 账号被停用时为*AccountRestrictedError, 无法读取账号状态时为*AccountStatusUnavailableError
+func ParseToken(sessions repository.SessionStore, users repository.UserStore, tokenStr string) (string, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &MyClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return MySecret, nil
 	})
@@ -77,7 +77,10 @@ func ParseToken(tokenStr string) (string, error) {
 		return "", err
 	}
 
-	if claims, ok := token.Claims.(*MyClaims); ok && dataBase.KeyExists(claims.UserName, 0) {
+	if claims, ok := token.Claims.(*MyClaims); ok && sessions.HasToken(claims.UserName) {
+		if err := checkAccountStatus(sessions, users, claims.UserName); err != nil {
+			return "", err
+		}
 		return claims.UserName, nil
 	}
 	return "", errors.New("invalid token")
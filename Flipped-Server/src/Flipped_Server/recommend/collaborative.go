@@ -0,0 +1,236 @@
+// @Title  collaborative.go
+// @Description  To provide the item-item collaborative filtering learned from swipes, matches and replies
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package recommend
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"github.com/sirupsen/logrus"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 各类互动信号对"用户对目标用户的偏好"的贡献，累加后限制在-1到1之间
+const (
+	likeSignal  = 0.5  //喜欢
+	passSignal  = -0.5 //跳过
+	matchSignal = 0.25 //双方互相喜欢
+	replySignal = 0.25 //双方互相发送过消息
+)
+
+// CollaborativeModel是离线计算得到的物品-物品协同过滤模型，这里的"物品"即被推荐的用户
+type CollaborativeModel struct {
+	preferences map[string]map[string]float64 //用户 -> 目标用户 -> 偏好
+	neighbors   map[string]map[string]float64 //目标用户 -> 最相似的目标用户 -> 余弦相似度
+}
+
+// @title    BuildCollaborativeModel
+// @description   			根据滑动记录和消息记录计算每个用户的偏好，再计算目标用户之间的余弦相似度，每个目标用户只保留neighborCount个最相似的邻居
+// @auth      郑康       	2026.10.19
+// @param     []dataBase.Swipe, []dataBase.MessagePair, int	滑动记录, 发送过消息的组合, 邻居数量
+// @return    *CollaborativeModel	模型
+func BuildCollaborativeModel(swipes []dataBase.Swipe, pairs []dataBase.MessagePair, neighborCount int) *CollaborativeModel {
+	preferences := make(map[string]map[string]float64)
+	add := func(user string, target string, signal float64) {
+		if preferences[user] == nil {
+			preferences[user] = make(map[string]float64)
+		}
+		preferences[user][target] = math.Max(-1, math.Min(1, preferences[user][target]+signal))
+	}
+	liked := make(map[[2]string]bool)
+	for i := range swipes {
+		if swipes[i].Liked {
+			add(swipes[i].SourceUser, swipes[i].TargetUser, likeSignal)
+			liked[[2]string{swipes[i].SourceUser, swipes[i].TargetUser}] = true
+		} else {
+			add(swipes[i].SourceUser, swipes[i].TargetUser, passSignal)
+		}
+	}
+	for pair := range liked {
+		if liked[[2]string{pair[1], pair[0]}] {
+			add(pair[0], pair[1], matchSignal)
+		}
+	}
+	sent := make(map[dataBase.MessagePair]bool, len(pairs))
+	for i := range pairs {
+		sent[pairs[i]] = true
+	}
+	for pair := range sent {
+		if sent[dataBase.MessagePair{SourceUser: pair.TargetUser, TargetUser: pair.SourceUser}] {
+			add(pair.SourceUser, pair.TargetUser, replySignal)
+		}
+	}
+
+	//同一用户对两个目标用户的偏好相乘后累加得到两个目标用户向量的内积
+	dots := make(map[string]map[string]float64)
+	norms := make(map[string]float64)
+	for _, targets := range preferences {
+		for targetA, weightA := range targets {
+			norms[targetA] += weightA * weightA
+			for targetB, weightB := range targets {
+				if targetA == targetB {
+					continue
+				}
+				if dots[targetA] == nil {
+					dots[targetA] = make(map[string]float64)
+				}
+				dots[targetA][targetB] += weightA * weightB
+			}
+		}
+	}
+	neighbors := make(map[string]map[string]float64, len(dots))
+	for targetA, row := range dots {
+		type neighbor struct {
+			username   string
+			similarity float64
+		}
+		list := make([]neighbor, 0, len(row))
+		for targetB, dot := range row {
+			if dot != 0 {
+				list = append(list, neighbor{targetB, dot / math.Sqrt(norms[targetA]*norms[targetB])})
+			}
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if math.Abs(list[i].similarity) != math.Abs(list[j].similarity) {
+				return math.Abs(list[i].similarity) > math.Abs(list[j].similarity)
+			}
+			return list[i].username < list[j].username
+		})
+		if len(list) > neighborCount {
+			list = list[:neighborCount]
+		}
+		neighbors[targetA] = make(map[string]float64, len(list))
+		for _, item := range list {
+			neighbors[targetA][item.username] = item.similarity
+		}
+	}
+	return &CollaborativeModel{preferences: preferences, neighbors: neighbors}
+}
+
+// @title    Score
+// @description   			用用户对其他目标用户的偏好和这些目标用户与候选用户的相似度加权估计用户对候选用户的偏好，结果映射到0到1之间
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户名, 候选用户名
+// @return    float64, bool	估计的偏好, 是否有足够的互动数据
+func (model *CollaborativeModel) Score(username string, candidate string) (float64, bool) {
+	var weighted, total float64
+	for target, preference := range model.preferences[username] {
+		similarity, ok := model.neighbors[target][candidate]
+		if !ok {
+			continue
+		}
+		weighted += preference * similarity
+		total += math.Abs(similarity)
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return (weighted/total + 1) / 2, true
+}
+
+// CollaborativeFilter定期从存储中读取互动记录重新计算模型，计算期间继续使用旧模型
+type CollaborativeFilter struct {
+	lock   sync.RWMutex
+	model  *CollaborativeModel
+	stores *repository.Stores
+	config Config
+}
+
+func NewCollaborativeFilter(stores *repository.Stores, config Config) *CollaborativeFilter {
+	return &CollaborativeFilter{model: BuildCollaborativeModel(nil, nil, 0), stores: stores, config: config}
+}
+
+// @title    Refresh
+// @description   			读取互动窗口内的滑动记录和消息记录并重新计算模型
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    error			错误信息
+func (filter *CollaborativeFilter) Refresh() error {
+	var since int64
+	if filter.config.InteractionWindow > 0 {
+		since = time.Now().Add(-filter.config.InteractionWindow).Unix()
+	}
+	swipes, err := filter.stores.Swipes.SwipesSince(since)
+	if err != nil {
+		return err
+	}
+	pairs, err := filter.stores.Messages.MessagePairsSince(since)
+	if err != nil {
+		return err
+	}
+	model := BuildCollaborativeModel(swipes, pairs, filter.config.NeighborCount)
+	filter.lock.Lock()
+	filter.model = model
+	filter.lock.Unlock()
+	return nil
+}
+
+// @title    Run
+// @description   			立即计算一次模型，之后每隔CollaborativeRefresh重新计算，直到stop被关闭
+// @auth      郑康       	2026.10.19
+// @param     <-chan struct{}	停止信号
+// @return    void
+func (filter *CollaborativeFilter) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(filter.config.CollaborativeRefresh)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if err := filter.Refresh(); err != nil {
+			logger.SetToLogger(logrus.ErrorLevel, "CollaborativeFilter.Run", "refresh collaborative model", err.Error())
+		} else {
+			logger.SetToLogger(logrus.InfoLevel, "CollaborativeFilter.Run", "refresh collaborative model", "cost: "+time.Since(start).String())
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// @title    Score
+// @description   			使用当前模型估计用户对候选用户的偏好
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户名, 候选用户名
+// @return    float64, bool	估计的偏好, 是否有足够的互动数据
+func (filter *CollaborativeFilter) Score(username string, candidate string) (float64, bool) {
+	filter.lock.RLock()
+	model := filter.model
+	filter.lock.RUnlock()
+	return model.Score(username, candidate)
+}
+
+// 协同过滤得分在Breakdown中的名称
+const FeatureCollaborative = "collaborative"
+
+// BlendedScorer将资料相似度与协同过滤得分按Weight加权混合，缺少互动数据时只使用资料相似度
+type BlendedScorer struct {
+	Attribute     Scorer
+	Collaborative *CollaborativeFilter
+	Weight        float64 //协同过滤得分所占的比例，0到1之间
+}
+
+// @title    Score
+// @description   			计算混合后的得分，Breakdown中资料相似度各特征的贡献按比例缩小，并增加协同过滤的贡献
+// @auth      郑康       	2026.10.19
+// @param     *dataBase.UserInfoTable, *dataBase.UserInfoTable	当前用户, 候选用户
+// @return    float32, Breakdown	总分, 各特征的贡献
+func (scorer *BlendedScorer) Score(current *dataBase.UserInfoTable, candidate *dataBase.UserInfoTable) (float32, Breakdown) {
+	score, breakdown := scorer.Attribute.Score(current, candidate)
+	collaborative, ok := scorer.Collaborative.Score(current.Username, candidate.Username)
+	if !ok {
+		breakdown[FeatureCollaborative] = 0
+		return score, breakdown
+	}
+	ratio := float32(1 - scorer.Weight)
+	for feature := range breakdown {
+		breakdown[feature] *= ratio
+	}
+	breakdown[FeatureCollaborative] = float32(scorer.Weight * collaborative)
+	return score*ratio + breakdown[FeatureCollaborative], breakdown
+}
@@ -0,0 +1,65 @@
+package recommend
+
+import (
+	"Flipped_Server/dataBase"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollaborativeModel(t *testing.T) {
+	swipes := []dataBase.Swipe{
+		// A和B都喜欢X和Y，A跳过了Z而B喜欢Z
+		{SourceUser: "A", TargetUser: "X", Liked: true},
+		{SourceUser: "A", TargetUser: "Y", Liked: true},
+		{SourceUser: "B", TargetUser: "X", Liked: true},
+		{SourceUser: "B", TargetUser: "Y", Liked: true},
+		{SourceUser: "C", TargetUser: "X", Liked: true},
+		{SourceUser: "C", TargetUser: "Z", Liked: false},
+	}
+	model := BuildCollaborativeModel(swipes, nil, 10)
+	// C喜欢X, 而喜欢X的用户也喜欢Y, 估计的偏好与C对X的偏好(一次喜欢)相同
+	score, ok := model.Score("C", "Y")
+	assert.True(t, ok)
+	assert.InDelta(t, (likeSignal+1)/2, score, 1e-6)
+	// A喜欢X, 而C在喜欢X的同时跳过了Z
+	score, ok = model.Score("A", "Z")
+	assert.True(t, ok)
+	assert.True(t, score < 0.5)
+	_, ok = model.Score("Nobody", "Y")
+	assert.False(t, ok)
+}
+
+func TestCollaborativeSignals(t *testing.T) {
+	swipes := []dataBase.Swipe{
+		{SourceUser: "A", TargetUser: "B", Liked: true},
+		{SourceUser: "B", TargetUser: "A", Liked: true},
+		{SourceUser: "A", TargetUser: "C", Liked: true},
+	}
+	pairs := []dataBase.MessagePair{{SourceUser: "A", TargetUser: "B"}, {SourceUser: "B", TargetUser: "A"}, {SourceUser: "A", TargetUser: "C"}}
+	model := BuildCollaborativeModel(swipes, pairs, 10)
+	assert.InDelta(t, likeSignal+matchSignal+replySignal, model.preferences["A"]["B"], 1e-6)
+	assert.InDelta(t, likeSignal, model.preferences["A"]["C"], 1e-6)
+}
+
+func TestBlendedScorer(t *testing.T) {
+	recommender := newTestRecommender()
+	stores := recommender.Stores
+	current, _ := stores.Users.FindUserInfo("MrFirst", "")
+	candidate, _ := stores.Users.FindUserInfo("MrFourth", "")
+	attribute, _ := recommender.Scorer.(*BlendedScorer).Attribute.Score(current, candidate)
+	score, breakdown := recommender.Scorer.Score(current, candidate)
+	assert.Equal(t, attribute, score)
+	assert.Equal(t, float32(0), breakdown[FeatureCollaborative])
+
+	for _, user := range []string{"MrSecond", "MrThird"} {
+		_ = stores.Swipes.RecordSwipe(user, "MrFifth", true)
+		_ = stores.Swipes.RecordSwipe(user, "MrFourth", true)
+	}
+	_ = stores.Swipes.RecordSwipe("MrFirst", "MrFifth", true)
+	assert.NoError(t, recommender.Collaborative.Refresh())
+	score, breakdown = recommender.Scorer.Score(current, candidate)
+	weight := float32(recommender.Config.CollaborativeWeight)
+	collaborative := weight * (likeSignal + 1) / 2
+	assert.InDelta(t, collaborative, breakdown[FeatureCollaborative], 1e-6)
+	assert.InDelta(t, attribute*(1-weight)+collaborative, score, 1e-6)
+}
@@ -0,0 +1,133 @@
+// @Title  config.go
+// @Description  To provide the configuration of the recommendation to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package recommend
+
+import (
+	"Flipped_Server/initialSetting"
+	"time"
+)
+
+// Config描述推荐的批量大小、候选池大小、滑动记录的排除窗口、相似度的权重以及协同过滤的参数
+type Config struct {
+	DefaultBatchSize  int                //未指定数量时每批返回的推荐数
+	MaxBatchSize      int                //每批最多返回的推荐数
+	CandidatePoolSize int                //每次参与排序的候选用户数
+	PoolAgeRange      float64            //候选池只包含年龄相差不超过该值的用户(未填写年龄的用户除外)，不大于0时不限制
+	SwipeWindow       time.Duration      //在该时间内滑动过的用户不再推荐，不大于0时排除全部滑动过的用户
+	Weights           map[string]float64 //相似度各特征的权重
+	AgeRange          float64            //年龄相差达到该值时年龄特征的相似度为0
+	CursorSecret      string             //签名游标的密钥，多个服务器实例应配置相同的值，为空时使用随机密钥
+	TagWeight         float64            //兴趣标签相似度所占的比例，为0时不考虑兴趣标签
+
+	CollaborativeWeight  float64       //协同过滤得分所占的比例，为0时不使用协同过滤
+	CollaborativeRefresh time.Duration //后台重新计算协同过滤模型的间隔
+	InteractionWindow    time.Duration //只使用该时间内的互动记录计算模型，不大于0时使用全部记录
+	NeighborCount        int           //每个用户保留的最相似用户数
+
+	QueueSize     int           //预先计算的推荐队列长度
+	QueueLowWater int           //队列剩余数量低于该值时在后台补充
+	QueueSweep    time.Duration //后台检查在线用户推荐队列的间隔
+}
+
+// @title    DefaultConfig
+// @description   			获取配置文件中没有推荐配置时使用的默认配置
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    Config		默认配置
+func DefaultConfig() Config {
+	return Config{
+		DefaultBatchSize:  10,
+		MaxBatchSize:      50,
+		CandidatePoolSize: 500,
+		PoolAgeRange:      20,
+		SwipeWindow:       30 * 24 * time.Hour,
+		Weights:           DefaultWeights(),
+		AgeRange:          10,
+		TagWeight:         0.4,
+
+		CollaborativeWeight:  0.3,
+		CollaborativeRefresh: 30 * time.Minute,
+		InteractionWindow:    90 * 24 * time.Hour,
+		NeighborCount:        50,
+
+		QueueSize:     50,
+		QueueLowWater: 10,
+		QueueSweep:    5 * time.Minute,
+	}
+}
+
+// @title    LoadConfig
+// @description   			读取配置文件中的recommendation项，缺少的字段使用默认值
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    Config		推荐配置
+func LoadConfig() Config {
+	config := DefaultConfig()
+	settings := initialSetting.RecommendConfig
+	if value, ok := settings["defaultBatchSize"].(float64); ok && value > 0 {
+		config.DefaultBatchSize = int(value)
+	}
+	if value, ok := settings["maxBatchSize"].(float64); ok && value > 0 {
+		config.MaxBatchSize = int(value)
+	}
+	if value, ok := settings["candidatePoolSize"].(float64); ok && value > 0 {
+		config.CandidatePoolSize = int(value)
+	}
+	if value, ok := settings["cursorSecret"].(string); ok {
+		config.CursorSecret = value
+	}
+	if value, ok := settings["poolAgeRange"].(float64); ok && value >= 0 {
+		config.PoolAgeRange = value
+	}
+	if value, ok := settings["swipeWindowHours"].(float64); ok {
+		config.SwipeWindow = time.Duration(value * float64(time.Hour))
+	}
+	if value, ok := settings["ageRange"].(float64); ok && value > 0 {
+		config.AgeRange = value
+	}
+	if value, ok := settings["tagWeight"].(float64); ok && value >= 0 && value <= 1 {
+		config.TagWeight = value
+	}
+	//只覆盖配置文件中出现的特征权重，权重为0表示不考虑该特征
+	if weights, ok := settings["weights"].(map[string]interface{}); ok {
+		for feature, weight := range weights {
+			if value, ok := weight.(float64); ok && value >= 0 {
+				config.Weights[feature] = value
+			}
+		}
+	}
+	if collaborative, ok := settings["collaborative"].(map[string]interface{}); ok {
+		if value, ok := collaborative["weight"].(float64); ok && value >= 0 && value <= 1 {
+			config.CollaborativeWeight = value
+		}
+		if value, ok := collaborative["refreshMinutes"].(float64); ok && value > 0 {
+			config.CollaborativeRefresh = time.Duration(value * float64(time.Minute))
+		}
+		if value, ok := collaborative["windowDays"].(float64); ok {
+			config.InteractionWindow = time.Duration(value * float64(24*time.Hour))
+		}
+		if value, ok := collaborative["neighborCount"].(float64); ok && value > 0 {
+			config.NeighborCount = int(value)
+		}
+	}
+	if queue, ok := settings["queue"].(map[string]interface{}); ok {
+		if value, ok := queue["size"].(float64); ok && value > 0 {
+			config.QueueSize = int(value)
+		}
+		if value, ok := queue["lowWater"].(float64); ok && value >= 0 {
+			config.QueueLowWater = int(value)
+		}
+		if value, ok := queue["sweepMinutes"].(float64); ok && value > 0 {
+			config.QueueSweep = time.Duration(value * float64(time.Minute))
+		}
+	}
+	if config.QueueLowWater >= config.QueueSize {
+		config.QueueLowWater = config.QueueSize / 2
+	}
+	if config.DefaultBatchSize > config.MaxBatchSize {
+		config.DefaultBatchSize = config.MaxBatchSize
+	}
+	return config
+}
@@ -0,0 +1,204 @@
+// @Title  queue.go
+// @Description  To precompute the recommendation queue of each active user and refill it in the background
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package recommend
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// 推荐队列中没有可推荐的用户时返回的错误
+var ErrNoCandidate = errors.New("there is no user to recommend")
+
+// 等待后台补充的请求数上限，超过时丢弃新的请求
+const queueRequestBuffer = 256
+
+// QueueWorker为用户预先计算推荐队列，队列不足时在后台补充，用户资料变化时使队列失效
+type QueueWorker struct {
+	recommender *Recommender
+	requests    chan string
+	lock        sync.Mutex
+	pending     map[string]bool //已在等待补充的用户，避免重复计算
+}
+
+// @title    NewQueueWorker
+// @description   			创建推荐队列的后台任务
+// @auth      郑康       	2026.10.19
+// @param     *Recommender	推荐器
+// @return    *QueueWorker	推荐队列的后台任务
+func NewQueueWorker(recommender *Recommender) *QueueWorker {
+	return &QueueWorker{
+		recommender: recommender,
+		requests:    make(chan string, queueRequestBuffer),
+		pending:     make(map[string]bool),
+	}
+}
+
+// @title    Refill
+// @description   			按得分重新计算用户的推荐队列并替换原有队列
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    error			错误信息
+func (worker *QueueWorker) Refill(username string) error {
+	recommender := worker.recommender
+	candidates, _, err := recommender.Recommend(username, &Query{Count: recommender.Config.QueueSize, SortBy: SortByScore})
+	if err != nil {
+		return err
+	}
+	usernames := make([]string, len(candidates))
+	for i := range candidates {
+		usernames[i] = candidates[i].User.Username
+	}
+	return recommender.Stores.Queues.Replace(username, usernames)
+}
+
+// @title    Request
+// @description   			请求在后台补充用户的推荐队列，不会阻塞调用者
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    void
+func (worker *QueueWorker) Request(username string) {
+	worker.lock.Lock()
+	defer worker.lock.Unlock()
+	if worker.pending[username] {
+		return
+	}
+	select {
+	case worker.requests <- username:
+		worker.pending[username] = true
+	default:
+		logger.SetToLogger(logrus.WarnLevel, "QueueWorker.Request", "request to refill the queue of "+username, "too many pending requests")
+	}
+}
+
+// @title    Invalidate
+// @description   			用户资料或位置变化时清空其推荐队列，并请求在后台重新计算
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    error			错误信息
+func (worker *QueueWorker) Invalidate(username string) error {
+	if err := worker.recommender.Stores.Queues.Invalidate(username); err != nil {
+		return err
+	}
+	worker.Request(username)
+	return nil
+}
+
+// @title    Next
+// @description   			从推荐队列中取出下一个仍然可以推荐的用户，队列为空时同步计算一次，剩余数量不足时请求后台补充
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    *dataBase.UserInfoTable, error	推荐用户, 错误信息
+func (worker *QueueWorker) Next(username string) (*dataBase.UserInfoTable, error) {
+	stores := worker.recommender.Stores
+	//全部排除条件已在计算队列时过滤, 这里只读取好友列表并检查取出的用户
+	friendList, err := stores.Friends.GetFriendList(username)
+	if err != nil {
+		return nil, err
+	}
+	refilled := false
+	for {
+		candidate, ok, err := stores.Queues.Pop(username)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if refilled {
+				return nil, ErrNoCandidate
+			}
+			if err := worker.Refill(username); err != nil {
+				return nil, err
+			}
+			refilled = true
+			continue
+		}
+		if utils.Contains(friendList, candidate) {
+			continue
+		}
+		if recommendable, err := worker.stillRecommendable(username, candidate); err != nil {
+			return nil, err
+		} else if !recommendable {
+			continue
+		}
+		user, err := stores.Users.FindUserInfo(candidate, "")
+		if err != nil {
+			continue
+		}
+		if length, err := stores.Queues.Length(username); err == nil && length < worker.recommender.Config.QueueLowWater {
+			worker.Request(username)
+		}
+		return user, nil
+	}
+}
+
+// @title    stillRecommendable
+// @description   			检查队列计算之后是否发生了使候选用户不能再被推荐的变化：滑动、拉黑或配对
+// @auth      郑康       	2026.10.19
+// @param     string, string	用户名, 从队列中取出的候选用户
+// @return    bool, error	是否仍然可以推荐, 错误信息
+func (worker *QueueWorker) stillRecommendable(username string, candidate string) (bool, error) {
+	stores := worker.recommender.Stores
+	if swipe, ok := stores.Swipes.GetSwipe(username, candidate); ok {
+		window := worker.recommender.Config.SwipeWindow
+		if window <= 0 || swipe.CreatedAt >= worker.recommender.now().Add(-window).Unix() {
+			return false, nil
+		}
+	}
+	blocked, err := repository.IsBlockedBetween(stores.Blocks, username, candidate)
+	if err != nil || blocked {
+		return false, err
+	}
+	return !stores.Matches.IsMatched(username, candidate), nil
+}
+
+// @title    sweep
+// @description   			检查全部在线用户，为推荐队列不足的用户请求补充
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    void
+func (worker *QueueWorker) sweep() {
+	stores := worker.recommender.Stores
+	onlineUsers, err := stores.Presence.OnlineUsers()
+	if err != nil {
+		logger.SetToLogger(logrus.ErrorLevel, "QueueWorker.sweep", "list online users", err.Error())
+		return
+	}
+	for _, username := range onlineUsers {
+		if length, err := stores.Queues.Length(username); err == nil && length < worker.recommender.Config.QueueLowWater {
+			worker.Request(username)
+		}
+	}
+}
+
+// @title    Run
+// @description   			处理补充推荐队列的请求，并定期检查在线用户的推荐队列，直到stop被关闭
+// @auth      郑康       	2026.10.19
+// @param     <-chan struct{}	停止信号
+// @return    void
+func (worker *QueueWorker) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(worker.recommender.Config.QueueSweep)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			worker.sweep()
+		case username := <-worker.requests:
+			worker.lock.Lock()
+			delete(worker.pending, username)
+			worker.lock.Unlock()
+			if err := worker.Refill(username); err != nil {
+				logger.SetToLogger(logrus.ErrorLevel, "QueueWorker.Run", "refill the queue of "+username, err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package recommend
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestQueueWorkerNext(t *testing.T) {
+	recommender := newTestRecommender()
+	worker := recommender.Queue
+	user, err := worker.Next("MrFirst")
+	assert.NoError(t, err)
+	assert.Equal(t, "MrFifth", user.Username)
+	length, _ := recommender.Stores.Queues.Length("MrFirst")
+	assert.Equal(t, 3, length)
+
+	//队列计算之后发生的拉黑和滑动同样生效
+	_ = recommender.Stores.Blocks.Block("MrSecond", "MrFirst")
+	_ = recommender.Stores.Swipes.RecordSwipe("MrFirst", "MrThird", true)
+	user, err = worker.Next("MrFirst")
+	assert.NoError(t, err)
+	assert.Equal(t, "MrFourth", user.Username)
+
+	//队列计算之后成为好友的用户同样不再推荐
+	assert.NoError(t, worker.Refill("MrFirst"))
+	_ = recommender.Stores.Friends.AddMutualFriends("MrFirst", "MrFifth")
+	user, err = worker.Next("MrFirst")
+	assert.NoError(t, err)
+	assert.Equal(t, "MrFourth", user.Username)
+
+	_ = recommender.Stores.Swipes.RecordSwipe("MrFirst", "MrFourth", false)
+	_ = recommender.Stores.Swipes.RecordSwipe("MrFirst", "MrFifth", false)
+	_, err = worker.Next("MrFirst")
+	assert.Equal(t, ErrNoCandidate, err)
+}
+
+func TestQueueWorkerInvalidate(t *testing.T) {
+	recommender := newTestRecommender()
+	worker := recommender.Queue
+	assert.NoError(t, worker.Refill("MrFirst"))
+	assert.NoError(t, worker.Invalidate("MrFirst"))
+	length, _ := recommender.Stores.Queues.Length("MrFirst")
+	assert.Equal(t, 0, length)
+
+	//重复请求只会排队一次
+	worker.Request("MrFirst")
+	assert.Len(t, worker.requests, 1)
+}
@@ -0,0 +1,335 @@
+// @Title  recommender.go
+// @Description  To provide ranked batches of recommended users with a cursor to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package recommend
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/repository"
+	"Flipped_Server/utils"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// 游标无法解析时返回的错误
+	ErrInvalidCursor = errors.New("cursor is invalid")
+	// 按距离筛选或排序但用户没有上报过位置时返回的错误
+	ErrNoLocation = errors.New("location is unknown, please report your location first")
+)
+
+// 推荐的排序方式
+const (
+	SortByScore    = "score"
+	SortByDistance = "distance"
+)
+
+// 无法计算距离(任一方没有上报位置)时Candidate.Distance的取值
+const UnknownDistance = -1.0
+
+// Query描述一次推荐请求
+type Query struct {
+	Cursor      string  //上一批返回的游标，第一批为空
+	Count       int     //本批数量
+	MaxDistance float64 //最大距离(千米)，不大于0时不限制距离
+	SortBy      string  //排序方式，SortByScore或SortByDistance
+}
+
+// Candidate是一个推荐结果及其相似度得分、各特征的贡献以及与当前用户的距离
+type Candidate struct {
+	User      *dataBase.UserInfoTable
+	Score     float32
+	Breakdown Breakdown
+	Distance  float64 //与当前用户的距离(千米)，未知时为UnknownDistance
+}
+
+// Recommender使用Scorer计算候选用户的得分，为用户生成推荐列表
+type Recommender struct {
+	Stores        *repository.Stores
+	Config        Config
+	Scorer        Scorer
+	Collaborative *CollaborativeFilter //未启用协同过滤时为nil
+	Queue         *QueueWorker
+	now           func() time.Time
+	cursorKey     []byte //签名游标的密钥
+}
+
+// @title    NewRecommender
+// @description   			创建推荐器，使用按配置加权的WeightedScorer，启用协同过滤时再与协同过滤得分混合
+// @auth      郑康       	2026.10.19
+// @param     *repository.Stores, Config	存储集合, 推荐配置
+// @return    *Recommender	推荐器
+func NewRecommender(stores *repository.Stores, config Config) *Recommender {
+	recommender := &Recommender{Stores: stores, Config: config, Scorer: NewWeightedScorer(config.Weights, config.AgeRange), now: time.Now}
+	recommender.cursorKey = []byte(config.CursorSecret)
+	if len(recommender.cursorKey) == 0 {
+		//未配置密钥时使用随机密钥, 重启后之前的游标失效
+		recommender.cursorKey = make([]byte, 32)
+		_, _ = rand.Read(recommender.cursorKey)
+	}
+	if config.CollaborativeWeight > 0 {
+		recommender.Collaborative = NewCollaborativeFilter(stores, config)
+		recommender.Scorer = &BlendedScorer{Attribute: recommender.Scorer, Collaborative: recommender.Collaborative, Weight: config.CollaborativeWeight}
+	}
+	recommender.Queue = NewQueueWorker(recommender)
+	return recommender
+}
+
+// @title    RunBackgroundJobs
+// @description   			启动推荐相关的后台任务(定期计算协同过滤模型、补充推荐队列)，直到stop被关闭
+// @auth      郑康       	2026.10.19
+// @param     <-chan struct{}	停止信号
+// @return    void
+func (recommender *Recommender) RunBackgroundJobs(stop <-chan struct{}) {
+	if recommender.Collaborative != nil {
+		go recommender.Collaborative.Run(stop)
+	}
+	go recommender.Queue.Run(stop)
+}
+
+// @title    ExcludedUsers
+// @description   			获取不应推荐给用户的全部用户：好友、存在拉黑关系的用户、已解除配对的用户以及窗口期内滑动过的用户
+// @auth      郑康       	2026.10.19
+// @param     string		用户名
+// @return    []string, error	用户列表(包含用户本身), 错误信息
+func (recommender *Recommender) ExcludedUsers(username string) ([]string, error) {
+	stores := recommender.Stores
+	friendList, err := stores.Friends.GetFriendList(username)
+	if err != nil {
+		return nil, err
+	}
+	blockedUsers, err := repository.BlockedUsersOf(stores.Blocks, username)
+	if err != nil {
+		return nil, err
+	}
+	unmatchedUsers, err := stores.Matches.ListUnmatched(username)
+	if err != nil {
+		return nil, err
+	}
+	var since int64
+	if recommender.Config.SwipeWindow > 0 {
+		since = recommender.now().Add(-recommender.Config.SwipeWindow).Unix()
+	}
+	swipedUsers, err := stores.Swipes.SwipedUsers(username, since)
+	if err != nil {
+		return nil, err
+	}
+	excluded := []string{username}
+	for _, list := range [][]string{friendList, blockedUsers, unmatchedUsers, swipedUsers} {
+		excluded = append(excluded, list...)
+	}
+	return excluded, nil
+}
+
+// @title    Recommend
+// @description   			按查询条件排序并返回游标之后的Count个推荐用户，以及下一批的游标(没有更多时为空)，游标只对生成它的用户有效
+// 第一批为候选池生成新的抽样种子并写入游标，同一游标链的各批从同一个候选池中选择
+// @auth      郑康       	2026.10.19
+// @param     string, *Query	用户名, 查询条件
+// @return    []Candidate, string, error	推荐用户, 下一批的游标, 错误信息
+func (recommender *Recommender) Recommend(username string, query *Query) ([]Candidate, string, error) {
+	after, err := recommender.decodeCursor(username, query.Cursor, query.SortBy)
+	if err != nil {
+		return nil, "", err
+	}
+	currentUser, err := recommender.Stores.Users.FindUserInfo(username, "")
+	if err != nil {
+		return nil, "", err
+	}
+	currentLocation, located := repository.LocationOf(recommender.Stores.Locations, username)
+	if !located && (query.MaxDistance > 0 || query.SortBy == SortByDistance) {
+		return nil, "", ErrNoLocation
+	}
+	excluded, err := recommender.ExcludedUsers(username)
+	if err != nil {
+		return nil, "", err
+	}
+	seed := strconv.FormatInt(recommender.now().UnixNano(), 36)
+	if after != nil {
+		seed = after.seed
+	}
+	pool, err := recommender.candidatePool(currentUser, currentLocation, query.MaxDistance, excluded, seed)
+	if err != nil {
+		return nil, "", err
+	}
+	users, err := recommender.Stores.Users.FindCandidates(pool)
+	if err != nil {
+		return nil, "", err
+	}
+	usernames := make([]string, len(users))
+	for i := range users {
+		usernames[i] = users[i].Username
+	}
+	locations, err := recommender.Stores.Locations.GetLocations(usernames)
+	if err != nil {
+		return nil, "", err
+	}
+	scorer, err := recommender.scorerFor(username, usernames)
+	if err != nil {
+		return nil, "", err
+	}
+	candidates := make([]Candidate, 0, len(users))
+	for i := range users {
+		candidate := Candidate{User: users[i], Distance: UnknownDistance}
+		if location, ok := locations[users[i].Username]; ok && located {
+			candidate.Distance = utils.DistanceKm(currentLocation.Latitude, currentLocation.Longitude, location.Latitude, location.Longitude)
+		}
+		if query.MaxDistance > 0 && (candidate.Distance == UnknownDistance || candidate.Distance > query.MaxDistance) {
+			continue
+		}
+		candidate.Score, candidate.Breakdown = scorer.Score(currentUser, users[i])
+		if after == nil || after.before(&candidate, query.SortBy) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return rankBefore(&candidates[i], &candidates[j], query.SortBy)
+	})
+	if len(candidates) <= query.Count {
+		return candidates, "", nil
+	}
+	candidates = candidates[:query.Count]
+	return candidates, recommender.encodeCursor(username, seed, &candidates[query.Count-1], query.SortBy), nil
+}
+
+// @title    candidatePool
+// @description   			生成候选池的条件：排除不应推荐的用户, 配置了PoolAgeRange时只包含年龄相近的用户, 限制距离时只包含位于距离范围外接矩形内的用户, 按种子在满足条件的用户中抽样
+// @auth      郑康       	2026.10.19
+// @param     *dataBase.UserInfoTable, *dataBase.Location, float64, []string, string	当前用户, 当前用户的位置(未上报时为nil), 最大距离, 不应推荐的用户, 抽样的种子
+// @return    *dataBase.CandidateQuery, error	候选池条件, 错误信息
+func (recommender *Recommender) candidatePool(currentUser *dataBase.UserInfoTable, location *dataBase.Location, maxDistance float64, excluded []string, seed string) (*dataBase.CandidateQuery, error) {
+	pool := &dataBase.CandidateQuery{Excluded: excluded, Seed: seed, Limit: recommender.Config.CandidatePoolSize}
+	if ageRange := int(recommender.Config.PoolAgeRange); ageRange > 0 && currentUser.Age > 0 {
+		pool.MinAge, pool.MaxAge = currentUser.Age-ageRange, currentUser.Age+ageRange
+	}
+	if maxDistance > 0 && location != nil {
+		minLatitude, maxLatitude, minLongitude, maxLongitude := utils.BoundingBox(location.Latitude, location.Longitude, maxDistance)
+		included, err := recommender.Stores.Locations.UsersWithin(minLatitude, maxLatitude, minLongitude, maxLongitude)
+		if err != nil {
+			return nil, err
+		}
+		pool.Included = included
+	}
+	return pool, nil
+}
+
+// @title    scorerFor
+// @description   			获取本次推荐使用的Scorer：启用兴趣标签时读取当前用户和候选用户的标签，与推荐器的Scorer混合
+// @auth      郑康       	2026.10.19
+// @param     string, []string	用户名, 候选用户名列表
+// @return    Scorer, error	本次推荐使用的Scorer, 错误信息
+func (recommender *Recommender) scorerFor(username string, candidates []string) (Scorer, error) {
+	if recommender.Config.TagWeight <= 0 {
+		return recommender.Scorer, nil
+	}
+	tags, err := recommender.Stores.Tags.UserTags(append([]string{username}, candidates...))
+	if err != nil {
+		return nil, err
+	}
+	return &TagScorer{Base: recommender.Scorer, Tags: tags, Weight: recommender.Config.TagWeight}, nil
+}
+
+// @title    rankKey
+// @description   			获取推荐用户的排序键，键越小越靠前：按得分排序时为得分的相反数，按距离排序时为展示给用户的近似距离(位置未知的排在最后)，排序键会写入游标，因此不使用精确距离
+// @auth      郑康       	2026.10.19
+// @param     *Candidate, string	推荐用户, 排序方式
+// @return    float64		排序键
+func rankKey(candidate *Candidate, sortBy string) float64 {
+	if sortBy == SortByDistance {
+		if candidate.Distance == UnknownDistance {
+			return math.Inf(1)
+		}
+		return float64(utils.ApproximateKm(candidate.Distance))
+	}
+	return -float64(candidate.Score)
+}
+
+// @title    rankBefore
+// @description   			推荐的排列顺序：排序键小的在前，排序键相同时按用户名字典序
+// @auth      郑康       	2026.10.19
+// @param     *Candidate, *Candidate, string	候选用户A, 候选用户B, 排序方式
+// @return    bool			A是否排在B之前
+func rankBefore(a *Candidate, b *Candidate, sortBy string) bool {
+	keyA, keyB := rankKey(a, sortBy), rankKey(b, sortBy)
+	if keyA != keyB {
+		return keyA < keyB
+	}
+	return a.User.Username < b.User.Username
+}
+
+// cursorPosition记录候选池的抽样种子以及上一批最后一个推荐用户的排序键和用户名
+type cursorPosition struct {
+	seed     string
+	key      float64
+	username string
+}
+
+func (position *cursorPosition) before(candidate *Candidate, sortBy string) bool {
+	key := rankKey(candidate, sortBy)
+	if position.key != key {
+		return position.key < key
+	}
+	return position.username < candidate.User.Username
+}
+
+// @title    encodeCursor
+// @description   			将排序方式、抽样种子、推荐用户的排序键和用户名编码为游标，并使用HMAC-SHA256对请求的用户和游标内容签名
+// @auth      郑康       	2026.10.19
+// @param     string, string, *Candidate, string	请求推荐的用户名, 抽样种子, 推荐用户, 排序方式
+// @return    string		游标
+func (recommender *Recommender) encodeCursor(username string, seed string, candidate *Candidate, sortBy string) string {
+	raw := sortBy + "|" + seed + "|" + strconv.FormatFloat(rankKey(candidate, sortBy), 'g', -1, 64) + "|" + candidate.User.Username
+	return base64.RawURLEncoding.EncodeToString([]byte(raw)) + "." + base64.RawURLEncoding.EncodeToString(recommender.signCursor(username, raw))
+}
+
+// @title    signCursor
+// @description   			计算游标内容的签名，签名中包含请求的用户名，其他用户无法使用该游标
+// @auth      郑康       	2026.10.19
+// @param     string, string	请求推荐的用户名, 游标内容
+// @return    []byte		签名
+func (recommender *Recommender) signCursor(username string, raw string) []byte {
+	mac := hmac.New(sha256.New, recommender.cursorKey)
+	mac.Write([]byte(username + "\n" + raw))
+	return mac.Sum(nil)
+}
+
+// @title    decodeCursor
+// @description   			校验签名并解析游标，游标为空时返回nil，签名错误或游标与排序方式不一致时视为非法
+// @auth      郑康       	2026.10.19
+// @param     string, string, string	请求推荐的用户名, 游标, 排序方式
+// @return    *cursorPosition, error	游标位置, 错误信息
+func (recommender *Recommender) decodeCursor(username string, cursor string, sortBy string) (*cursorPosition, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	fields := strings.SplitN(cursor, ".", 2)
+	if len(fields) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(fields[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(fields[1])
+	if err != nil || !hmac.Equal(signature, recommender.signCursor(username, string(raw))) {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 4)
+	if len(parts) != 4 || parts[0] != sortBy {
+		return nil, ErrInvalidCursor
+	}
+	key, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursorPosition{seed: parts[1], key: key, username: parts[3]}, nil
+}
@@ -0,0 +1,141 @@
+package recommend
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/repository"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRecommender() *Recommender {
+	stores := repository.NewMemoryStores()
+	for _, user := range []*dataBase.UserInfoTable{
+		{Username: "MrFirst", Email: "MrFirst@qq.com", Age: 20, Region: "Wuhan", Profession: "student", Hobby: "reading"},
+		{Username: "MrSecond", Email: "MrSecond@qq.com", Age: 21, Region: "Wuhan", Profession: "student", Hobby: "reading"},
+		{Username: "MrThird", Email: "MrThird@qq.com", Age: 22, Region: "Wuhan", Profession: "teacher", Hobby: "reading"},
+		{Username: "MrFourth", Email: "MrFourth@163.com", Age: 40, Region: "Beijing", Profession: "doctor", Hobby: "running"},
+		{Username: "MrFifth", Email: "MrFifth@qq.com", Age: 20, Region: "Wuhan", Profession: "student", Hobby: "reading"},
+	} {
+		_ = stores.Users.InsertUser(user)
+		_ = stores.Friends.InitFriendList(user.Username)
+	}
+	return NewRecommender(stores, DefaultConfig())
+}
+
+func usernamesOf(candidates []Candidate) []string {
+	res := make([]string, len(candidates))
+	for i := range candidates {
+		res[i] = candidates[i].User.Username
+	}
+	return res
+}
+
+func TestRecommendWithCursor(t *testing.T) {
+	recommender := newTestRecommender()
+	all, cursor, err := recommender.Recommend("MrFirst", &Query{Count: 10, SortBy: SortByScore})
+	assert.NoError(t, err)
+	assert.Equal(t, "", cursor)
+	assert.Equal(t, []string{"MrFifth", "MrSecond", "MrThird", "MrFourth"}, usernamesOf(all))
+
+	first, cursor, _ := recommender.Recommend("MrFirst", &Query{Count: 3, SortBy: SortByScore})
+	assert.Equal(t, usernamesOf(all[:3]), usernamesOf(first))
+	assert.NotEqual(t, "", cursor)
+	second, cursor, _ := recommender.Recommend("MrFirst", &Query{Cursor: cursor, Count: 3, SortBy: SortByScore})
+	assert.Equal(t, []string{"MrFourth"}, usernamesOf(second))
+	assert.Equal(t, "", cursor)
+
+	_, _, err = recommender.Recommend("MrFirst", &Query{Cursor: "not a cursor", Count: 3, SortBy: SortByScore})
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestRecommendSamplesCandidatePool(t *testing.T) {
+	recommender := newTestRecommender()
+	recommender.Config.CandidatePoolSize = 2
+	recommender.Config.PoolAgeRange = 0
+	//不同的种子从全部用户中抽取不同的候选池, 而不是总是同样的几个用户
+	sampled := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		seed := time.Unix(0, int64(i))
+		recommender.now = func() time.Time { return seed }
+		candidates, _, err := recommender.Recommend("MrFirst", &Query{Count: 10, SortBy: SortByScore})
+		assert.NoError(t, err)
+		assert.Len(t, candidates, 2)
+		for _, name := range usernamesOf(candidates) {
+			sampled[name] = true
+		}
+	}
+	assert.Len(t, sampled, 4)
+
+	//同一游标链的各批使用同一个候选池
+	pool, _, _ := recommender.Recommend("MrFirst", &Query{Count: 10, SortBy: SortByScore})
+	first, cursor, _ := recommender.Recommend("MrFirst", &Query{Count: 1, SortBy: SortByScore})
+	recommender.now = time.Now
+	second, _, _ := recommender.Recommend("MrFirst", &Query{Cursor: cursor, Count: 1, SortBy: SortByScore})
+	assert.Equal(t, usernamesOf(pool), append(usernamesOf(first), usernamesOf(second)...))
+}
+
+func TestRecommendExcludesSeenUsers(t *testing.T) {
+	recommender := newTestRecommender()
+	stores := recommender.Stores
+	_ = stores.Friends.AddMutualFriends("MrFirst", "MrSecond")
+	_ = stores.Blocks.Block("MrThird", "MrFirst")
+	_ = stores.Swipes.RecordSwipe("MrFirst", "MrFifth", false)
+	candidates, _, err := recommender.Recommend("MrFirst", &Query{Count: 10, SortBy: SortByScore})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MrFourth"}, usernamesOf(candidates))
+
+	recommender.Config.SwipeWindow = time.Hour
+	recommender.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	candidates, _, _ = recommender.Recommend("MrFirst", &Query{Count: 10, SortBy: SortByScore})
+	assert.Equal(t, []string{"MrFifth", "MrFourth"}, usernamesOf(candidates))
+}
+
+func TestRecommendCandidatePoolByAge(t *testing.T) {
+	recommender := newTestRecommender()
+	recommender.Config.PoolAgeRange = 5
+	_ = recommender.Stores.Users.InsertUser(&dataBase.UserInfoTable{Username: "MrSixth", Email: "MrSixth@qq.com", Region: "Beijing"})
+	candidates, _, err := recommender.Recommend("MrFirst", &Query{Count: 10, SortBy: SortByScore})
+	assert.NoError(t, err)
+	//年龄相差超过5岁的MrFourth不在候选池中, 未填写年龄的MrSixth不受限制
+	assert.ElementsMatch(t, []string{"MrFifth", "MrSecond", "MrThird", "MrSixth"}, usernamesOf(candidates))
+}
+
+func TestRecommendByDistance(t *testing.T) {
+	recommender := newTestRecommender()
+	locations := recommender.Stores.Locations
+	query := &Query{Count: 10, MaxDistance: 100, SortBy: SortByDistance}
+	_, _, err := recommender.Recommend("MrFirst", query)
+	assert.Equal(t, ErrNoLocation, err)
+
+	_ = locations.UpdateLocation("MrFirst", 30.59, 114.31)
+	_ = locations.UpdateLocation("MrSecond", 30.52, 114.36)
+	_ = locations.UpdateLocation("MrThird", 30.59, 114.30)
+	_ = locations.UpdateLocation("MrFourth", 39.90, 116.41)
+	candidates, cursor, err := recommender.Recommend("MrFirst", query)
+	assert.NoError(t, err)
+	assert.Equal(t, "", cursor)
+	assert.Equal(t, []string{"MrThird", "MrSecond"}, usernamesOf(candidates))
+	assert.True(t, candidates[0].Distance < candidates[1].Distance)
+
+	query = &Query{Count: 2, SortBy: SortByDistance}
+	recommender.now = func() time.Time { return time.Unix(0, 36) }
+	first, cursor, _ := recommender.Recommend("MrFirst", query)
+	assert.Equal(t, []string{"MrThird", "MrSecond"}, usernamesOf(first))
+	_, _, err = recommender.Recommend("MrFirst", &Query{Cursor: cursor, Count: 2, SortBy: SortByScore})
+	assert.Equal(t, ErrInvalidCursor, err)
+	//游标只包含抽样种子和近似距离, 且不能被其他用户使用或篡改
+	payload, _ := base64.RawURLEncoding.DecodeString(strings.SplitN(cursor, ".", 2)[0])
+	assert.Equal(t, "distance|10|10|MrSecond", string(payload))
+	_, _, err = recommender.Recommend("MrSecond", &Query{Cursor: cursor, Count: 2, SortBy: SortByDistance})
+	assert.Equal(t, ErrInvalidCursor, err)
+	forged := base64.RawURLEncoding.EncodeToString([]byte("distance|10|0|MrSecond")) + cursor[strings.Index(cursor, "."):]
+	_, _, err = recommender.Recommend("MrFirst", &Query{Cursor: forged, Count: 2, SortBy: SortByDistance})
+	assert.Equal(t, ErrInvalidCursor, err)
+	query.Cursor = cursor
+	second, _, _ := recommender.Recommend("MrFirst", query)
+	assert.Equal(t, []string{"MrFourth", "MrFifth"}, usernamesOf(second))
+	assert.Equal(t, UnknownDistance, second[1].Distance)
+}
@@ -0,0 +1,205 @@
+// @Title  scorer.go
+// @Description  To provide the pluggable similarity scoring between users to the recommendation
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package recommend
+
+import (
+	"Flipped_Server/dataBase"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 参与相似度计算的特征名称，同时也是配置文件recommendation.weights中的键
+const (
+	FeatureUserType    = "userType"
+	FeatureEmailDomain = "emailDomain"
+	FeatureAge         = "age"
+	FeatureRegion      = "region"
+	FeatureProfession  = "profession"
+	FeatureHobby       = "hobby"
+	FeatureTags        = "tags"
+)
+
+// Breakdown记录每个特征对总分的贡献(已乘以权重并归一化)，全部贡献之和等于总分
+type Breakdown map[string]float32
+
+// Scorer计算候选用户与当前用户的相似度，得分越高越优先推荐
+type Scorer interface {
+	Score(current *dataBase.UserInfoTable, candidate *dataBase.UserInfoTable) (float32, Breakdown)
+}
+
+// WeightedScorer对每个特征计算0到1之间的相似度，再按权重加权平均，总分也在0到1之间
+type WeightedScorer struct {
+	Weights  map[string]float64
+	AgeRange float64 //年龄相差达到该值时年龄特征的相似度为0
+}
+
+// @title    DefaultWeights
+// @description   			获取配置文件中没有设置权重时使用的默认权重
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    map[string]float64	特征名称与权重
+func DefaultWeights() map[string]float64 {
+	return map[string]float64{
+		FeatureUserType:    1,
+		FeatureEmailDomain: 0.5,
+		FeatureAge:         1.5,
+		FeatureRegion:      2,
+		FeatureProfession:  1,
+		FeatureHobby:       2,
+	}
+}
+
+func NewWeightedScorer(weights map[string]float64, ageRange float64) *WeightedScorer {
+	return &WeightedScorer{Weights: weights, AgeRange: ageRange}
+}
+
+// @title    Score
+// @description   			计算两个用户的加权相似度及各特征的贡献
+// @auth      郑康       	2026.10.19
+// @param     *dataBase.UserInfoTable, *dataBase.UserInfoTable	当前用户, 候选用户
+// @return    float32, Breakdown	总分, 各特征的贡献
+func (scorer *WeightedScorer) Score(current *dataBase.UserInfoTable, candidate *dataBase.UserInfoTable) (float32, Breakdown) {
+	features := map[string]float64{
+		FeatureUserType:    boolScore(current.UserType == candidate.UserType),
+		FeatureEmailDomain: sameText(EmailDomain(current.Email), EmailDomain(candidate.Email)),
+		FeatureAge:         scorer.ageScore(current.Age, candidate.Age),
+		FeatureRegion:      sameText(current.Region, candidate.Region),
+		FeatureProfession:  sameText(current.Profession, candidate.Profession),
+		FeatureHobby:       Jaccard(TokenizeHobby(current.Hobby), TokenizeHobby(candidate.Hobby)),
+	}
+	//按特征名称的固定顺序以float64累加, 保证相同的输入总是得到完全相同的得分
+	names := make([]string, 0, len(features))
+	for feature := range features {
+		names = append(names, feature)
+	}
+	sort.Strings(names)
+	var totalWeight float64
+	for _, feature := range names {
+		if weight := scorer.Weights[feature]; weight > 0 {
+			totalWeight += weight
+		}
+	}
+	breakdown := make(Breakdown, len(features))
+	var total float64
+	for _, feature := range names {
+		weight := scorer.Weights[feature]
+		if weight <= 0 || totalWeight == 0 {
+			breakdown[feature] = 0
+			continue
+		}
+		contribution := weight * features[feature] / totalWeight
+		breakdown[feature] = float32(contribution)
+		total += contribution
+	}
+	return float32(total), breakdown
+}
+
+// @title    ageScore
+// @description   			年龄越接近相似度越高，相差AgeRange岁及以上时为0，年龄未填写时为0
+// @auth      郑康       	2026.10.19
+// @param     int, int		年龄A, 年龄B
+// @return    float64		相似度
+func (scorer *WeightedScorer) ageScore(ageA int, ageB int) float64 {
+	if ageA <= 0 || ageB <= 0 || scorer.AgeRange <= 0 {
+		return 0
+	}
+	return math.Max(0, 1-math.Abs(float64(ageA-ageB))/scorer.AgeRange)
+}
+
+// @title    EmailDomain
+// @description   			获取邮箱@之后的域名(小写)，邮箱不合法时返回空字符串
+// @auth      郑康       	2026.10.19
+// @param     string		邮箱
+// @return    string		域名
+func EmailDomain(email string) string {
+	index := strings.LastIndex(email, "@")
+	if index < 0 || index == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(email[index+1:])
+}
+
+// @title    TokenizeHobby
+// @description   			将爱好按中英文逗号、分号、顿号、斜杠和空白拆分为去重后的小写词
+// @auth      郑康       	2026.10.19
+// @param     string		爱好
+// @return    map[string]bool	词集合
+func TokenizeHobby(hobby string) map[string]bool {
+	tokens := strings.FieldsFunc(strings.ToLower(hobby), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",，;；、/|", r)
+	})
+	res := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		res[token] = true
+	}
+	return res
+}
+
+// @title    Jaccard
+// @description   			计算两个词集合的Jaccard相似度(交集大小/并集大小)，任一集合为空时为0
+// @auth      郑康       	2026.10.19
+// @param     map[string]bool, map[string]bool	词集合A, 词集合B
+// @return    float64		相似度
+func Jaccard(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for token := range a {
+		if b[token] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+func sameText(a string, b string) float64 {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	return boolScore(a != "" && strings.EqualFold(a, b))
+}
+
+func boolScore(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// TagScorer将兴趣标签的Jaccard相似度按Weight与已有得分混合，任一方没有标签时只使用已有得分
+type TagScorer struct {
+	Base   Scorer
+	Tags   map[string][]string //用户名与标签名，只需包含当前用户和本次参与排序的候选用户
+	Weight float64             //标签相似度所占的比例，0到1之间
+}
+
+// @title    Score
+// @description   			计算混合后的得分，Breakdown中已有特征的贡献按比例缩小，并增加标签的贡献
+// @auth      郑康       	2026.10.19
+// @param     *dataBase.UserInfoTable, *dataBase.UserInfoTable	当前用户, 候选用户
+// @return    float32, Breakdown	总分, 各特征的贡献
+func (scorer *TagScorer) Score(current *dataBase.UserInfoTable, candidate *dataBase.UserInfoTable) (float32, Breakdown) {
+	score, breakdown := scorer.Base.Score(current, candidate)
+	currentTags, candidateTags := scorer.Tags[current.Username], scorer.Tags[candidate.Username]
+	if len(currentTags) == 0 || len(candidateTags) == 0 {
+		breakdown[FeatureTags] = 0
+		return score, breakdown
+	}
+	ratio := float32(1 - scorer.Weight)
+	for feature := range breakdown {
+		breakdown[feature] *= ratio
+	}
+	breakdown[FeatureTags] = float32(scorer.Weight * Jaccard(tagSet(currentTags), tagSet(candidateTags)))
+	return score*ratio + breakdown[FeatureTags], breakdown
+}
+
+func tagSet(tags []string) map[string]bool {
+	res := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		res[tag] = true
+	}
+	return res
+}
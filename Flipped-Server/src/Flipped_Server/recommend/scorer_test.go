@@ -0,0 +1,74 @@
+package recommend
+
+import (
+	"Flipped_Server/dataBase"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTokenizeHobbyAndJaccard(t *testing.T) {
+	assert.Equal(t, map[string]bool{"reading": true, "hiking": true, "篮球": true}, TokenizeHobby(" Reading, hiking；篮球、reading"))
+	assert.Empty(t, TokenizeHobby(""))
+	assert.Equal(t, 0.5, Jaccard(TokenizeHobby("reading,hiking"), TokenizeHobby("hiking reading swimming running")))
+	assert.Equal(t, float64(0), Jaccard(TokenizeHobby(""), TokenizeHobby("reading")))
+}
+
+func TestEmailDomain(t *testing.T) {
+	assert.Equal(t, "qq.com", EmailDomain("MrFirst@QQ.com"))
+	assert.Equal(t, "", EmailDomain("no-at-sign"))
+	assert.Equal(t, "", EmailDomain("trailing@"))
+}
+
+func TestWeightedScorer(t *testing.T) {
+	current := &dataBase.UserInfoTable{Email: "a@qq.com", Age: 20, Region: "Wuhan", Profession: "student", Hobby: "reading,hiking"}
+	scorer := NewWeightedScorer(DefaultWeights(), 10)
+
+	score, breakdown := scorer.Score(current, current)
+	assert.InDelta(t, 1, score, 1e-6)
+	var sum float32
+	for _, value := range breakdown {
+		sum += value
+	}
+	assert.InDelta(t, score, sum, 1e-6)
+
+	score, breakdown = scorer.Score(current, &dataBase.UserInfoTable{Email: "broken", UserType: 1, Age: 25, Hobby: "hiking"})
+	assert.Equal(t, float32(0), breakdown[FeatureEmailDomain])
+	assert.Equal(t, float32(0), breakdown[FeatureRegion])
+	assert.InDelta(t, 0.5*1.5/8, breakdown[FeatureAge], 1e-6)
+	assert.InDelta(t, 0.5*2.0/8, breakdown[FeatureHobby], 1e-6)
+	assert.InDelta(t, (0.5*1.5+0.5*2)/8, score, 1e-6)
+
+	//得分与map的遍历顺序无关, 多次计算完全相同
+	candidate := &dataBase.UserInfoTable{Email: "b@qq.com", Age: 23, Region: "Wuhan", Hobby: "reading"}
+	expected, _ := scorer.Score(current, candidate)
+	for i := 0; i < 100; i++ {
+		score, _ = scorer.Score(current, candidate)
+		assert.Equal(t, expected, score)
+	}
+
+	onlyHobby := NewWeightedScorer(map[string]float64{FeatureHobby: 1}, 10)
+	score, _ = onlyHobby.Score(current, &dataBase.UserInfoTable{Hobby: "Reading"})
+	assert.InDelta(t, 0.5, score, 1e-6)
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	config := LoadConfig()
+	assert.Equal(t, DefaultConfig().MaxBatchSize, config.MaxBatchSize)
+	assert.Equal(t, DefaultWeights(), config.Weights)
+}
+
+func TestTagScorer(t *testing.T) {
+	current := &dataBase.UserInfoTable{Username: "MrFirst"}
+	candidate := &dataBase.UserInfoTable{Username: "MrSecond"}
+	base := NewWeightedScorer(map[string]float64{FeatureUserType: 1}, 10)
+	scorer := &TagScorer{Base: base, Weight: 0.5, Tags: map[string][]string{"MrFirst": {"music", "reading"}}}
+	score, breakdown := scorer.Score(current, candidate)
+	assert.Equal(t, float32(1), score)
+	assert.Equal(t, float32(0), breakdown[FeatureTags])
+
+	scorer.Tags["MrSecond"] = []string{"reading", "running", "music"}
+	score, breakdown = scorer.Score(current, candidate)
+	assert.InDelta(t, 0.5+0.5*2.0/3, score, 1e-6)
+	assert.InDelta(t, 0.5*2.0/3, breakdown[FeatureTags], 1e-6)
+	assert.InDelta(t, 0.5, breakdown[FeatureUserType], 1e-6)
+}
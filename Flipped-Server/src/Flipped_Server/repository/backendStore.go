@@ -0,0 +1,413 @@
+// @Title  backendStore.go
+// @Description  The implementations of the storage interfaces based on mysql, MongoDB and Redis
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package repository
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/sqlmapper"
+	"strconv"
+	"time"
+)
+
+// MysqlUserStore将用户信息存放于mysql的userinfo表
+type MysqlUserStore struct{}
+
+func (store *MysqlUserStore) FindUserInfo(username string, pwd string) (*dataBase.UserInfoTable, error) {
+	return dataBase.FindUserInfo(username, pwd)
+}
+
+func (store *MysqlUserStore) FindUsersByNames(usernames []string) ([]*dataBase.UserInfoTable, error) {
+	return dataBase.FindUsersByNames(usernames)
+}
+
+func (store *MysqlUserStore) DoesUserExist(username string) bool {
+	return dataBase.DoesUserExist(username)
+}
+
+func (store *MysqlUserStore) InsertUser(user *dataBase.UserInfoTable) error {
+	return sqlmapper.Insert(*user, "userinfo")
+}
+
+func (store *MysqlUserStore) UpdateUser(username string, fields map[string]string) error {
+	return sqlmapper.Update(&map[string]string{"username": username}, &fields, "userinfo")
+}
+
+func (store *MysqlUserStore) SampleUsers(limit int) ([]*dataBase.UserInfoTable, error) {
+	return dataBase.ExecSelectSQL("Select * From im.userinfo Order By Rand() Limit " + strconv.Itoa(limit))
+}
+
+func (store *MysqlUserStore) SearchUsers(query *dataBase.UserQuery) ([]*dataBase.UserInfoTable, error) {
+	return dataBase.SearchUsers(query)
+}
+
+func (store *MysqlUserStore) FindCandidates(query *dataBase.CandidateQuery) ([]*dataBase.UserInfoTable, error) {
+	return dataBase.FindCandidates(query)
+}
+
+func (store *MysqlUserStore) AccountStatus(username string) (*dataBase.AccountStatus, error) {
+	return dataBase.FindAccountStatus(username)
+}
+
+func (store *MysqlUserStore) SetAccountStatus(status *dataBase.AccountStatus, change *dataBase.AccountStatusChange) error {
+	return dataBase.UpdateAccountStatus(status, change)
+}
+
+func (store *MysqlUserStore) AccountStatusChanges(username string) ([]dataBase.AccountStatusChange, error) {
+	return dataBase.FindAccountStatusChanges(username)
+}
+
+// MongoFriendStore将好友列表存放于MongoDB的friendMap集合
+type MongoFriendStore struct{}
+
+func (store *MongoFriendStore) GetFriendList(username string) ([]string, error) {
+	return dataBase.GetFriendListByUserName(username)
+}
+
+func (store *MongoFriendStore) InitFriendList(username string) error {
+	return dataBase.InitUserFriendList(username)
+}
+
+func (store *MongoFriendStore) AddFriend(sourceUser string, targetUser string) error {
+	return dataBase.AddFriend(sourceUser, targetUser)
+}
+
+func (store *MongoFriendStore) AddMutualFriends(userA string, userB string) error {
+	return dataBase.AddMutualFriends(userA, userB)
+}
+
+func (store *MongoFriendStore) DeleteFriend(sourceUser string, targetUser string) error {
+	return dataBase.DeleteFriend(sourceUser, targetUser)
+}
+
+// MongoFriendRequestStore将好友请求存放于MongoDB的friendRequest集合
+type MongoFriendRequestStore struct{}
+
+func (store *MongoFriendRequestStore) SendRequest(sourceUser string, targetUser string) error {
+	return dataBase.InsertFriendRequest(&dataBase.FriendRequest{SourceUser: sourceUser, TargetUser: targetUser, CreatedAt: time.Now().Unix()})
+}
+
+func (store *MongoFriendRequestStore) HasRequest(sourceUser string, targetUser string) bool {
+	return dataBase.FriendRequestExists(sourceUser, targetUser)
+}
+
+func (store *MongoFriendRequestStore) ListIncoming(username string) ([]dataBase.FriendRequest, error) {
+	return dataBase.FindFriendRequests("targetUser", username)
+}
+
+func (store *MongoFriendRequestStore) ListOutgoing(username string) ([]dataBase.FriendRequest, error) {
+	return dataBase.FindFriendRequests("sourceUser", username)
+}
+
+func (store *MongoFriendRequestStore) RemoveRequest(sourceUser string, targetUser string) error {
+	return dataBase.RemoveFriendRequest(sourceUser, targetUser)
+}
+
+// MongoBlockStore将黑名单存放于MongoDB的blockList集合
+type MongoBlockStore struct{}
+
+func (store *MongoBlockStore) Block(sourceUser string, targetUser string) error {
+	return dataBase.BlockUser(sourceUser, targetUser)
+}
+
+func (store *MongoBlockStore) Unblock(sourceUser string, targetUser string) error {
+	return dataBase.UnblockUser(sourceUser, targetUser)
+}
+
+func (store *MongoBlockStore) GetBlockList(username string) ([]string, error) {
+	return dataBase.GetBlockList(username)
+}
+
+func (store *MongoBlockStore) GetBlockedBy(username string) ([]string, error) {
+	return dataBase.GetBlockedBy(username)
+}
+
+func (store *MongoBlockStore) IsBlocked(sourceUser string, targetUser string) (bool, error) {
+	return dataBase.IsUserBlocked(sourceUser, targetUser)
+}
+
+// MongoSwipeStore将滑动记录存放于MongoDB的swipe集合
+type MongoSwipeStore struct{}
+
+func (store *MongoSwipeStore) RecordSwipe(sourceUser string, targetUser string, liked bool) error {
+	return dataBase.UpsertSwipe(&dataBase.Swipe{SourceUser: sourceUser, TargetUser: targetUser, Liked: liked, CreatedAt: time.Now().Unix()})
+}
+
+func (store *MongoSwipeStore) GetSwipe(sourceUser string, targetUser string) (*dataBase.Swipe, bool) {
+	swipe, err := dataBase.FindSwipe(sourceUser, targetUser)
+	return swipe, err == nil
+}
+
+func (store *MongoSwipeStore) SwipedUsers(sourceUser string, since int64) ([]string, error) {
+	return dataBase.FindSwipedUsers(sourceUser, since)
+}
+
+func (store *MongoSwipeStore) SwipesSince(since int64) ([]dataBase.Swipe, error) {
+	return dataBase.FindSwipesSince(since)
+}
+
+// MongoMatchStore将配对记录存放于MongoDB的match集合
+type MongoMatchStore struct{}
+
+func (store *MongoMatchStore) CreateMatch(userA string, userB string) error {
+	return dataBase.InsertMatch(dataBase.NewMatch(userA, userB, time.Now().Unix()))
+}
+
+func (store *MongoMatchStore) IsMatched(userA string, userB string) bool {
+	return dataBase.MatchExists(userA, userB)
+}
+
+func (store *MongoMatchStore) ListMatches(username string) ([]dataBase.Match, error) {
+	return dataBase.FindMatches(username, false)
+}
+
+func (store *MongoMatchStore) Unmatch(userA string, userB string) error {
+	return dataBase.SetUnmatched(userA, userB, time.Now().Unix())
+}
+
+func (store *MongoMatchStore) ListUnmatched(username string) ([]string, error) {
+	matches, err := dataBase.FindMatches(username, true)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, len(matches))
+	for i := range matches {
+		res[i] = matches[i].Partner(username)
+	}
+	return res, nil
+}
+
+// MongoMessageStore将聊天记录存放于MongoDB的messageMap集合
+type MongoMessageStore struct{}
+
+func (store *MongoMessageStore) SaveMessage(sourceUser string, targetUser string, content string) error {
+	return dataBase.InsertMessage(&dataBase.Message{SourceUser: sourceUser, TargetUser: targetUser, Content: content, CreatedAt: time.Now().Unix()})
+}
+
+func (store *MongoMessageStore) ListMessages(userA string, userB string, limit int) ([]dataBase.Message, error) {
+	return dataBase.FindMessages(userA, userB, limit)
+}
+
+func (store *MongoMessageStore) LastMessages(username string, partners []string) (map[string]dataBase.Message, error) {
+	return dataBase.FindLastMessages(username, partners)
+}
+
+func (store *MongoMessageStore) MessagesSentAt(sourceUser string, targetUser string, createdAt int64) ([]dataBase.Message, error) {
+	return dataBase.FindMessagesSentAt(sourceUser, targetUser, createdAt)
+}
+
+func (store *MongoMessageStore) MessagePairsSince(since int64) ([]dataBase.MessagePair, error) {
+	return dataBase.FindMessagePairs(since)
+}
+
+// MongoLocationStore将用户位置存放于MongoDB的location集合
+type MongoLocationStore struct{}
+
+func (store *MongoLocationStore) UpdateLocation(username string, latitude float64, longitude float64) error {
+	return dataBase.UpsertLocation(&dataBase.Location{Username: username, Latitude: latitude, Longitude: longitude, UpdatedAt: time.Now().Unix()})
+}
+
+func (store *MongoLocationStore) GetLocations(usernames []string) (map[string]dataBase.Location, error) {
+	locations, err := dataBase.FindLocations(usernames)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]dataBase.Location, len(locations))
+	for i := range locations {
+		res[locations[i].Username] = locations[i]
+	}
+	return res, nil
+}
+
+func (store *MongoLocationStore) UsersWithin(minLatitude float64, maxLatitude float64, minLongitude float64, maxLongitude float64) ([]string, error) {
+	return dataBase.FindUsersWithin(minLatitude, maxLatitude, minLongitude, maxLongitude)
+}
+
+// RedisSessionStore将用户名与token的键值对存放于Redis db0
+type RedisSessionStore struct{}
+
+func (store *RedisSessionStore) SaveToken(username string, token string) error {
+	return dataBase.WriteToRedis(username, token)
+}
+
+func (store *RedisSessionStore) ReadToken(username string) (string, error) {
+	return dataBase.ReadFromRedis(username)
+}
+
+func (store *RedisSessionStore) HasToken(username string) bool {
+	return dataBase.KeyExists(username, 0)
+}
+
+func (store *RedisSessionStore) DeleteToken(username string) error {
+	return dataBase.DeleteKey(username, 0)
+}
+
+func (store *RedisSessionStore) SaveAccountStatus(status *dataBase.AccountStatus) error {
+	return dataBase.CacheAccountStatus(status)
+}
+
+func (store *RedisSessionStore) ReadAccountStatus(username string) (*dataBase.AccountStatus, error) {
+	return dataBase.ReadCachedAccountStatus(username)
+}
+
+// RedisPresenceStore将在线用户存放于Redis db2, 键的过期时间即心跳超时时间
+type RedisPresenceStore struct{}
+
+func NewRedisPresenceStore() *RedisPresenceStore {
+	return &RedisPresenceStore{}
+}
+
+func (store *RedisPresenceStore) UpdateStatus(username string, heartbeat bool) (bool, error) {
+	return dataBase.UpdateUserStatus(username, heartbeat)
+}
+
+func (store *RedisPresenceStore) HasHeartbeat(username string) bool {
+	return dataBase.UserHasHeartbeat(username)
+}
+
+func (store *RedisPresenceStore) SetOffline(username string) error {
+	return dataBase.DeleteUserStatus(username)
+}
+
+func (store *RedisPresenceStore) IsOnline(username string) bool {
+	return dataBase.KeyExists(username, 2)
+}
+
+func (store *RedisPresenceStore) CountOnline() (int, error) {
+	return dataBase.CountOnlineUsers()
+}
+
+func (store *RedisPresenceStore) OnlineUsers() ([]string, error) {
+	return dataBase.ListOnlineUsers()
+}
+
+// RedisRecommendQueueStore将推荐队列以列表的形式存放于Redis db3
+type RedisRecommendQueueStore struct{}
+
+func (store *RedisRecommendQueueStore) Replace(username string, candidates []string) error {
+	return dataBase.ReplaceRecommendQueue(username, candidates)
+}
+
+func (store *RedisRecommendQueueStore) Pop(username string) (string, bool, error) {
+	return dataBase.PopRecommendQueue(username)
+}
+
+func (store *RedisRecommendQueueStore) Length(username string) (int, error) {
+	return dataBase.RecommendQueueLength(username)
+}
+
+func (store *RedisRecommendQueueStore) Invalidate(username string) error {
+	return dataBase.DeleteRecommendQueue(username)
+}
+
+// MysqlTagStore将兴趣标签目录和用户标签保存在mysql的tag、user_tag表中
+type MysqlTagStore struct{}
+
+func (store *MysqlTagStore) SearchTags(prefix string, limit int) ([]dataBase.Tag, error) {
+	return dataBase.SearchTags(prefix, limit)
+}
+
+func (store *MysqlTagStore) FindTags(names []string) ([]dataBase.Tag, error) {
+	return dataBase.FindTagsByNames(names)
+}
+
+func (store *MysqlTagStore) SetUserTags(username string, tagIDs []int) error {
+	return dataBase.ReplaceUserTags(username, tagIDs)
+}
+
+func (store *MysqlTagStore) UserTags(usernames []string) (map[string][]string, error) {
+	return dataBase.FindUserTags(usernames)
+}
+
+// MysqlPhotoStore将相册照片的元数据保存在mysql的user_photo表中
+type MysqlPhotoStore struct{}
+
+func (store *MysqlPhotoStore) AddPhoto(photo *dataBase.Photo, limit int) error {
+	return dataBase.InsertPhoto(photo, limit)
+}
+
+func (store *MysqlPhotoStore) ListPhotos(usernames []string) (map[string][]dataBase.Photo, error) {
+	return dataBase.FindPhotos(usernames)
+}
+
+func (store *MysqlPhotoStore) DeletePhoto(username string, photoID string) error {
+	return dataBase.DeletePhoto(username, photoID)
+}
+
+func (store *MysqlPhotoStore) Reorder(username string, photoIDs []string) error {
+	return dataBase.UpdatePhotoPositions(username, photoIDs)
+}
+
+func (store *MysqlPhotoStore) SetPrimary(username string, photoID string) error {
+	return dataBase.SetPrimaryPhoto(username, photoID)
+}
+
+func (store *MysqlPhotoStore) References() ([]string, error) {
+	return dataBase.FindAllPhotoReferences()
+}
+
+// MysqlImageStore将图片及Blob的引用计数保存在mysql的image、image_variant和image_blob表中
+type MysqlImageStore struct{}
+
+func (store *MysqlImageStore) AddImage(image *dataBase.Image) error {
+	return dataBase.InsertImage(image)
+}
+
+func (store *MysqlImageStore) FindImages(imageIDs []string) (map[string]dataBase.Image, error) {
+	return dataBase.FindImages(imageIDs)
+}
+
+func (store *MysqlImageStore) ReleaseImage(imageID string) error {
+	return dataBase.DeleteImage(imageID)
+}
+
+func (store *MysqlImageStore) ListImages() ([]dataBase.Image, error) {
+	return dataBase.FindAllImages()
+}
+
+func (store *MysqlImageStore) ListBlobs() ([]dataBase.Blob, error) {
+	return dataBase.FindAllBlobs()
+}
+
+func (store *MysqlImageStore) AddReferences(references []dataBase.ImageReference) error {
+	return dataBase.InsertImageReferences(references)
+}
+
+func (store *MysqlImageStore) ReferencedNames() ([]string, error) {
+	return dataBase.FindReferencedImageNames()
+}
+
+// MysqlModerationStore将被标记的内容保存在mysql的flagged_content表中
+type MysqlModerationStore struct{}
+
+func (store *MysqlModerationStore) AddFlagged(item *dataBase.FlaggedContent) error {
+	return dataBase.InsertFlaggedContent(item)
+}
+
+func (store *MysqlModerationStore) ListFlagged(limit int) ([]dataBase.FlaggedContent, error) {
+	return dataBase.FindFlaggedContents(limit)
+}
+
+// MysqlReportStore将举报及其审计记录保存在mysql的report和report_action表中
+type MysqlReportStore struct{}
+
+func (store *MysqlReportStore) AddReport(report *dataBase.Report, action *dataBase.ReportAction) error {
+	return dataBase.InsertReport(report, action)
+}
+
+func (store *MysqlReportStore) ListReports(status string, limit int) ([]dataBase.Report, error) {
+	return dataBase.FindReports(status, limit)
+}
+
+func (store *MysqlReportStore) FindReport(reportID string) (*dataBase.Report, error) {
+	return dataBase.FindReport(reportID)
+}
+
+func (store *MysqlReportStore) UpdateReport(report *dataBase.Report, from string, action *dataBase.ReportAction) error {
+	return dataBase.UpdateReport(report, from, action)
+}
+
+func (store *MysqlReportStore) ListActions(reportID string) ([]dataBase.ReportAction, error) {
+	return dataBase.FindReportActions(reportID)
+}
@@ -0,0 +1,1223 @@
+// @Title  memoryStore.go
+// @Description  The in-memory implementations of the storage interfaces, used by tests and local debugging
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package repository
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/utils"
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 内存在线状态的过期时间，与Redis db2中心跳键的过期时间一致
+const memoryHeartBeatTimeout = 60 * time.Second
+
+// MemoryUserStore以用户名为键在内存中保存用户信息，按兴趣标签搜索时使用tags中的对应关系(为nil时没有用户拥有标签)
+type MemoryUserStore struct {
+	lock     sync.RWMutex
+	users    map[string]*dataBase.UserInfoTable
+	statuses map[string]dataBase.AccountStatus
+	changes  []dataBase.AccountStatusChange
+	tags     *MemoryTagStore
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[string]*dataBase.UserInfoTable), statuses: make(map[string]dataBase.AccountStatus)}
+}
+
+func (store *MemoryUserStore) FindUserInfo(username string, pwd string) (*dataBase.UserInfoTable, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	user, ok := store.users[username]
+	if !ok || (pwd != "" && user.Password != pwd) {
+		return nil, errors.New("the data you select is nil or has repetitive")
+	}
+	res := *user
+	return &res, nil
+}
+
+func (store *MemoryUserStore) FindUsersByNames(usernames []string) ([]*dataBase.UserInfoTable, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []*dataBase.UserInfoTable{}
+	for _, username := range usernames {
+		if user, ok := store.users[username]; ok {
+			copied := *user
+			res = append(res, &copied)
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryUserStore) DoesUserExist(username string) bool {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	_, ok := store.users[username]
+	return ok
+}
+
+func (store *MemoryUserStore) InsertUser(user *dataBase.UserInfoTable) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if _, ok := store.users[user.Username]; ok {
+		return errors.New("duplicate username: " + user.Username)
+	}
+	res := *user
+	store.users[user.Username] = &res
+	return nil
+}
+
+func (store *MemoryUserStore) UpdateUser(username string, fields map[string]string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	user, ok := store.users[username]
+	if !ok {
+		return errors.New("user doesn't exist: " + username)
+	}
+	updated := *user
+	if err := updated.ApplyFields(fields); err != nil {
+		return err
+	}
+	store.users[username] = &updated
+	return nil
+}
+
+func (store *MemoryUserStore) SampleUsers(limit int) ([]*dataBase.UserInfoTable, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	all := make([]*dataBase.UserInfoTable, 0, len(store.users))
+	for _, user := range store.users {
+		res := *user
+		all = append(all, &res)
+	}
+	rand.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+	if len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+func (store *MemoryUserStore) SearchUsers(query *dataBase.UserQuery) ([]*dataBase.UserInfoTable, error) {
+	var withTags []string
+	if len(query.Tags) > 0 && store.tags != nil {
+		withTags, _ = store.tags.UsersWithTags(query.Tags)
+	}
+	now := time.Now().Unix()
+	store.lock.RLock()
+	res := []*dataBase.UserInfoTable{}
+	for _, user := range store.users {
+		if query.Matches(user) && (len(query.Tags) == 0 || utils.Contains(withTags, user.Username)) && !store.isRestricted(user.Username, now) {
+			copied := *user
+			res = append(res, &copied)
+		}
+	}
+	store.lock.RUnlock()
+	query.SortByRelevance(res)
+	if query.Offset >= len(res) {
+		return []*dataBase.UserInfoTable{}, nil
+	}
+	res = res[query.Offset:]
+	if len(res) > query.Limit {
+		res = res[:query.Limit]
+	}
+	return res, nil
+}
+
+func (store *MemoryUserStore) FindCandidates(query *dataBase.CandidateQuery) ([]*dataBase.UserInfoTable, error) {
+	now := time.Now().Unix()
+	store.lock.RLock()
+	res := []*dataBase.UserInfoTable{}
+	for _, user := range store.users {
+		if query.Matches(user) && !store.isRestricted(user.Username, now) {
+			copied := *user
+			res = append(res, &copied)
+		}
+	}
+	store.lock.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		keyI, keyJ := query.SampleKey(res[i].Username), query.SampleKey(res[j].Username)
+		if keyI != keyJ {
+			return keyI < keyJ
+		}
+		return res[i].Username < res[j].Username
+	})
+	if len(res) > query.Limit {
+		res = res[:query.Limit]
+	}
+	return res, nil
+}
+
+// isRestricted判断账号是否处于停用状态，调用者需持有读锁
+func (store *MemoryUserStore) isRestricted(username string, now int64) bool {
+	status, ok := store.statuses[username]
+	return ok && status.IsRestricted(now)
+}
+
+func (store *MemoryUserStore) AccountStatus(username string) (*dataBase.AccountStatus, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	if _, ok := store.users[username]; !ok {
+		return nil, errors.New("user doesn't exist: " + username)
+	}
+	status, ok := store.statuses[username]
+	if !ok {
+		status = dataBase.AccountStatus{Username: username, Status: dataBase.AccountActive}
+	}
+	return &status, nil
+}
+
+func (store *MemoryUserStore) SetAccountStatus(status *dataBase.AccountStatus, change *dataBase.AccountStatusChange) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if _, ok := store.users[status.Username]; !ok {
+		return errors.New("user doesn't exist: " + status.Username)
+	}
+	change.From = dataBase.AccountActive
+	if previous, ok := store.statuses[status.Username]; ok {
+		change.From = previous.Status
+	}
+	change.Username, change.Status, change.Until, change.Reason = status.Username, status.Status, status.Until, status.Reason
+	store.statuses[status.Username] = *status
+	store.changes = append(store.changes, *change)
+	return nil
+}
+
+func (store *MemoryUserStore) AccountStatusChanges(username string) ([]dataBase.AccountStatusChange, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.AccountStatusChange{}
+	for i := range store.changes {
+		if store.changes[i].Username == username {
+			res = append(res, store.changes[i])
+		}
+	}
+	return res, nil
+}
+
+// MemoryFriendStore在内存中保存每个用户的好友列表
+type MemoryFriendStore struct {
+	lock    sync.RWMutex
+	users   UserStore
+	friends map[string][]string
+}
+
+func NewMemoryFriendStore(users UserStore) *MemoryFriendStore {
+	return &MemoryFriendStore{users: users, friends: make(map[string][]string)}
+}
+
+func (store *MemoryFriendStore) GetFriendList(username string) ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	friendList, ok := store.friends[username]
+	if !ok {
+		return []string{}, errors.New("not found")
+	}
+	return append([]string{}, friendList...), nil
+}
+
+func (store *MemoryFriendStore) InitFriendList(username string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.friends[username] = []string{}
+	return nil
+}
+
+func (store *MemoryFriendStore) AddFriend(sourceUser string, targetUser string) error {
+	if !store.users.DoesUserExist(targetUser) {
+		return errors.New("target friend doesn't exist")
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if utils.Contains(store.friends[sourceUser], targetUser) || targetUser == sourceUser {
+		return errors.New("can't add a friend that already is your friend or add yourself friend")
+	}
+	store.friends[sourceUser] = append(store.friends[sourceUser], targetUser)
+	return nil
+}
+
+func (store *MemoryFriendStore) AddMutualFriends(userA string, userB string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if !utils.Contains(store.friends[userA], userB) {
+		store.friends[userA] = append(store.friends[userA], userB)
+	}
+	if !utils.Contains(store.friends[userB], userA) {
+		store.friends[userB] = append(store.friends[userB], userA)
+	}
+	return nil
+}
+
+func (store *MemoryFriendStore) DeleteFriend(sourceUser string, targetUser string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	friendList := store.friends[sourceUser]
+	for i := range friendList {
+		if friendList[i] == targetUser {
+			store.friends[sourceUser] = append(friendList[:i:i], friendList[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// MemoryFriendRequestStore在内存中按发出顺序保存好友请求
+type MemoryFriendRequestStore struct {
+	lock     sync.RWMutex
+	requests []dataBase.FriendRequest
+}
+
+func NewMemoryFriendRequestStore() *MemoryFriendRequestStore {
+	return &MemoryFriendRequestStore{}
+}
+
+func (store *MemoryFriendRequestStore) SendRequest(sourceUser string, targetUser string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for i := range store.requests {
+		if store.requests[i].SourceUser == sourceUser && store.requests[i].TargetUser == targetUser {
+			return dataBase.ErrFriendRequestExists
+		}
+	}
+	store.requests = append(store.requests, dataBase.FriendRequest{SourceUser: sourceUser, TargetUser: targetUser, CreatedAt: time.Now().Unix()})
+	return nil
+}
+
+func (store *MemoryFriendRequestStore) HasRequest(sourceUser string, targetUser string) bool {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	for i := range store.requests {
+		if store.requests[i].SourceUser == sourceUser && store.requests[i].TargetUser == targetUser {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *MemoryFriendRequestStore) ListIncoming(username string) ([]dataBase.FriendRequest, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.FriendRequest{}
+	for i := range store.requests {
+		if store.requests[i].TargetUser == username {
+			res = append(res, store.requests[i])
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryFriendRequestStore) ListOutgoing(username string) ([]dataBase.FriendRequest, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.FriendRequest{}
+	for i := range store.requests {
+		if store.requests[i].SourceUser == username {
+			res = append(res, store.requests[i])
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryFriendRequestStore) RemoveRequest(sourceUser string, targetUser string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for i := range store.requests {
+		if store.requests[i].SourceUser == sourceUser && store.requests[i].TargetUser == targetUser {
+			store.requests = append(store.requests[:i:i], store.requests[i+1:]...)
+			return nil
+		}
+	}
+	return dataBase.ErrFriendRequestNotFound
+}
+
+// MemoryBlockStore在内存中保存每个用户的黑名单
+type MemoryBlockStore struct {
+	lock    sync.RWMutex
+	blocked map[string][]string
+}
+
+func NewMemoryBlockStore() *MemoryBlockStore {
+	return &MemoryBlockStore{blocked: make(map[string][]string)}
+}
+
+func (store *MemoryBlockStore) Block(sourceUser string, targetUser string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if !utils.Contains(store.blocked[sourceUser], targetUser) {
+		store.blocked[sourceUser] = append(store.blocked[sourceUser], targetUser)
+	}
+	return nil
+}
+
+func (store *MemoryBlockStore) Unblock(sourceUser string, targetUser string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	blockList := store.blocked[sourceUser]
+	for i := range blockList {
+		if blockList[i] == targetUser {
+			store.blocked[sourceUser] = append(blockList[:i:i], blockList[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (store *MemoryBlockStore) GetBlockList(username string) ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	return append([]string{}, store.blocked[username]...), nil
+}
+
+func (store *MemoryBlockStore) GetBlockedBy(username string) ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	for sourceUser, blockList := range store.blocked {
+		if utils.Contains(blockList, username) {
+			res = append(res, sourceUser)
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryBlockStore) IsBlocked(sourceUser string, targetUser string) (bool, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	return utils.Contains(store.blocked[sourceUser], targetUser), nil
+}
+
+// MemorySwipeStore在内存中保存每个用户对每个目标的最近一次滑动记录
+type MemorySwipeStore struct {
+	lock   sync.RWMutex
+	swipes map[[2]string]dataBase.Swipe
+}
+
+func NewMemorySwipeStore() *MemorySwipeStore {
+	return &MemorySwipeStore{swipes: make(map[[2]string]dataBase.Swipe)}
+}
+
+func (store *MemorySwipeStore) RecordSwipe(sourceUser string, targetUser string, liked bool) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.swipes[[2]string{sourceUser, targetUser}] = dataBase.Swipe{SourceUser: sourceUser, TargetUser: targetUser, Liked: liked, CreatedAt: time.Now().Unix()}
+	return nil
+}
+
+func (store *MemorySwipeStore) GetSwipe(sourceUser string, targetUser string) (*dataBase.Swipe, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	swipe, ok := store.swipes[[2]string{sourceUser, targetUser}]
+	if !ok {
+		return nil, false
+	}
+	return &swipe, true
+}
+
+func (store *MemorySwipeStore) SwipedUsers(sourceUser string, since int64) ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	for _, swipe := range store.swipes {
+		if swipe.SourceUser == sourceUser && swipe.CreatedAt >= since {
+			res = append(res, swipe.TargetUser)
+		}
+	}
+	return res, nil
+}
+
+func (store *MemorySwipeStore) SwipesSince(since int64) ([]dataBase.Swipe, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.Swipe{}
+	for _, swipe := range store.swipes {
+		if swipe.CreatedAt >= since {
+			res = append(res, swipe)
+		}
+	}
+	return res, nil
+}
+
+// MemoryMatchStore在内存中保存配对记录
+type MemoryMatchStore struct {
+	lock    sync.RWMutex
+	matches map[[2]string]dataBase.Match
+}
+
+func NewMemoryMatchStore() *MemoryMatchStore {
+	return &MemoryMatchStore{matches: make(map[[2]string]dataBase.Match)}
+}
+
+func (store *MemoryMatchStore) CreateMatch(userA string, userB string) error {
+	match := dataBase.NewMatch(userA, userB, time.Now().Unix())
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if _, ok := store.matches[[2]string{match.UserA, match.UserB}]; !ok {
+		store.matches[[2]string{match.UserA, match.UserB}] = *match
+	}
+	return nil
+}
+
+func (store *MemoryMatchStore) IsMatched(userA string, userB string) bool {
+	match := dataBase.NewMatch(userA, userB, 0)
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res, ok := store.matches[[2]string{match.UserA, match.UserB}]
+	return ok && res.UnmatchedAt == 0
+}
+
+func (store *MemoryMatchStore) ListMatches(username string) ([]dataBase.Match, error) {
+	store.lock.RLock()
+	res := []dataBase.Match{}
+	for _, match := range store.matches {
+		if match.UnmatchedAt == 0 && (match.UserA == username || match.UserB == username) {
+			res = append(res, match)
+		}
+	}
+	store.lock.RUnlock()
+	sort.Slice(res, func(i, j int) bool { return res[i].CreatedAt > res[j].CreatedAt })
+	return res, nil
+}
+
+func (store *MemoryMatchStore) Unmatch(userA string, userB string) error {
+	key := dataBase.NewMatch(userA, userB, 0)
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	match, ok := store.matches[[2]string{key.UserA, key.UserB}]
+	if !ok || match.UnmatchedAt != 0 {
+		return errors.New("not found")
+	}
+	match.UnmatchedAt = time.Now().Unix()
+	store.matches[[2]string{key.UserA, key.UserB}] = match
+	return nil
+}
+
+func (store *MemoryMatchStore) ListUnmatched(username string) ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	for _, match := range store.matches {
+		if match.UnmatchedAt != 0 && (match.UserA == username || match.UserB == username) {
+			res = append(res, match.Partner(username))
+		}
+	}
+	return res, nil
+}
+
+// MemoryMessageStore在内存中按发送顺序保存聊天记录
+type MemoryMessageStore struct {
+	lock     sync.RWMutex
+	messages []dataBase.Message
+}
+
+func NewMemoryMessageStore() *MemoryMessageStore {
+	return &MemoryMessageStore{}
+}
+
+func (store *MemoryMessageStore) SaveMessage(sourceUser string, targetUser string, content string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.messages = append(store.messages, dataBase.Message{SourceUser: sourceUser, TargetUser: targetUser, Content: content, CreatedAt: time.Now().Unix()})
+	return nil
+}
+
+func (store *MemoryMessageStore) ListMessages(userA string, userB string, limit int) ([]dataBase.Message, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.Message{}
+	for i := range store.messages {
+		if isBetween(&store.messages[i], userA, userB) {
+			res = append(res, store.messages[i])
+		}
+	}
+	if len(res) > limit {
+		res = res[len(res)-limit:]
+	}
+	return res, nil
+}
+
+func (store *MemoryMessageStore) LastMessages(username string, partners []string) (map[string]dataBase.Message, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make(map[string]dataBase.Message, len(partners))
+	for _, partner := range partners {
+		for i := len(store.messages) - 1; i >= 0; i-- {
+			if isBetween(&store.messages[i], username, partner) {
+				res[partner] = store.messages[i]
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryMessageStore) MessagesSentAt(sourceUser string, targetUser string, createdAt int64) ([]dataBase.Message, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.Message{}
+	for i := range store.messages {
+		message := store.messages[i]
+		if message.SourceUser == sourceUser && message.TargetUser == targetUser && message.CreatedAt == createdAt {
+			res = append(res, message)
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryMessageStore) MessagePairsSince(since int64) ([]dataBase.MessagePair, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	seen := make(map[dataBase.MessagePair]bool)
+	res := []dataBase.MessagePair{}
+	for i := range store.messages {
+		pair := dataBase.MessagePair{SourceUser: store.messages[i].SourceUser, TargetUser: store.messages[i].TargetUser}
+		if store.messages[i].CreatedAt >= since && !seen[pair] {
+			seen[pair] = true
+			res = append(res, pair)
+		}
+	}
+	return res, nil
+}
+
+func isBetween(message *dataBase.Message, userA string, userB string) bool {
+	return (message.SourceUser == userA && message.TargetUser == userB) || (message.SourceUser == userB && message.TargetUser == userA)
+}
+
+// MemoryLocationStore在内存中保存每个用户最近一次上报的位置
+type MemoryLocationStore struct {
+	lock      sync.RWMutex
+	locations map[string]dataBase.Location
+}
+
+func NewMemoryLocationStore() *MemoryLocationStore {
+	return &MemoryLocationStore{locations: make(map[string]dataBase.Location)}
+}
+
+func (store *MemoryLocationStore) UpdateLocation(username string, latitude float64, longitude float64) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.locations[username] = dataBase.Location{Username: username, Latitude: latitude, Longitude: longitude, UpdatedAt: time.Now().Unix()}
+	return nil
+}
+
+func (store *MemoryLocationStore) GetLocations(usernames []string) (map[string]dataBase.Location, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make(map[string]dataBase.Location, len(usernames))
+	for _, username := range usernames {
+		if location, ok := store.locations[username]; ok {
+			res[username] = location
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryLocationStore) UsersWithin(minLatitude float64, maxLatitude float64, minLongitude float64, maxLongitude float64) ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	for username, location := range store.locations {
+		if location.Latitude >= minLatitude && location.Latitude <= maxLatitude && location.Longitude >= minLongitude && location.Longitude <= maxLongitude {
+			res = append(res, username)
+		}
+	}
+	return res, nil
+}
+
+// MemorySessionStore在内存中保存用户名与token的映射以及缓存的账号状态
+type MemorySessionStore struct {
+	lock     sync.RWMutex
+	tokens   map[string]string
+	statuses map[string]dataBase.AccountStatus
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{tokens: make(map[string]string), statuses: make(map[string]dataBase.AccountStatus)}
+}
+
+func (store *MemorySessionStore) SaveToken(username string, token string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.tokens[username] = token
+	return nil
+}
+
+func (store *MemorySessionStore) ReadToken(username string) (string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	token, ok := store.tokens[username]
+	if !ok {
+		return "", errors.New("nil value of the key")
+	}
+	return token, nil
+}
+
+func (store *MemorySessionStore) HasToken(username string) bool {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	_, ok := store.tokens[username]
+	return ok
+}
+
+func (store *MemorySessionStore) DeleteToken(username string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.tokens, username)
+	return nil
+}
+
+func (store *MemorySessionStore) SaveAccountStatus(status *dataBase.AccountStatus) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.statuses[status.Username] = *status
+	return nil
+}
+
+func (store *MemorySessionStore) ReadAccountStatus(username string) (*dataBase.AccountStatus, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	status, ok := store.statuses[username]
+	if !ok {
+		return nil, nil
+	}
+	return &status, nil
+}
+
+// MemoryPresenceStore在内存中记录每个用户最近一次心跳的过期时刻以及在线状态是否由http心跳维持
+type MemoryPresenceStore struct {
+	lock       sync.RWMutex
+	deadline   map[string]time.Time
+	heartbeats map[string]bool
+}
+
+func NewMemoryPresenceStore() *MemoryPresenceStore {
+	return &MemoryPresenceStore{deadline: make(map[string]time.Time), heartbeats: make(map[string]bool)}
+}
+
+func (store *MemoryPresenceStore) UpdateStatus(username string, heartbeat bool) (bool, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	now := time.Now()
+	deadline, ok := store.deadline[username]
+	created := !ok || !now.Before(deadline)
+	store.deadline[username] = now.Add(memoryHeartBeatTimeout)
+	if created || heartbeat {
+		store.heartbeats[username] = heartbeat
+	}
+	return created, nil
+}
+
+func (store *MemoryPresenceStore) HasHeartbeat(username string) bool {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	deadline, ok := store.deadline[username]
+	return ok && time.Now().Before(deadline) && store.heartbeats[username]
+}
+
+func (store *MemoryPresenceStore) SetOffline(username string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.deadline, username)
+	delete(store.heartbeats, username)
+	return nil
+}
+
+func (store *MemoryPresenceStore) IsOnline(username string) bool {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	deadline, ok := store.deadline[username]
+	return ok && time.Now().Before(deadline)
+}
+
+func (store *MemoryPresenceStore) CountOnline() (int, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	count := 0
+	now := time.Now()
+	for _, deadline := range store.deadline {
+		if now.Before(deadline) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (store *MemoryPresenceStore) OnlineUsers() ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	now := time.Now()
+	for username, deadline := range store.deadline {
+		if now.Before(deadline) {
+			res = append(res, username)
+		}
+	}
+	return res, nil
+}
+
+// MemoryRecommendQueueStore在内存中保存每个用户的推荐队列
+type MemoryRecommendQueueStore struct {
+	lock   sync.Mutex
+	queues map[string][]string
+}
+
+func NewMemoryRecommendQueueStore() *MemoryRecommendQueueStore {
+	return &MemoryRecommendQueueStore{queues: make(map[string][]string)}
+}
+
+func (store *MemoryRecommendQueueStore) Replace(username string, candidates []string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.queues[username] = append([]string{}, candidates...)
+	return nil
+}
+
+func (store *MemoryRecommendQueueStore) Pop(username string) (string, bool, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	queue := store.queues[username]
+	if len(queue) == 0 {
+		return "", false, nil
+	}
+	store.queues[username] = queue[1:]
+	return queue[0], true, nil
+}
+
+func (store *MemoryRecommendQueueStore) Length(username string) (int, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	return len(store.queues[username]), nil
+}
+
+func (store *MemoryRecommendQueueStore) Invalidate(username string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	delete(store.queues, username)
+	return nil
+}
+
+// MemoryTagStore在内存中保存兴趣标签目录和每个用户的标签id
+type MemoryTagStore struct {
+	lock     sync.RWMutex
+	tags     []dataBase.Tag
+	userTags map[string][]int
+}
+
+func NewMemoryTagStore() *MemoryTagStore {
+	return &MemoryTagStore{userTags: make(map[string][]int)}
+}
+
+// @title    AddTags
+// @description   			向标签目录中添加标签，已存在的标签会被忽略
+// @auth      郑康       	2026.10.19
+// @param     ...string		已规范化的标签名
+// @return    void
+func (store *MemoryTagStore) AddTags(names ...string) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for _, name := range names {
+		if _, ok := store.findByName(name); !ok {
+			store.tags = append(store.tags, dataBase.Tag{ID: len(store.tags) + 1, Name: name})
+		}
+	}
+}
+
+func (store *MemoryTagStore) findByName(name string) (dataBase.Tag, bool) {
+	for _, tag := range store.tags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return dataBase.Tag{}, false
+}
+
+func (store *MemoryTagStore) SearchTags(prefix string, limit int) ([]dataBase.Tag, error) {
+	store.lock.RLock()
+	res := []dataBase.Tag{}
+	for _, tag := range store.tags {
+		if strings.HasPrefix(tag.Name, prefix) {
+			res = append(res, tag)
+		}
+	}
+	store.lock.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		if len(res[i].Name) != len(res[j].Name) {
+			return len(res[i].Name) < len(res[j].Name)
+		}
+		return res[i].Name < res[j].Name
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (store *MemoryTagStore) FindTags(names []string) ([]dataBase.Tag, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.Tag{}
+	for _, name := range names {
+		if tag, ok := store.findByName(name); ok {
+			res = append(res, tag)
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryTagStore) SetUserTags(username string, tagIDs []int) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for _, tagID := range tagIDs {
+		if tagID < 1 || tagID > len(store.tags) {
+			return errors.New("tag doesn't exist: " + strconv.Itoa(tagID))
+		}
+	}
+	if len(tagIDs) == 0 {
+		delete(store.userTags, username)
+		return nil
+	}
+	store.userTags[username] = append([]int{}, tagIDs...)
+	return nil
+}
+
+func (store *MemoryTagStore) UserTags(usernames []string) (map[string][]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make(map[string][]string)
+	for _, username := range usernames {
+		for _, tagID := range store.userTags[username] {
+			res[username] = append(res[username], store.tags[tagID-1].Name)
+		}
+		sort.Strings(res[username])
+	}
+	return res, nil
+}
+
+func (store *MemoryTagStore) UsersWithTags(names []string) ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	for username, tagIDs := range store.userTags {
+		for _, tagID := range tagIDs {
+			if utils.Contains(names, store.tags[tagID-1].Name) {
+				res = append(res, username)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// MemoryPhotoStore在内存中保存每个用户按Position排列的相册
+type MemoryPhotoStore struct {
+	lock   sync.RWMutex
+	photos map[string][]dataBase.Photo
+}
+
+func NewMemoryPhotoStore() *MemoryPhotoStore {
+	return &MemoryPhotoStore{photos: make(map[string][]dataBase.Photo)}
+}
+
+func (store *MemoryPhotoStore) AddPhoto(photo *dataBase.Photo, limit int) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if len(store.photos[photo.Username]) >= limit {
+		return dataBase.ErrGalleryFull
+	}
+	photo.Position = len(store.photos[photo.Username])
+	store.photos[photo.Username] = append(store.photos[photo.Username], *photo)
+	return nil
+}
+
+func (store *MemoryPhotoStore) ListPhotos(usernames []string) (map[string][]dataBase.Photo, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make(map[string][]dataBase.Photo)
+	for _, username := range usernames {
+		if photos := store.photos[username]; len(photos) > 0 {
+			res[username] = append([]dataBase.Photo{}, photos...)
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryPhotoStore) indexOf(username string, photoID string) int {
+	for i := range store.photos[username] {
+		if store.photos[username][i].ID == photoID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (store *MemoryPhotoStore) DeletePhoto(username string, photoID string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	index := store.indexOf(username, photoID)
+	if index < 0 {
+		return dataBase.ErrPhotoNotFound
+	}
+	photos := append(store.photos[username][:index:index], store.photos[username][index+1:]...)
+	for i := index; i < len(photos); i++ {
+		photos[i].Position--
+	}
+	store.photos[username] = photos
+	return nil
+}
+
+func (store *MemoryPhotoStore) Reorder(username string, photoIDs []string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	photos := make([]dataBase.Photo, 0, len(photoIDs))
+	for position, photoID := range photoIDs {
+		index := store.indexOf(username, photoID)
+		if index < 0 {
+			return dataBase.ErrPhotoNotFound
+		}
+		photo := store.photos[username][index]
+		photo.Position = position
+		photos = append(photos, photo)
+	}
+	store.photos[username] = photos
+	return nil
+}
+
+// References只包含相册中的照片，内存存储中的头像总是与主照片同步
+func (store *MemoryPhotoStore) References() ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	for _, photos := range store.photos {
+		for i := range photos {
+			res = append(res, photos[i].Path)
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryPhotoStore) SetPrimary(username string, photoID string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if store.indexOf(username, photoID) < 0 {
+		return dataBase.ErrPhotoNotFound
+	}
+	for i := range store.photos[username] {
+		store.photos[username][i].Primary = store.photos[username][i].ID == photoID
+	}
+	return nil
+}
+
+// MemoryImageStore在内存中保存图片及以哈希为键的Blob引用计数
+type MemoryImageStore struct {
+	lock       sync.RWMutex
+	images     map[string]dataBase.Image
+	blobs      map[string]dataBase.Blob
+	references map[dataBase.ImageReference]bool //CreatedAt为0的引用
+}
+
+func NewMemoryImageStore() *MemoryImageStore {
+	return &MemoryImageStore{images: make(map[string]dataBase.Image), blobs: make(map[string]dataBase.Blob), references: make(map[dataBase.ImageReference]bool)}
+}
+
+func (store *MemoryImageStore) AddImage(image *dataBase.Image) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if _, ok := store.images[image.ID]; ok {
+		return errors.New("image " + image.ID + " already exists")
+	}
+	for _, variant := range image.Variants {
+		blob, ok := store.blobs[variant.Blob.Hash]
+		if !ok {
+			blob = variant.Blob
+			blob.RefCount = 0
+		}
+		blob.RefCount++
+		store.blobs[blob.Hash] = blob
+	}
+	store.images[image.ID] = *image
+	return nil
+}
+
+func (store *MemoryImageStore) FindImages(imageIDs []string) (map[string]dataBase.Image, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make(map[string]dataBase.Image)
+	for _, imageID := range imageIDs {
+		image, ok := store.images[imageID]
+		if !ok {
+			continue
+		}
+		variants := make(map[string]dataBase.ImageVariant, len(image.Variants))
+		for name, variant := range image.Variants {
+			variant.Blob = store.blobs[variant.Blob.Hash]
+			variants[name] = variant
+		}
+		image.Variants = variants
+		res[imageID] = image
+	}
+	return res, nil
+}
+
+func (store *MemoryImageStore) ReleaseImage(imageID string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	image, ok := store.images[imageID]
+	if !ok {
+		return dataBase.ErrImageNotFound
+	}
+	delete(store.images, imageID)
+	for _, variant := range image.Variants {
+		blob := store.blobs[variant.Blob.Hash]
+		blob.RefCount--
+		if blob.RefCount > 0 {
+			store.blobs[blob.Hash] = blob
+			continue
+		}
+		delete(store.blobs, blob.Hash)
+	}
+	return nil
+}
+
+func (store *MemoryImageStore) ListImages() ([]dataBase.Image, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make([]dataBase.Image, 0, len(store.images))
+	for _, image := range store.images {
+		res = append(res, dataBase.Image{ID: image.ID, Owner: image.Owner, CreatedAt: image.CreatedAt})
+	}
+	return res, nil
+}
+
+func (store *MemoryImageStore) ListBlobs() ([]dataBase.Blob, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := make([]dataBase.Blob, 0, len(store.blobs))
+	for _, blob := range store.blobs {
+		res = append(res, blob)
+	}
+	return res, nil
+}
+
+func (store *MemoryImageStore) AddReferences(references []dataBase.ImageReference) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for _, reference := range references {
+		reference.CreatedAt = 0
+		store.references[reference] = true
+	}
+	return nil
+}
+
+func (store *MemoryImageStore) ReferencedNames() ([]string, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []string{}
+	for reference := range store.references {
+		if !utils.Contains(res, reference.Name) {
+			res = append(res, reference.Name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// MemoryModerationStore在内存中按时间顺序保存被标记的内容
+type MemoryModerationStore struct {
+	lock  sync.RWMutex
+	items []dataBase.FlaggedContent
+}
+
+func NewMemoryModerationStore() *MemoryModerationStore {
+	return &MemoryModerationStore{}
+}
+
+func (store *MemoryModerationStore) AddFlagged(item *dataBase.FlaggedContent) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.items = append(store.items, *item)
+	return nil
+}
+
+func (store *MemoryModerationStore) ListFlagged(limit int) ([]dataBase.FlaggedContent, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.FlaggedContent{}
+	for i := len(store.items) - 1; i >= 0 && len(res) < limit; i-- {
+		res = append(res, store.items[i])
+	}
+	return res, nil
+}
+
+// MemoryReportStore在内存中按创建顺序保存举报，审计记录按举报id分组
+type MemoryReportStore struct {
+	lock    sync.RWMutex
+	reports []dataBase.Report
+	actions map[string][]dataBase.ReportAction
+}
+
+func NewMemoryReportStore() *MemoryReportStore {
+	return &MemoryReportStore{actions: make(map[string][]dataBase.ReportAction)}
+}
+
+func (store *MemoryReportStore) AddReport(report *dataBase.Report, action *dataBase.ReportAction) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.reports = append(store.reports, *report)
+	store.actions[report.ID] = append(store.actions[report.ID], *action)
+	return nil
+}
+
+func (store *MemoryReportStore) ListReports(status string, limit int) ([]dataBase.Report, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	res := []dataBase.Report{}
+	for i := len(store.reports) - 1; i >= 0 && len(res) < limit; i-- {
+		if status == "" || store.reports[i].Status == status {
+			res = append(res, store.reports[i])
+		}
+	}
+	return res, nil
+}
+
+func (store *MemoryReportStore) FindReport(reportID string) (*dataBase.Report, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	for i := range store.reports {
+		if store.reports[i].ID == reportID {
+			report := store.reports[i]
+			return &report, nil
+		}
+	}
+	return nil, dataBase.ErrReportNotFound
+}
+
+func (store *MemoryReportStore) UpdateReport(report *dataBase.Report, from string, action *dataBase.ReportAction) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	for i := range store.reports {
+		if store.reports[i].ID == report.ID {
+			if store.reports[i].Status != from {
+				return dataBase.ErrReportChanged
+			}
+			store.reports[i].Status = report.Status
+			store.reports[i].Assignee = report.Assignee
+			store.reports[i].Resolution = report.Resolution
+			store.reports[i].SuspendedUntil = report.SuspendedUntil
+			store.reports[i].UpdatedAt = report.UpdatedAt
+			store.actions[report.ID] = append(store.actions[report.ID], *action)
+			return nil
+		}
+	}
+	return dataBase.ErrReportNotFound
+}
+
+func (store *MemoryReportStore) ListActions(reportID string) ([]dataBase.ReportAction, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	return append([]dataBase.ReportAction{}, store.actions[reportID]...), nil
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"Flipped_Server/dataBase"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryUserStore(t *testing.T) {
+	store := NewMemoryUserStore()
+	assert.NoError(t, store.InsertUser(&dataBase.UserInfoTable{Username: "MrFirst", Password: "123456"}))
+	assert.Error(t, store.InsertUser(&dataBase.UserInfoTable{Username: "MrFirst"}))
+	assert.True(t, store.DoesUserExist("MrFirst"))
+	assert.False(t, store.DoesUserExist("MrSecond"))
+
+	user, err := store.FindUserInfo("MrFirst", "123456")
+	assert.NoError(t, err)
+	assert.Equal(t, "MrFirst", user.Username)
+	_, err = store.FindUserInfo("MrFirst", "wrong")
+	assert.Error(t, err)
+
+	users, _ := store.SampleUsers(20)
+	assert.Len(t, users, 1)
+
+	status, err := store.AccountStatus("MrFirst")
+	assert.NoError(t, err)
+	assert.Equal(t, dataBase.AccountActive, status.Status)
+	assert.NoError(t, store.SetAccountStatus(&dataBase.AccountStatus{Username: "MrFirst", Status: dataBase.AccountSuspended, Until: 100}, &dataBase.AccountStatusChange{Actor: "admin"}))
+	status, _ = store.AccountStatus("MrFirst")
+	assert.True(t, status.IsRestricted(99))
+	assert.False(t, status.IsRestricted(100))
+	assert.NoError(t, store.SetAccountStatus(&dataBase.AccountStatus{Username: "MrFirst", Status: dataBase.AccountActive}, &dataBase.AccountStatusChange{Actor: "admin"}))
+	changes, _ := store.AccountStatusChanges("MrFirst")
+	assert.Len(t, changes, 2)
+	assert.Equal(t, dataBase.AccountActive, changes[0].From)
+	assert.Equal(t, dataBase.AccountSuspended, changes[1].From)
+	assert.Equal(t, dataBase.AccountActive, changes[1].Status)
+	assert.Error(t, store.SetAccountStatus(&dataBase.AccountStatus{Username: "MrSecond", Status: dataBase.AccountBanned}, &dataBase.AccountStatusChange{}))
+	_, err = store.AccountStatus("MrSecond")
+	assert.Error(t, err)
+}
+
+func TestMemoryUserStoreHidesRestrictedAccounts(t *testing.T) {
+	store := NewMemoryUserStore()
+	for _, name := range []string{"MrBanned", "MrExpired", "MrFree", "MrSuspended"} {
+		_ = store.InsertUser(&dataBase.UserInfoTable{Username: name})
+	}
+	now := time.Now().Unix()
+	_ = store.SetAccountStatus(&dataBase.AccountStatus{Username: "MrBanned", Status: dataBase.AccountBanned}, &dataBase.AccountStatusChange{})
+	_ = store.SetAccountStatus(&dataBase.AccountStatus{Username: "MrSuspended", Status: dataBase.AccountSuspended, Until: now + 3600}, &dataBase.AccountStatusChange{})
+	_ = store.SetAccountStatus(&dataBase.AccountStatus{Username: "MrExpired", Status: dataBase.AccountSuspended, Until: now - 1}, &dataBase.AccountStatusChange{})
+
+	users, err := store.SearchUsers(&dataBase.UserQuery{NamePrefix: "Mr", UserType: -1, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MrFree", "MrExpired"}, usernamesOf(users))
+	users, err = store.FindCandidates(&dataBase.CandidateQuery{Limit: 10})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"MrExpired", "MrFree"}, usernamesOf(users))
+}
+
+func usernamesOf(users []*dataBase.UserInfoTable) []string {
+	res := []string{}
+	for _, user := range users {
+		res = append(res, user.Username)
+	}
+	return res
+}
+
+func TestMemoryFriendRequestStore(t *testing.T) {
+	store := NewMemoryFriendRequestStore()
+	assert.NoError(t, store.SendRequest("MrFirst", "MrSecond"))
+	assert.Equal(t, dataBase.ErrFriendRequestExists, store.SendRequest("MrFirst", "MrSecond"))
+	assert.NoError(t, store.SendRequest("MrSecond", "MrFirst"))
+	requests, _ := store.ListOutgoing("MrFirst")
+	assert.Len(t, requests, 1)
+	assert.NoError(t, store.RemoveRequest("MrFirst", "MrSecond"))
+	assert.Equal(t, dataBase.ErrFriendRequestNotFound, store.RemoveRequest("MrFirst", "MrSecond"))
+}
+
+func TestMemoryFriendStore(t *testing.T) {
+	stores := NewMemoryStores()
+	_ = stores.Users.InsertUser(&dataBase.UserInfoTable{Username: "MrFirst"})
+	_ = stores.Users.InsertUser(&dataBase.UserInfoTable{Username: "MrSecond"})
+	_ = stores.Friends.InitFriendList("MrFirst")
+
+	assert.Error(t, stores.Friends.AddFriend("MrFirst", "Nobody"))
+	assert.Error(t, stores.Friends.AddFriend("MrFirst", "MrFirst"))
+	assert.NoError(t, stores.Friends.AddFriend("MrFirst", "MrSecond"))
+	assert.Error(t, stores.Friends.AddFriend("MrFirst", "MrSecond"))
+
+	friendList, err := stores.Friends.GetFriendList("MrFirst")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MrSecond"}, friendList)
+
+	assert.NoError(t, stores.Friends.DeleteFriend("MrFirst", "MrSecond"))
+	friendList, _ = stores.Friends.GetFriendList("MrFirst")
+	assert.Empty(t, friendList)
+}
+
+func TestMemorySessionAndPresenceStore(t *testing.T) {
+	stores := NewMemoryStores()
+	assert.False(t, stores.Sessions.HasToken("MrFirst"))
+	_ = stores.Sessions.SaveToken("MrFirst", "token")
+	token, err := stores.Sessions.ReadToken("MrFirst")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+	_ = stores.Sessions.DeleteToken("MrFirst")
+	assert.False(t, stores.Sessions.HasToken("MrFirst"))
+	status, err := stores.Sessions.ReadAccountStatus("MrFirst")
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	_ = stores.Sessions.SaveAccountStatus(&dataBase.AccountStatus{Username: "MrFirst", Status: dataBase.AccountBanned})
+	status, _ = stores.Sessions.ReadAccountStatus("MrFirst")
+	assert.Equal(t, dataBase.AccountBanned, status.Status)
+
+	assert.False(t, stores.Presence.IsOnline("MrFirst"))
+	online, err := stores.Presence.UpdateStatus("MrFirst", false)
+	assert.NoError(t, err)
+	assert.True(t, online)
+	assert.True(t, stores.Presence.IsOnline("MrFirst"))
+	assert.False(t, stores.Presence.HasHeartbeat("MrFirst"))
+	online, _ = stores.Presence.UpdateStatus("MrFirst", true)
+	assert.False(t, online)
+	assert.True(t, stores.Presence.HasHeartbeat("MrFirst"))
+	online, _ = stores.Presence.UpdateStatus("MrFirst", false)
+	assert.False(t, online)
+	assert.True(t, stores.Presence.HasHeartbeat("MrFirst"))
+	count, _ := stores.Presence.CountOnline()
+	assert.Equal(t, 1, count)
+	assert.NoError(t, stores.Presence.SetOffline("MrFirst"))
+	assert.False(t, stores.Presence.IsOnline("MrFirst"))
+	assert.False(t, stores.Presence.HasHeartbeat("MrFirst"))
+}
+
+func TestMemorySwipeAndMatchStore(t *testing.T) {
+	stores := NewMemoryStores()
+	_, ok := stores.Swipes.GetSwipe("MrFirst", "MrSecond")
+	assert.False(t, ok)
+	assert.NoError(t, stores.Swipes.RecordSwipe("MrFirst", "MrSecond", true))
+	assert.NoError(t, stores.Swipes.RecordSwipe("MrFirst", "MrSecond", false))
+	swipe, ok := stores.Swipes.GetSwipe("MrFirst", "MrSecond")
+	assert.True(t, ok)
+	assert.False(t, swipe.Liked)
+
+	assert.False(t, stores.Matches.IsMatched("MrFirst", "MrSecond"))
+	assert.NoError(t, stores.Matches.CreateMatch("MrSecond", "MrFirst"))
+	assert.True(t, stores.Matches.IsMatched("MrFirst", "MrSecond"))
+	matches, _ := stores.Matches.ListMatches("MrFirst")
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "MrSecond", matches[0].Partner("MrFirst"))
+
+	assert.NoError(t, stores.Matches.Unmatch("MrFirst", "MrSecond"))
+	assert.Error(t, stores.Matches.Unmatch("MrFirst", "MrSecond"))
+	assert.False(t, stores.Matches.IsMatched("MrFirst", "MrSecond"))
+	matches, _ = stores.Matches.ListMatches("MrFirst")
+	assert.Empty(t, matches)
+	unmatched, _ := stores.Matches.ListUnmatched("MrSecond")
+	assert.Equal(t, []string{"MrFirst"}, unmatched)
+}
+
+func TestMemoryMessageStore(t *testing.T) {
+	store := NewMemoryMessageStore()
+	lastMessages, _ := store.LastMessages("MrFirst", []string{"MrSecond"})
+	assert.Empty(t, lastMessages)
+	_ = store.SaveMessage("MrFirst", "MrSecond", "one")
+	_ = store.SaveMessage("MrThird", "MrFirst", "other")
+	_ = store.SaveMessage("MrSecond", "MrFirst", "two")
+	messages, _ := store.ListMessages("MrSecond", "MrFirst", 1)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "two", messages[0].Content)
+	lastMessages, _ = store.LastMessages("MrFirst", []string{"MrSecond", "MrThird", "MrFourth"})
+	assert.Len(t, lastMessages, 2)
+	assert.Equal(t, "two", lastMessages["MrSecond"].Content)
+	assert.Equal(t, "other", lastMessages["MrThird"].Content)
+	messages, _ = store.ListMessages("MrFirst", "MrThird", 10)
+	assert.Len(t, messages, 1)
+}
+
+func TestMemoryRecommendQueueStore(t *testing.T) {
+	store := NewMemoryRecommendQueueStore()
+	_, ok, _ := store.Pop("MrFirst")
+	assert.False(t, ok)
+	_ = store.Replace("MrFirst", []string{"MrSecond", "MrThird"})
+	length, _ := store.Length("MrFirst")
+	assert.Equal(t, 2, length)
+	next, ok, _ := store.Pop("MrFirst")
+	assert.True(t, ok)
+	assert.Equal(t, "MrSecond", next)
+
+	assert.NoError(t, store.Invalidate("MrFirst"))
+	length, _ = store.Length("MrFirst")
+	assert.Equal(t, 0, length)
+}
+
+func TestMemoryTagStore(t *testing.T) {
+	store := NewMemoryTagStore()
+	store.AddTags("reading", "running", "reading")
+	tags, _ := store.SearchTags("r", 0)
+	assert.Len(t, tags, 2)
+	tags, _ = store.FindTags([]string{"running", "knitting"})
+	assert.Equal(t, []dataBase.Tag{{ID: 2, Name: "running"}}, tags)
+
+	assert.Error(t, store.SetUserTags("MrFirst", []int{3}))
+	assert.NoError(t, store.SetUserTags("MrFirst", []int{2, 1}))
+	userTags, _ := store.UserTags([]string{"MrFirst", "MrSecond"})
+	assert.Equal(t, map[string][]string{"MrFirst": {"reading", "running"}}, userTags)
+	users, _ := store.UsersWithTags([]string{"running"})
+	assert.Equal(t, []string{"MrFirst"}, users)
+}
+
+func TestMemoryPhotoStore(t *testing.T) {
+	store := NewMemoryPhotoStore()
+	for _, id := range []string{"a", "b", "c"} {
+		_ = store.AddPhoto(&dataBase.Photo{ID: id, Username: "MrFirst"}, 3)
+	}
+	assert.Equal(t, dataBase.ErrGalleryFull, store.AddPhoto(&dataBase.Photo{ID: "d", Username: "MrFirst"}, 3))
+	assert.NoError(t, store.SetPrimary("MrFirst", "b"))
+	assert.Equal(t, dataBase.ErrPhotoNotFound, store.SetPrimary("MrSecond", "b"))
+	assert.NoError(t, store.DeletePhoto("MrFirst", "a"))
+	galleries, _ := store.ListPhotos([]string{"MrFirst", "MrSecond"})
+	assert.Len(t, galleries, 1)
+	assert.Equal(t, []dataBase.Photo{
+		{ID: "b", Username: "MrFirst", Position: 0, Primary: true},
+		{ID: "c", Username: "MrFirst", Position: 1},
+	}, galleries["MrFirst"])
+
+	assert.NoError(t, store.Reorder("MrFirst", []string{"c", "b"}))
+	galleries, _ = store.ListPhotos([]string{"MrFirst"})
+	assert.Equal(t, "c", galleries["MrFirst"][0].ID)
+	assert.Equal(t, 1, galleries["MrFirst"][1].Position)
+}
+
+func TestMemoryImageStore(t *testing.T) {
+	store := NewMemoryImageStore()
+	shared := dataBase.Blob{Hash: "shared", Format: "png", Size: 10}
+	_ = store.AddImage(&dataBase.Image{ID: "a", Owner: "MrFirst", Variants: map[string]dataBase.ImageVariant{
+		dataBase.OriginalVariant: {Name: dataBase.OriginalVariant, Blob: shared},
+		"list":                   {Name: "list", Blob: dataBase.Blob{Hash: "small", Format: "png", Size: 2}},
+	}})
+	//相同内容的上传共享同一个Blob
+	_ = store.AddImage(&dataBase.Image{ID: "b", Owner: "MrSecond", Variants: map[string]dataBase.ImageVariant{
+		dataBase.OriginalVariant: {Name: dataBase.OriginalVariant, Blob: shared},
+	}})
+	assert.Error(t, store.AddImage(&dataBase.Image{ID: "b"}))
+	images, _ := store.FindImages([]string{"a", "b", "c"})
+	assert.Len(t, images, 2)
+	assert.Equal(t, 2, images["a"].Variants[dataBase.OriginalVariant].Blob.RefCount)
+	list, _ := store.ListImages()
+	assert.Len(t, list, 2)
+	blobs, _ := store.ListBlobs()
+	assert.Len(t, blobs, 2)
+
+	assert.NoError(t, store.ReleaseImage("a"))
+	images, _ = store.FindImages([]string{"b"})
+	assert.Equal(t, 1, images["b"].Variants[dataBase.OriginalVariant].Blob.RefCount)
+	blobs, _ = store.ListBlobs()
+	assert.Len(t, blobs, 1)
+	assert.Equal(t, dataBase.ErrImageNotFound, store.ReleaseImage("a"))
+	assert.NoError(t, store.ReleaseImage("b"))
+	blobs, _ = store.ListBlobs()
+	assert.Empty(t, blobs)
+
+	conversation := dataBase.ConversationRef("MrSecond", "MrFirst")
+	assert.Equal(t, conversation, dataBase.ConversationRef("MrFirst", "MrSecond"))
+	_ = store.AddReferences([]dataBase.ImageReference{
+		{Name: "a", Source: dataBase.ReferenceMessage, Ref: conversation, CreatedAt: 1},
+		{Name: "a", Source: dataBase.ReferenceMessage, Ref: conversation, CreatedAt: 2},
+		{Name: "a", Source: dataBase.ReferenceReport, Ref: "report"},
+		{Name: "b", Source: dataBase.ReferenceMessage, Ref: conversation},
+	})
+	names, _ := store.ReferencedNames()
+	assert.Equal(t, []string{"a", "b"}, names)
+}
+
+func TestMemoryModerationStore(t *testing.T) {
+	store := NewMemoryModerationStore()
+	for _, content := range []string{"first", "second", "third"} {
+		_ = store.AddFlagged(&dataBase.FlaggedContent{Username: "MrFirst", Source: dataBase.SourceMessage, Content: content})
+	}
+	items, err := store.ListFlagged(2)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "third", items[0].Content)
+}
+
+func TestMemoryReportStore(t *testing.T) {
+	store := NewMemoryReportStore()
+	for _, id := range []string{"a", "b"} {
+		_ = store.AddReport(&dataBase.Report{ID: id, Reporter: "MrFirst", Target: "MrSecond", Status: dataBase.ReportOpen},
+			&dataBase.ReportAction{ReportID: id, Actor: "MrFirst", Action: dataBase.ReportActionCreated})
+	}
+	assert.NoError(t, store.UpdateReport(&dataBase.Report{ID: "a", Status: dataBase.ReportResolved, Resolution: dataBase.ResolutionWarning},
+		dataBase.ReportOpen, &dataBase.ReportAction{ReportID: "a", Actor: "admin", Action: dataBase.ReportActionResolved}))
+	//同时处理的另一个管理员读取到的仍是open状态
+	assert.Equal(t, dataBase.ErrReportChanged, store.UpdateReport(&dataBase.Report{ID: "a", Status: dataBase.ReportResolved, Resolution: dataBase.ResolutionBan},
+		dataBase.ReportOpen, &dataBase.ReportAction{ReportID: "a", Actor: "other", Action: dataBase.ReportActionResolved}))
+	assert.Equal(t, dataBase.ErrReportNotFound, store.UpdateReport(&dataBase.Report{ID: "c"}, dataBase.ReportOpen, &dataBase.ReportAction{}))
+
+	reports, _ := store.ListReports(dataBase.ReportOpen, 10)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "b", reports[0].ID)
+	report, err := store.FindReport("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "MrFirst", report.Reporter)
+	assert.Equal(t, dataBase.ResolutionWarning, report.Resolution)
+	actions, _ := store.ListActions("a")
+	assert.Len(t, actions, 2)
+	assert.Equal(t, dataBase.ReportActionResolved, actions[1].Action)
+	_, err = store.FindReport("c")
+	assert.Equal(t, dataBase.ErrReportNotFound, err)
+}
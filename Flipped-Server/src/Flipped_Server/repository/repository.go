@@ -0,0 +1,298 @@
+// @Title  repository.go
+// @Description  To provide storage interfaces of users, friends, sessions and presence to the Server
+// @Author  郑康
+// @Update  郑康 2026.10.19
+package repository
+
+import (
+	"Flipped_Server/dataBase"
+	"Flipped_Server/logger"
+)
+
+// UserStore负责用户信息的读写，默认实现基于mysql
+type UserStore interface {
+	FindUserInfo(username string, pwd string) (*dataBase.UserInfoTable, error)
+	FindUsersByNames(usernames []string) ([]*dataBase.UserInfoTable, error)
+	DoesUserExist(username string) bool
+	InsertUser(user *dataBase.UserInfoTable) error
+	UpdateUser(username string, fields map[string]string) error
+	SampleUsers(limit int) ([]*dataBase.UserInfoTable, error)
+	SearchUsers(query *dataBase.UserQuery) ([]*dataBase.UserInfoTable, error)
+	FindCandidates(query *dataBase.CandidateQuery) ([]*dataBase.UserInfoTable, error)
+	AccountStatus(username string) (*dataBase.AccountStatus, error)
+	SetAccountStatus(status *dataBase.AccountStatus, change *dataBase.AccountStatusChange) error
+	AccountStatusChanges(username string) ([]dataBase.AccountStatusChange, error)
+}
+
+// FriendStore负责好友关系的读写，默认实现基于MongoDB
+type FriendStore interface {
+	GetFriendList(username string) ([]string, error)
+	InitFriendList(username string) error
+	AddFriend(sourceUser string, targetUser string) error
+	AddMutualFriends(userA string, userB string) error
+	DeleteFriend(sourceUser string, targetUser string) error
+}
+
+// FriendRequestStore负责保存尚未处理的好友请求，默认实现基于MongoDB
+// 同一方向的请求最多一条，重复发送时SendRequest返回dataBase.ErrFriendRequestExists，删除不存在的请求时RemoveRequest返回dataBase.ErrFriendRequestNotFound
+type FriendRequestStore interface {
+	SendRequest(sourceUser string, targetUser string) error
+	HasRequest(sourceUser string, targetUser string) bool
+	ListIncoming(username string) ([]dataBase.FriendRequest, error)
+	ListOutgoing(username string) ([]dataBase.FriendRequest, error)
+	RemoveRequest(sourceUser string, targetUser string) error
+}
+
+// BlockStore负责保存用户的黑名单，默认实现基于MongoDB
+type BlockStore interface {
+	Block(sourceUser string, targetUser string) error
+	Unblock(sourceUser string, targetUser string) error
+	GetBlockList(username string) ([]string, error)
+	GetBlockedBy(username string) ([]string, error)
+	IsBlocked(sourceUser string, targetUser string) (bool, error)
+}
+
+// SwipeStore负责保存用户对推荐对象的喜欢或跳过决定，默认实现基于MongoDB
+type SwipeStore interface {
+	RecordSwipe(sourceUser string, targetUser string, liked bool) error
+	GetSwipe(sourceUser string, targetUser string) (*dataBase.Swipe, bool)
+	SwipedUsers(sourceUser string, since int64) ([]string, error)
+	SwipesSince(since int64) ([]dataBase.Swipe, error)
+}
+
+// MatchStore负责保存互相喜欢的配对关系，默认实现基于MongoDB
+type MatchStore interface {
+	CreateMatch(userA string, userB string) error
+	IsMatched(userA string, userB string) bool
+	ListMatches(username string) ([]dataBase.Match, error)
+	Unmatch(userA string, userB string) error
+	ListUnmatched(username string) ([]string, error)
+}
+
+// MessageStore负责保存用户之间的聊天记录，默认实现基于MongoDB
+type MessageStore interface {
+	SaveMessage(sourceUser string, targetUser string, content string) error
+	ListMessages(userA string, userB string, limit int) ([]dataBase.Message, error)
+	LastMessages(username string, partners []string) (map[string]dataBase.Message, error)
+	MessagePairsSince(since int64) ([]dataBase.MessagePair, error)
+	MessagesSentAt(sourceUser string, targetUser string, createdAt int64) ([]dataBase.Message, error)
+}
+
+// LocationStore负责保存用户上报的粗略位置，默认实现基于MongoDB
+type LocationStore interface {
+	UpdateLocation(username string, latitude float64, longitude float64) error
+	GetLocations(usernames []string) (map[string]dataBase.Location, error)
+	UsersWithin(minLatitude float64, maxLatitude float64, minLongitude float64, maxLongitude float64) ([]string, error)
+}
+
+// SessionStore负责保存用户登录后的token，默认实现基于Redis db0
+// 账号的状态同样缓存在其中，避免每次解析token都查询MySQL，ReadAccountStatus在未缓存时返回nil
+type SessionStore interface {
+	SaveToken(username string, token string) error
+	ReadToken(username string) (string, error)
+	HasToken(username string) bool
+	DeleteToken(username string) error
+	SaveAccountStatus(status *dataBase.AccountStatus) error
+	ReadAccountStatus(username string) (*dataBase.AccountStatus, error)
+}
+
+// PresenceStore负责记录用户的在线状态，默认实现基于Redis db2
+// UpdateStatus返回用户是否由离线变为在线，heartbeat表示状态来自http心跳而不是socket连接
+// HasHeartbeat判断用户是否仍在发送http心跳，SetOffline使用户立即变为离线
+type PresenceStore interface {
+	UpdateStatus(username string, heartbeat bool) (bool, error)
+	HasHeartbeat(username string) bool
+	SetOffline(username string) error
+	IsOnline(username string) bool
+	CountOnline() (int, error)
+	OnlineUsers() ([]string, error)
+}
+
+// TagStore负责兴趣标签目录以及用户与标签的对应关系，标签名均已规范化，默认实现基于mysql
+type TagStore interface {
+	SearchTags(prefix string, limit int) ([]dataBase.Tag, error)
+	FindTags(names []string) ([]dataBase.Tag, error)
+	SetUserTags(username string, tagIDs []int) error
+	UserTags(usernames []string) (map[string][]string, error)
+}
+
+// PhotoStore负责用户相册中照片的元数据，照片文件本身由调用者保存，默认实现基于mysql
+// AddPhoto原子地检查相册未满并将照片添加到末尾，相册已有limit张照片时返回dataBase.ErrGalleryFull
+// References返回相册照片和用户头像引用的全部图片id(或旧版本的文件路径)
+type PhotoStore interface {
+	AddPhoto(photo *dataBase.Photo, limit int) error
+	ListPhotos(usernames []string) (map[string][]dataBase.Photo, error)
+	DeletePhoto(username string, photoID string) error
+	Reorder(username string, photoIDs []string) error
+	SetPrimary(username string, photoID string) error
+	References() ([]string, error)
+}
+
+// ImageStore负责图片、图片各尺寸与以内容哈希命名的Blob之间的引用关系及Blob的引用计数，文件本身由调用者保存，默认实现基于mysql
+// ReleaseImage只删除记录，不再被引用的Blob文件由清理任务在宽限期后回收
+// AddReferences在发送消息、提交举报时记录其中引用的图片，ReferencedNames返回这些被引用的图片id(或旧版本的文件名)
+type ImageStore interface {
+	AddImage(image *dataBase.Image) error
+	FindImages(imageIDs []string) (map[string]dataBase.Image, error)
+	ReleaseImage(imageID string) error
+	ListImages() ([]dataBase.Image, error)
+	ListBlobs() ([]dataBase.Blob, error)
+	AddReferences(references []dataBase.ImageReference) error
+	ReferencedNames() ([]string, error)
+}
+
+// ModerationStore负责保存被内容审核规则标记或拒绝的内容，供人工审核，默认实现基于mysql
+type ModerationStore interface {
+	AddFlagged(item *dataBase.FlaggedContent) error
+	ListFlagged(limit int) ([]dataBase.FlaggedContent, error)
+}
+
+// ReportStore负责保存用户举报及其处理过程的审计记录，每次新建或更新举报都同时追加一条审计记录，默认实现基于mysql
+// UpdateReport只在举报仍处于读取时的状态from时更新，否则返回dataBase.ErrReportChanged
+type ReportStore interface {
+	AddReport(report *dataBase.Report, action *dataBase.ReportAction) error
+	ListReports(status string, limit int) ([]dataBase.Report, error)
+	FindReport(reportID string) (*dataBase.Report, error)
+	UpdateReport(report *dataBase.Report, from string, action *dataBase.ReportAction) error
+	ListActions(reportID string) ([]dataBase.ReportAction, error)
+}
+
+// RecommendQueueStore负责保存每个用户预先计算好的推荐队列，默认实现基于Redis db3
+type RecommendQueueStore interface {
+	Replace(username string, candidates []string) error
+	Pop(username string) (string, bool, error)
+	Length(username string) (int, error)
+	Invalidate(username string) error
+}
+
+// Stores汇总了服务器所需的全部存储，由HttpServer和SocketServer共享
+type Stores struct {
+	Users          UserStore
+	Friends        FriendStore
+	FriendRequests FriendRequestStore
+	Blocks         BlockStore
+	Swipes         SwipeStore
+	Matches        MatchStore
+	Messages       MessageStore
+	Locations      LocationStore
+	Sessions       SessionStore
+	Presence       PresenceStore
+	Queues         RecommendQueueStore
+	Tags           TagStore
+	Photos         PhotoStore
+	Images         ImageStore
+	Moderation     ModerationStore
+	Reports        ReportStore
+}
+
+// @title    NewBackendStores
+// @description   			使用mysql、MongoDB、Redis作为后端创建存储，调用前需完成各数据库的初始化
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    *Stores		存储集合
+func NewBackendStores() *Stores {
+	return &Stores{
+		Users:          &MysqlUserStore{},
+		Friends:        &MongoFriendStore{},
+		FriendRequests: &MongoFriendRequestStore{},
+		Blocks:         &MongoBlockStore{},
+		Swipes:         &MongoSwipeStore{},
+		Matches:        &MongoMatchStore{},
+		Messages:       &MongoMessageStore{},
+		Locations:      &MongoLocationStore{},
+		Sessions:       &RedisSessionStore{},
+		Presence:       NewRedisPresenceStore(),
+		Queues:         &RedisRecommendQueueStore{},
+		Tags:           &MysqlTagStore{},
+		Photos:         &MysqlPhotoStore{},
+		Images:         &MysqlImageStore{},
+		Moderation:     &MysqlModerationStore{},
+		Reports:        &MysqlReportStore{},
+	}
+}
+
+// @title    InitBackendStores
+// @description   			初始化mysql数据库、日志模块、Redis数据库、MongoDB数据库，并创建对应的存储
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    *Stores		存储集合
+func InitBackendStores() *Stores {
+	dataBase.Init()
+	logger.InitLog()
+	dataBase.RedisClientInit()
+	dataBase.InitializeMongoDB()
+	return NewBackendStores()
+}
+
+// @title    NewMemoryStores
+// @description   			创建基于内存的存储，用于测试或不依赖数据库的本地调试
+// @auth      郑康       	2026.10.19
+// @param     void
+// @return    *Stores		存储集合
+func NewMemoryStores() *Stores {
+	users := NewMemoryUserStore()
+	tags := NewMemoryTagStore()
+	users.tags = tags
+	return &Stores{
+		Users:          users,
+		Friends:        NewMemoryFriendStore(users),
+		FriendRequests: NewMemoryFriendRequestStore(),
+		Blocks:         NewMemoryBlockStore(),
+		Swipes:         NewMemorySwipeStore(),
+		Matches:        NewMemoryMatchStore(),
+		Messages:       NewMemoryMessageStore(),
+		Locations:      NewMemoryLocationStore(),
+		Sessions:       NewMemorySessionStore(),
+		Presence:       NewMemoryPresenceStore(),
+		Queues:         NewMemoryRecommendQueueStore(),
+		Tags:           tags,
+		Photos:         NewMemoryPhotoStore(),
+		Images:         NewMemoryImageStore(),
+		Moderation:     NewMemoryModerationStore(),
+		Reports:        NewMemoryReportStore(),
+	}
+}
+
+// @title    IsBlockedBetween
+// @description   			判断两个用户之间是否有任意一方拉黑了另一方
+// @auth      郑康       	2026.10.19
+// @param     BlockStore, string, string	黑名单存储, 用户A, 用户B
+// @return    bool, error			是否存在拉黑关系, 读取失败时的错误信息, 调用者应在出错时拒绝请求
+func IsBlockedBetween(blocks BlockStore, userA string, userB string) (bool, error) {
+	blocked, err := blocks.IsBlocked(userA, userB)
+	if err != nil || blocked {
+		return blocked, err
+	}
+	return blocks.IsBlocked(userB, userA)
+}
+
+// @title    LocationOf
+// @description   			获取单个用户的位置
+// @auth      郑康       	2026.10.19
+// @param     LocationStore, string	位置存储, 用户名
+// @return    *dataBase.Location, bool	位置, 是否上报过位置
+func LocationOf(locations LocationStore, username string) (*dataBase.Location, bool) {
+	res, err := locations.GetLocations([]string{username})
+	if err != nil {
+		return nil, false
+	}
+	location, ok := res[username]
+	return &location, ok
+}
+
+// @title    BlockedUsersOf
+// @description   			获取与用户存在拉黑关系(拉黑了对方或被对方拉黑)的全部用户
+// @auth      郑康       	2026.10.19
+// @param     BlockStore, string	黑名单存储, 用户名
+// @return    []string, error	用户列表, 错误信息
+func BlockedUsersOf(blocks BlockStore, username string) ([]string, error) {
+	blockList, err := blocks.GetBlockList(username)
+	if err != nil {
+		return nil, err
+	}
+	blockedBy, err := blocks.GetBlockedBy(username)
+	if err != nil {
+		return nil, err
+	}
+	return append(blockList, blockedBy...), nil
+}
@@ -12,7 +12,9 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 //包内全局字符串列表，将tag分类，在stringAttr内的tag表示其字段为字符串，在intAttr内的tag表示器字段为整型
@@ -81,14 +83,14 @@ func splitDataAndStruct(metaData interface{}) ([]string, []string, error) {
 		}
 
 		tagArr[i] = tagVal
-		dataArr[i] = dataVal.Field(i).String()
+		dataArr[i] = fmt.Sprint(dataVal.Field(i).Interface())
 	}
 
 	return tagArr, dataArr, nil
 }
 
 // @title    Insert
-// @description   Insert接口函数, 通过给定的结构体和表名来进行Insert操作
+// @description   Insert接口函数, 通过给定的结构体和表名来进行Insert操作, 按tag显式列出列名, 不依赖数据表的列顺序
 // @auth      郑康           					2020.5.17
 // @param     interface{}, string				接口变量, 数据表名称
 // @return    error								错误信息
@@ -101,12 +103,12 @@ func Insert(data interface{}, tableName string) error {
 	}
 
 	var buffer bytes.Buffer
-	buffer.WriteString(fmt.Sprintf("INSERT INTO im.%s VALUES(\n", tableName))
+	buffer.WriteString(fmt.Sprintf("INSERT INTO im.%s (%s) VALUES(\n", tableName, strings.Join(tagArr, ", ")))
 
 	for i := 0; i < tagLen; i++ {
 		if isStringAttr(tagArr[i]) {
 			buffer.WriteString("'")
-			buffer.WriteString(dataArr[i])
+			buffer.WriteString(escapeString(dataArr[i]))
 			buffer.WriteString("'")
 		} else if isIntAttr(tagArr[i]) {
 			integer, _ := strconv.Atoi(dataArr[i])
@@ -141,54 +143,83 @@ func Insert(data interface{}, tableName string) error {
 }
 
 // @title    Update
-// @description   Update接口函数，通过给定旧数据和新数据来进行Update操作
+// @description   Update接口函数，通过给定旧数据(筛选条件)和新数据来进行Update操作
 // @auth      郑康           											2020.5.17
 // @param     *map[string]string, *map[string]string, string			旧数据, 新数据, 表名
 // @return    error														错误信息
 func Update(oldData *map[string]string, newData *map[string]string, tableName string) error {
-	var buffer bytes.Buffer
-	buffer.WriteString("Update im." + tableName + " set ")
+	sql, err := buildUpdateSQL(*oldData, *newData, tableName)
+	if err != nil {
+		return err
+	}
+	logger.Logger.WithFields(logrus.Fields{
+		"function": "Update",
+		"cause":    "display sql",
+	}).Info(sql)
 
-	newDataLen := len(*newData)
-	index := 0
-	for key, value := range *newData {
-		if isStringAttr(key) {
-			buffer.WriteString(key + "='" + value + "'")
-		} else if isIntAttr(key) {
-			integer, _ := strconv.Atoi(value)
-			buffer.WriteString(key + "=" + strconv.Itoa(integer))
-		} else {
-			return errors.New("unexpected value: " + key)
-		}
-		if index != newDataLen-1 {
-			buffer.WriteString(", ")
-		}
-		index++
+	res, err := dataBase.ExecSQL(sql)
+	if err != nil {
+		return err
 	}
+	logger.Logger.WithFields(logrus.Fields{
+		"function": "Update",
+		"cause":    "succeed to update data in database",
+	}).Info(res)
+	return nil
+}
+
+// @title    buildUpdateSQL
+// @description   根据旧数据和新数据拼接Update语句, 按列名排序以保证语句稳定
+// @auth      郑康           											2026.10.19
+// @param     map[string]string, map[string]string, string			旧数据, 新数据, 表名
+// @return    string, error												sql语句, 错误信息
+func buildUpdateSQL(oldData map[string]string, newData map[string]string, tableName string) (string, error) {
+	if len(newData) == 0 || len(oldData) == 0 {
+		return "", errors.New("both new data and condition are required")
+	}
+	setArr, err := assignments(newData)
+	if err != nil {
+		return "", err
+	}
+	whereArr, err := assignments(oldData)
+	if err != nil {
+		return "", err
+	}
+	return "Update im." + tableName + " set " + strings.Join(setArr, ", ") + " where " + strings.Join(whereArr, " and ") + ";", nil
+}
 
-	index = 0
-	buffer.WriteString(" where ")
-	for key, value := range *oldData {
+// @title    assignments
+// @description   将键值对转换为按列名排序的"列=值"字符串列表, 字符串值会被转义
+// @auth      郑康           					2026.10.19
+// @param     map[string]string				键值对
+// @return    []string, error					"列=值"列表, 错误信息
+func assignments(data map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	res := make([]string, len(keys))
+	for i, key := range keys {
 		if isStringAttr(key) {
-			buffer.WriteString(key + "='" + value + "'")
+			res[i] = key + "='" + escapeString(data[key]) + "'"
 		} else if isIntAttr(key) {
-			buffer.WriteString(key + "=" + value)
+			integer, _ := strconv.Atoi(data[key])
+			res[i] = key + "=" + strconv.Itoa(integer)
 		} else {
-			return errors.New("unexpected value: " + key)
-		}
-		if index != newDataLen-1 {
-			buffer.WriteString(" and ")
+			return nil, errors.New("unexpected value: " + key)
 		}
 	}
-	buffer.WriteString(";")
-	sql := buffer.String()
-	logger.Logger.WithFields(logrus.Fields{
-		"function": "Update",
-		"cause":    "display sql",
-	}).Info(sql)
+	return res, nil
+}
 
-	fmt.Println(sql)
-	return nil
+// @title    escapeString
+// @description   转义字符串中的反斜杠和引号, 防止拼接sql时被注入
+// @auth      郑康           2026.10.19
+// @param     string		原始字符串
+// @return    string		转义后的字符串
+func escapeString(str string) string {
+	return strings.NewReplacer("\\", "\\\\", "'", "\\'", "\"", "\\\"", "\x00", "\\0", "\n", "\\n", "\r", "\\r").Replace(str)
 }
 
 // @title    	Delete
@@ -213,7 +244,7 @@ func Delete(data interface{}, tableName string) error {
 
 		if isStringAttr(tagArr[i]) {
 			if dataArr[i] != stringDefault {
-				buffer.WriteString(tagArr[i] + "='" + dataArr[i] + "' ")
+				buffer.WriteString(tagArr[i] + "='" + escapeString(dataArr[i]) + "' ")
 			}
 		} else if isIntAttr(tagArr[i]) {
 			integer, _ := strconv.Atoi(dataArr[i])
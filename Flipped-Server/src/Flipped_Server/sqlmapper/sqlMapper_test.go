@@ -0,0 +1,30 @@
+package sqlmapper
+
+import (
+	"Flipped_Server/dataBase"
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestSplitDataAndStruct(t *testing.T) {
+	tagArr, dataArr, err := splitDataAndStruct(dataBase.UserInfoTable{Username: "MrFirst", UserType: 1, Age: 20})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "username", tagArr[1])
+	assert.Equal(t, "MrFirst", dataArr[1])
+	assert.Equal(t, "1", dataArr[3])
+	assert.Equal(t, "20", dataArr[8])
+}
+
+func TestBuildUpdateSQL(t *testing.T) {
+	sql, err := buildUpdateSQL(map[string]string{"username": "MrFirst"}, map[string]string{"region": "Wuhan", "age": "20"}, "userinfo")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Update im.userinfo set age=20, region='Wuhan' where username='MrFirst';", sql)
+
+	sql, _ = buildUpdateSQL(map[string]string{"username": "MrFirst"}, map[string]string{"hobby": "it's"}, "userinfo")
+	assert.Equal(t, "Update im.userinfo set hobby='it\\'s' where username='MrFirst';", sql)
+
+	_, err = buildUpdateSQL(map[string]string{"username": "MrFirst"}, map[string]string{"unknown": "1"}, "userinfo")
+	assert.NotEqual(t, nil, err)
+	_, err = buildUpdateSQL(map[string]string{"username": "MrFirst"}, map[string]string{}, "userinfo")
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"math"
+)
+
+// 地球平均半径(千米)
+const earthRadiusKm = 6371.0
+
+// 坐标保留的小数位数，两位小数约为1千米的精度，避免保存用户的精确位置
+const coordinatePrecision = 100
+
+// @title    RoundCoordinate
+// @description   			将经纬度四舍五入到两位小数
+// @auth      郑康       	2026.10.19
+// @param     float64		经度或纬度
+// @return    float64		取整后的值
+func RoundCoordinate(value float64) float64 {
+	return math.Round(value*coordinatePrecision) / coordinatePrecision
+}
+
+// @title    IsValidCoordinate
+// @description   			判断经纬度是否在合法范围内
+// @auth      郑康       	2026.10.19
+// @param     float64, float64	纬度, 经度
+// @return    bool			是否合法
+func IsValidCoordinate(latitude float64, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+// @title    DistanceKm
+// @description   			使用Haversine公式计算两点之间的球面距离
+// @auth      郑康       	2026.10.19
+// @param     float64, float64, float64, float64	纬度A, 经度A, 纬度B, 经度B
+// @return    float64		距离(千米)
+func DistanceKm(latitudeA float64, longitudeA float64, latitudeB float64, longitudeB float64) float64 {
+	toRadian := func(degree float64) float64 { return degree * math.Pi / 180 }
+	deltaLatitude := toRadian(latitudeB - latitudeA)
+	deltaLongitude := toRadian(longitudeB - longitudeA)
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(toRadian(latitudeA))*math.Cos(toRadian(latitudeB))*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// @title    ApproximateKm
+// @description   			将距离向上取整为展示给用户的近似千米数，不足1千米时显示为1千米
+// @auth      郑康       	2026.10.19
+// @param     float64		距离(千米)
+// @return    int			近似距离(千米)
+func ApproximateKm(distance float64) int {
+	return int(math.Max(1, math.Ceil(distance)))
+}
+
+// @title    BoundingBox
+// @description   			计算包含以某点为中心、半径为distance千米的圆的经纬度范围，范围跨越极点或180度经线时经度不做限制
+// @auth      郑康       	2026.10.19
+// @param     float64, float64, float64	纬度, 经度, 半径(千米)
+// @return    float64, float64, float64, float64	最小纬度, 最大纬度, 最小经度, 最大经度
+func BoundingBox(latitude float64, longitude float64, distance float64) (float64, float64, float64, float64) {
+	deltaLatitude := distance / earthRadiusKm * 180 / math.Pi
+	minLatitude, maxLatitude := latitude-deltaLatitude, latitude+deltaLatitude
+	if minLatitude <= -90 || maxLatitude >= 90 {
+		return math.Max(minLatitude, -90), math.Min(maxLatitude, 90), -180, 180
+	}
+	ratio := math.Sin(distance/earthRadiusKm) / math.Cos(latitude*math.Pi/180)
+	if ratio >= 1 {
+		return minLatitude, maxLatitude, -180, 180
+	}
+	deltaLongitude := math.Asin(ratio) * 180 / math.Pi
+	minLongitude, maxLongitude := longitude-deltaLongitude, longitude+deltaLongitude
+	if minLongitude < -180 || maxLongitude > 180 {
+		return minLatitude, maxLatitude, -180, 180
+	}
+	return minLatitude, maxLatitude, minLongitude, maxLongitude
+}
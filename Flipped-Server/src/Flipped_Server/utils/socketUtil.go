@@ -3,11 +3,16 @@ package utils
 import (
 	"net"
 	"sync"
+	"time"
 )
 
 var (
 	UserConnectionMap map[string]net.Conn
 	RWLock            = &sync.RWMutex{}
+	//WriteTimeout是向客户端连接写入的超时时间, 避免不读取数据的客户端阻塞写入方
+	WriteTimeout = 5 * time.Second
+	//writeLocks保存每个连接的写锁(*sync.Mutex), 同一连接上的写入和写超时不会互相干扰
+	writeLocks sync.Map
 )
 
 type FromClientMsg struct {
@@ -27,6 +32,30 @@ type ReplyMsg struct {
 	MsgContent string `json:"MsgContent"`
 }
 
+// 服务器主动推送给客户端的通知类型
+const (
+	NotifyFriendRequest         = "friendRequest"
+	NotifyFriendRequestAccepted = "friendRequestAccepted"
+	NotifyNewMatch              = "newMatch"
+	NotifyWarning               = "warning"
+	NotifyAccountRestricted     = "accountRestricted"
+	NotifyPresence              = "presence"
+	NotifyPresenceSnapshot      = "presenceSnapshot"
+)
+
+// presence通知的内容，presenceSnapshot通知的内容为在线好友用户名的json数组
+const (
+	PresenceOnline  = "online"
+	PresenceOffline = "offline"
+)
+
+// NotificationMsg是服务器主动推送给客户端的通知，Notification字段区分通知类型
+type NotificationMsg struct {
+	Notification string `json:"Notification"`
+	MsgFrom      string `json:"MsgFrom"`
+	MsgContent   string `json:"MsgContent"`
+}
+
 type Recorder struct {
 	TargetUser string `bson:"targetUser"`
 	SourceUser string `bson:"sourceUser"`
@@ -51,10 +80,51 @@ func GetUserConnection(username string) net.Conn {
 	}
 }
 
-func DeleteConnection(conn net.Conn) {
+// RemoveUserConnection从UserConnectionMap中移除用户的连接并返回该连接，用户不在线时返回nil
+func RemoveUserConnection(username string) net.Conn {
+	RWLock.Lock()
+	defer RWLock.Unlock()
+	conn, ok := UserConnectionMap[username]
+	if !ok {
+		return nil
+	}
+	delete(UserConnectionMap, username)
+	return conn
+}
+
+// RegisterConnection记录用户的连接，返回该连接是否是新建立的(之前没有记录或记录的是其他连接)
+func RegisterConnection(username string, conn net.Conn) bool {
+	RWLock.Lock()
+	defer RWLock.Unlock()
+	curConn, ok := UserConnectionMap[username]
+	UserConnectionMap[username] = conn
+	return !ok || curConn != conn
+}
+
+// WriteToConnection在该连接的写锁内写入数据，写入前设置WriteTimeout的写超时，结束后清除
+// 所有向用户连接的写入都应经过这里，避免并发写入交错或互相清除对方的写超时
+func WriteToConnection(conn net.Conn, buf []byte) (int, error) {
+	lock, _ := writeLocks.LoadOrStore(conn, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+	if err := conn.SetWriteDeadline(time.Now().Add(WriteTimeout)); err != nil {
+		return 0, err
+	}
+	defer conn.SetWriteDeadline(time.Time{})
+	return conn.Write(buf)
+}
+
+// DeleteConnection从UserConnectionMap中移除该连接及其写锁，返回使用该连接的用户名
+func DeleteConnection(conn net.Conn) []string {
+	writeLocks.Delete(conn)
+	RWLock.Lock()
+	defer RWLock.Unlock()
+	usernames := []string{}
 	for username, curConn := range UserConnectionMap {
 		if curConn == conn {
 			delete(UserConnectionMap, username)
+			usernames = append(usernames, username)
 		}
 	}
+	return usernames
 }
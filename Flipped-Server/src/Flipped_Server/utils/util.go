@@ -13,7 +13,6 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
-	"strings"
 )
 
 var ExitFlag chan bool
@@ -26,23 +25,6 @@ func GeneratorUUID() string {
 	return u2.String()
 }
 
-func GetImageURL(imageName string, imageData []byte) string {
-	imageDir := filepath.Dir(GetCurrentPath()) + "/imageContainer/"
-	if !Exists(imageDir) {
-		panic(errors.New("image Dir does't exist"))
-	}
-	imageName = imageDir + imageName
-	imageName = strings.Replace(imageName, "\\", "/", -1)
-	f, err := os.OpenFile(imageName, os.O_WRONLY|os.O_CREATE, 0777)
-	if err != nil {
-		panic(err)
-	}
-	_, _ = f.Write(imageData)
-	defer f.Close()
-
-	return imageName
-}
-
 func GetCurrentPath() string {
 	_, filename, _, ok := runtime.Caller(1)
 	var cwdPath string
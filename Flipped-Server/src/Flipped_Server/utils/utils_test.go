@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/go-playground/assert/v2"
+	"net"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestGeneratorUUID(t *testing.T) {
@@ -43,3 +47,49 @@ func TestAesEncrypt(t *testing.T) {
 func TestAesDecrypt(t *testing.T) {
 	assert.Equal(t, "mysql", AesDecrypt("imLkEPRpDT8QHX+B2i5oFg==", "You Are Thieves "))
 }
+
+func TestGeo(t *testing.T) {
+	assert.Equal(t, 30.59, RoundCoordinate(30.5928))
+	assert.Equal(t, -114.31, RoundCoordinate(-114.3055))
+	assert.Equal(t, true, IsValidCoordinate(30.59, 114.31))
+	assert.Equal(t, false, IsValidCoordinate(91, 0))
+	assert.Equal(t, false, IsValidCoordinate(0, -181))
+	// 武汉到北京约1050千米
+	distance := DistanceKm(30.59, 114.31, 39.90, 116.41)
+	assert.Equal(t, true, distance > 1000 && distance < 1100)
+	assert.Equal(t, float64(0), DistanceKm(30.59, 114.31, 30.59, 114.31))
+	assert.Equal(t, 1, ApproximateKm(0.2))
+	assert.Equal(t, 13, ApproximateKm(12.1))
+	// 范围的边界点到中心的距离不小于半径
+	minLatitude, maxLatitude, minLongitude, maxLongitude := BoundingBox(30.59, 114.31, 100)
+	assert.Equal(t, true, DistanceKm(30.59, 114.31, minLatitude, 114.31) >= 99.9)
+	assert.Equal(t, true, DistanceKm(30.59, 114.31, maxLatitude, 114.31) >= 99.9)
+	assert.Equal(t, true, DistanceKm(30.59, 114.31, 30.59, minLongitude) >= 99.9)
+	assert.Equal(t, true, DistanceKm(30.59, 114.31, 30.59, maxLongitude) >= 99.9)
+	_, _, minLongitude, maxLongitude = BoundingBox(89.5, 0, 100)
+	assert.Equal(t, float64(-180), minLongitude)
+	assert.Equal(t, float64(180), maxLongitude)
+}
+
+func TestWriteToConnection(t *testing.T) {
+	timeout := WriteTimeout
+	WriteTimeout = 50 * time.Millisecond
+	defer func() { WriteTimeout = timeout }()
+	client, conn := net.Pipe()
+	defer client.Close()
+	//客户端不读取时写入超时
+	_, err := WriteToConnection(conn, []byte("{}"))
+	assert.NotEqual(t, nil, err)
+	//并发写入的消息完整送达, 上一次的写超时不会影响之后的写入
+	for i := 0; i < 20; i++ {
+		go func(i int) {
+			buf, _ := json.Marshal(NotificationMsg{MsgContent: strconv.Itoa(i)})
+			_, _ = WriteToConnection(conn, buf)
+		}(i)
+	}
+	decoder := json.NewDecoder(client)
+	for i := 0; i < 20; i++ {
+		var msg NotificationMsg
+		assert.Equal(t, nil, decoder.Decode(&msg))
+	}
+}